@@ -0,0 +1,72 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "reflect"
+)
+
+// FirstAndLastConsumer captures the first and last values of a Stream
+// while reading the whole Stream. Unlike FirstOnly, it does not stop
+// early, so it can sit alongside other consumers under Compose.
+type FirstAndLastConsumer struct {
+  firstPtr interface{}
+  lastPtr interface{}
+  temp interface{}
+  copier functional.Copier
+  count int
+  err error
+}
+
+// FirstAndLast returns a FirstAndLastConsumer of T. firstPtr and lastPtr
+// are *T where the first and last values are stored. c is a Copier of T;
+// nil means the Copier registered for T or simple assignment.
+func FirstAndLast(
+    firstPtr, lastPtr interface{}, c functional.Copier) *FirstAndLastConsumer {
+  if c == nil {
+    c = functional.CopierFor(lastPtr)
+  }
+  return &FirstAndLastConsumer{
+      firstPtr: firstPtr,
+      lastPtr: lastPtr,
+      temp: reflect.New(reflect.TypeOf(lastPtr).Elem()).Interface(),
+      copier: c}
+}
+
+// Consume reads all of s, a Stream of T, storing its first and last values.
+// If s is empty, the values at firstPtr and lastPtr are left unchanged.
+func (f *FirstAndLastConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  f.count = 0
+  f.err = nil
+  var err error
+  for err = s.Next(f.temp); err == nil; err = s.Next(f.temp) {
+    if f.count == 0 {
+      f.copier(f.temp, f.firstPtr)
+    }
+    f.copier(f.temp, f.lastPtr)
+    f.count++
+  }
+  if err != functional.Done {
+    f.err = err
+  }
+}
+
+// Found returns true if the last call to Consume found at least one value.
+func (f *FirstAndLastConsumer) Found() bool {
+  return f.count > 0
+}
+
+// Count returns the number of values read by the last call to Consume.
+func (f *FirstAndLastConsumer) Count() int {
+  return f.count
+}
+
+// Error returns any error from last call to Consume.
+func (f *FirstAndLastConsumer) Error() error {
+  return f.err
+}
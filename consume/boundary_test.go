@@ -0,0 +1,52 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestFirstAndLast(t *testing.T) {
+  var first, last int
+  fl := FirstAndLast(&first, &last, nil)
+  b := NewGrowingBuffer(intSlice, 1)
+  stream := &closeChecker{Stream: functional.Slice(functional.Count(), 3, 8)}
+  c := Compose(new(int), nil, fl, b)
+  c.Consume(stream)
+  verifyClosed(t, stream)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if !fl.Found() || fl.Count() != 5 {
+    t.Errorf("Expected 5 values found, got %d", fl.Count())
+  }
+  if first != 3 || last != 7 {
+    t.Errorf("Expected 3 and 7, got %d and %d", first, last)
+  }
+  verifyFetched(t, b, 3, 8)
+}
+
+func TestFirstAndLastEmpty(t *testing.T) {
+  first, last := -1, -1
+  fl := FirstAndLast(&first, &last, nil)
+  fl.Consume(functional.NilStream())
+  if fl.Found() || fl.Error() != nil {
+    t.Error("Expected nothing found and no error")
+  }
+  if first != -1 || last != -1 {
+    t.Errorf("Expected values unchanged, got %d and %d", first, last)
+  }
+}
+
+func TestFirstAndLastError(t *testing.T) {
+  var first, last int
+  fl := FirstAndLast(&first, &last, nil)
+  fl.Consume(errorStream{otherError})
+  if err := fl.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
@@ -8,6 +8,7 @@ package consume
 
 import (
   "github.com/keep94/gofunctional2/functional"
+  "math/rand"
   "reflect"
 )
 
@@ -23,9 +24,10 @@ type ErrorReportingConsumer interface {
 // that sends values it consumes to each one of consumers. The returned
 // ErrorReportingConsumer reports an error if any of consumers reports
 // an error. ptr is a *T where T values being consumed are temporarily held;
-// copier knows how to copy the values of type T being consumed
-// (can be nil if simple assignment should be used). If caller passes a slice
-// for consumers, no copy is made of it.
+// copier knows how to copy the values of type T being consumed. Passing
+// nil for copier means use the Copier registered for T or simple
+// assignment if there is none. If caller passes a slice for consumers, no
+// copy is made of it.
 func Compose(
     ptr interface{},
     copier functional.Copier,
@@ -158,11 +160,16 @@ func NewPtrGrowingBuffer(
   return result
 }
 
-// Consume fetches the values. s is a Stream of T.
+// Consume fetches the values. s is a Stream of T. If s implements
+// functional.LenHinter, Consume grows the buffer to the needed size up front.
 func (g *GrowingBuffer) Consume(s functional.Stream) {
   defer s.Close()
   g.err = nil
   g.idx = 0
+  if n, ok := functional.LenHintOf(s); ok && n + 1 > g.buffer.Len() {
+    // One extra slot lets Consume see the end without growing again.
+    g.buffer = g.ensureCapacity(g.buffer, n + 1)
+  }
   for g.err == nil {
     bufLen := g.buffer.Len()
     if g.idx == bufLen {
@@ -345,6 +352,45 @@ func FirstOnly(stream functional.Stream, emptyError error, ptr interface{}) (err
   return
 }
 
+// NthOnly reads the nth value from stream storing it in ptr. n is
+// 0-based so that NthOnly(stream, 0, emptyError, ptr) behaves like
+// FirstOnly. NthOnly closes the stream.
+// NthOnly returns emptyError if stream has n or fewer values.
+func NthOnly(
+    stream functional.Stream,
+    n int,
+    emptyError error,
+    ptr interface{}) (err error) {
+  return FirstOnly(functional.Slice(stream, n, n + 1), emptyError, ptr)
+}
+
+// RandomOne reads all of stream and stores one of its values chosen
+// uniformly at random using rng in ptr. RandomOne closes the stream.
+// RandomOne returns functional.Done if no values were on stream, in
+// which case ptr is left unchanged.
+func RandomOne(
+    stream functional.Stream, rng *rand.Rand, ptr interface{}) (err error) {
+  defer func() {
+    closeError := stream.Close()
+    if err == nil {
+      err = closeError
+    }
+  }()
+  temp := reflect.New(reflect.TypeOf(ptr).Elem()).Interface()
+  copier := functional.CopierFor(ptr)
+  count := 0
+  for err = stream.Next(temp); err == nil; err = stream.Next(temp) {
+    count++
+    if rng.Intn(count) == 0 {
+      copier(temp, ptr)
+    }
+  }
+  if err == functional.Done && count > 0 {
+    err = nil
+  }
+  return
+}
+
 type compositeConsumer struct {
   ptr interface{}
   copier functional.Copier
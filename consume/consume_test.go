@@ -8,6 +8,7 @@ package consume
 import (
   "errors"
   "github.com/keep94/gofunctional2/functional"
+  "math/rand"
   "testing"
 )
 
@@ -111,6 +112,19 @@ func TestGrowingBufferBig(t *testing.T) {
   }
 }
 
+func TestGrowingBufferLenHint(t *testing.T) {
+  values := make([]int, 100)
+  for i := range values {
+    values[i] = i
+  }
+  b := NewGrowingBuffer(intSlice, 1)
+  b.Consume(functional.NewStreamFromValues(values, nil))
+  verifyFetched(t, b, 0, 100)
+  if actual := cap(b.Values().([]int)); actual != 101 {
+    t.Errorf("Expected capacity of 101, got %v", actual)
+  }
+}
+
 func TestGrowingBufferError(t *testing.T) {
   stream := &closeChecker{Stream: errorStream{otherError}}
   b := NewGrowingBuffer(intSlice, 5)
@@ -282,6 +296,67 @@ func TestFirstOnlyCloseError(t *testing.T) {
   }
 }
 
+func TestNthOnly(t *testing.T) {
+  stream := &closeChecker{Stream: functional.CountFrom(3, 1)}
+  var value int
+  if output := NthOnly(stream, 2, emptyError, &value); output != nil {
+    t.Errorf("Got error fetching nth value, %v", output)
+  }
+  if value != 5 {
+    t.Errorf("Expected 5, got %v", value)
+  }
+  verifyClosed(t, stream)
+}
+
+func TestNthOnlyEmpty(t *testing.T) {
+  stream := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 2)}
+  var value int
+  if output := NthOnly(stream, 2, emptyError, &value); output != emptyError {
+    t.Errorf("Expected emptyError, got %v", output)
+  }
+  verifyClosed(t, stream)
+}
+
+func TestRandomOne(t *testing.T) {
+  rng := rand.New(rand.NewSource(1))
+  counts := make([]int, 4)
+  for i := 0; i < 400; i++ {
+    stream := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 4)}
+    var value int
+    if output := RandomOne(stream, rng, &value); output != nil {
+      t.Fatalf("Got error fetching random value, %v", output)
+    }
+    verifyClosed(t, stream)
+    counts[value]++
+  }
+  for i, c := range counts {
+    if c < 50 {
+      t.Errorf("Expected value %d chosen about 100 times, got %d", i, c)
+    }
+  }
+}
+
+func TestRandomOneEmpty(t *testing.T) {
+  value := -1
+  output := RandomOne(
+      functional.NilStream(), rand.New(rand.NewSource(1)), &value)
+  if output != functional.Done {
+    t.Errorf("Expected Done, got %v", output)
+  }
+  if value != -1 {
+    t.Errorf("Expected -1, got %v", value)
+  }
+}
+
+func TestRandomOneCloseError(t *testing.T) {
+  stream := closeErrorStream{Stream: functional.Slice(functional.Count(), 0, 3)}
+  var value int
+  output := RandomOne(stream, rand.New(rand.NewSource(1)), &value)
+  if output != closeError {
+    t.Errorf("Expected closeError, got %v", output)
+  }
+}
+
 func TestCompose(t *testing.T) {
   consumer1 := errorReportingConsumerForTesting{}
   consumer2 := errorReportingConsumerForTesting{}
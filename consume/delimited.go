@@ -0,0 +1,66 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "encoding/binary"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+)
+
+// Marshaler is implemented by messages that can encode themselves such
+// as those generated by many protocol buffer compilers.
+type Marshaler interface {
+  Marshal() ([]byte, error)
+}
+
+// NewDelimitedWriter returns an ErrorReportingConsumer of T that writes
+// each value it consumes to w preceded by its length encoded as a varint.
+// This is the framing functional.ReadDelimited reads. ptr is a *T that
+// implements Marshaler and temporarily holds consumed values. The returned
+// consumer stops at the first error.
+func NewDelimitedWriter(w io.Writer, ptr Marshaler) ErrorReportingConsumer {
+  return &delimitedWriter{w: w, ptr: ptr}
+}
+
+type delimitedWriter struct {
+  w io.Writer
+  ptr Marshaler
+  err error
+}
+
+func (d *delimitedWriter) Consume(s functional.Stream) {
+  defer s.Close()
+  d.err = nil
+  var err error
+  for err = s.Next(d.ptr); err == nil; err = s.Next(d.ptr) {
+    if err = d.write(); err != nil {
+      d.err = err
+      return
+    }
+  }
+  if err != functional.Done {
+    d.err = err
+  }
+}
+
+func (d *delimitedWriter) Error() error {
+  return d.err
+}
+
+func (d *delimitedWriter) write() error {
+  b, err := d.ptr.Marshal()
+  if err != nil {
+    return err
+  }
+  var size [binary.MaxVarintLen64]byte
+  n := binary.PutUvarint(size[:], uint64(len(b)))
+  if _, err = d.w.Write(size[:n]); err != nil {
+    return err
+  }
+  _, err = d.w.Write(b)
+  return err
+}
@@ -0,0 +1,50 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "bytes"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+type textMessage string
+
+func (m *textMessage) Marshal() ([]byte, error) {
+  return []byte(*m), nil
+}
+
+func (m *textMessage) Unmarshal(b []byte) error {
+  *m = textMessage(b)
+  return nil
+}
+
+func TestDelimitedWriter(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewDelimitedWriter(&buf, new(textMessage))
+  s := &closeChecker{Stream: functional.NewStreamFromValues([]textMessage{"abc", "", "xy"}, nil)}
+  c.Consume(s)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := buf.String(); output != "\x03abc\x00\x02xy" {
+    t.Errorf("Expected framed messages, got %q", output)
+  }
+  var m textMessage
+  rs := functional.ReadDelimited(&buf)
+  if err := rs.Next(&m); err != nil || m != "abc" {
+    t.Errorf("Expected abc, got %v and %v", m, err)
+  }
+}
+
+func TestDelimitedWriterError(t *testing.T) {
+  c := NewDelimitedWriter(&bytes.Buffer{}, new(textMessage))
+  c.Consume(errorStream{otherError})
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
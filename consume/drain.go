@@ -0,0 +1,56 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// Drain feeds streams, each a Stream of T, to c, a Consumer of T, one
+// after another as if they were a single Stream. Drain closes each Stream
+// as soon as c has read it to the end, and closes any Streams c did not get
+// to when c returns. Drain returns the error c reports or, if c reports
+// none, the first error from closing streams.
+func Drain(c ErrorReportingConsumer, streams ...functional.Stream) error {
+  d := &drainStream{streams: streams}
+  c.Consume(d)
+  closeErr := d.Close()
+  if err := c.Error(); err != nil {
+    return err
+  }
+  return closeErr
+}
+
+type drainStream struct {
+  streams []functional.Stream
+  idx int
+  closeErr error
+}
+
+func (d *drainStream) Next(ptr interface{}) error {
+  for d.idx < len(d.streams) {
+    err := d.streams[d.idx].Next(ptr)
+    if err != functional.Done {
+      return err
+    }
+    d.closeCurrent()
+  }
+  return functional.Done
+}
+
+func (d *drainStream) Close() error {
+  for d.idx < len(d.streams) {
+    d.closeCurrent()
+  }
+  return d.closeErr
+}
+
+func (d *drainStream) closeCurrent() {
+  if err := d.streams[d.idx].Close(); d.closeErr == nil {
+    d.closeErr = err
+  }
+  d.idx++
+}
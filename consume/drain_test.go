@@ -0,0 +1,45 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestDrain(t *testing.T) {
+  first := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  second := &closeChecker{Stream: functional.Slice(functional.Count(), 3, 5)}
+  b := NewGrowingBuffer(intSlice, 1)
+  if err := Drain(b, first, second); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, first)
+  verifyClosed(t, second)
+  verifyFetched(t, b, 0, 5)
+}
+
+func TestDrainConsumerStopsEarly(t *testing.T) {
+  first := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  second := &closeChecker{Stream: closeErrorStream{functional.Count()}}
+  b := NewBuffer(make([]int, 2))
+  if err := Drain(b, first, second); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  verifyClosed(t, first)
+  verifyClosed(t, second)
+  verifyFetched(t, b, 0, 2)
+}
+
+func TestDrainConsumerError(t *testing.T) {
+  c := &errorReportingConsumerForTesting{e: consumerError}
+  if err := Drain(c, functional.Slice(functional.Count(), 0, 3)); err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  if output := c.count; output != 3 {
+    t.Errorf("Expected 3, got %v", output)
+  }
+}
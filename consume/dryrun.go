@@ -0,0 +1,46 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "errors"
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// ErrNoDryRun is reported by the consumer DryRun returns for consumers
+// that do not support dry runs.
+var ErrNoDryRun = errors.New("consume: consumer does not support dry run.")
+
+// DryRunner is implemented by consumers that write somewhere and can
+// instead validate what they would write, such as SQLInserter and
+// RollingFiles.
+type DryRunner interface {
+  // DryRun returns a consumer that does everything this consumer does
+  // except make lasting changes.
+  DryRun() ErrorReportingConsumer
+}
+
+// DryRun returns the dry run version of c if c implements DryRunner.
+// Otherwise, so that a dry run never writes by accident, it returns a
+// consumer that closes the Stream it gets without reading it and reports
+// ErrNoDryRun.
+func DryRun(c ErrorReportingConsumer) ErrorReportingConsumer {
+  if d, ok := c.(DryRunner); ok {
+    return d.DryRun()
+  }
+  return noDryRunConsumer{}
+}
+
+type noDryRunConsumer struct {
+}
+
+func (c noDryRunConsumer) Consume(s functional.Stream) {
+  s.Close()
+}
+
+func (c noDryRunConsumer) Error() error {
+  return ErrNoDryRun
+}
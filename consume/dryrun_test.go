@@ -0,0 +1,78 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+func TestDryRunSQLInserter(t *testing.T) {
+  db, fdb := openFakeDb(t, "dryrun")
+  defer db.Close()
+  checkpointed := false
+  policy := CommitPolicy{Every: 2, Checkpoint: func(committed int) error {
+    checkpointed = true
+    return nil
+  }}
+  c := DryRun(NewSQLInserterPolicy(db, "insert", new(int), intArgs, policy))
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := c.(*SQLInserter).Count(); output != 5 {
+    t.Errorf("Expected 5, got %v", output)
+  }
+  if output := fmt.Sprintf("%v %d %d", fdb.committed, fdb.commits, fdb.rollbacks); output != "[] 0 3" {
+    t.Errorf("Expected [] 0 3, got %v", output)
+  }
+  if checkpointed {
+    t.Error("Expected no checkpoints in dry run.")
+  }
+  fdb.failOn = 3
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if c.Error() != execError {
+    t.Errorf("Expected execError, got %v", c.Error())
+  }
+}
+
+func TestDryRunRollingFiles(t *testing.T) {
+  dir, err := ioutil.TempDir("", "dryruntest")
+  if err != nil {
+    t.Fatalf("Error creating temp dir: %v", err)
+  }
+  defer os.RemoveAll(dir)
+  r := NewRollingFiles(filepath.Join(dir, "out-%d.txt"), new(int), renderInt, CommitPolicy{Every: 2}, true)
+  c := DryRun(r)
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := c.(*RollingFiles).Count(); output != 5 {
+    t.Errorf("Expected 5, got %v", output)
+  }
+  if files, _ := ioutil.ReadDir(dir); len(files) != 0 {
+    t.Errorf("Expected no files, got %v", len(files))
+  }
+}
+
+func TestDryRunNotSupported(t *testing.T) {
+  var sent []int
+  c := DryRun(NewSendConsumer(SendFunc(func(ptr interface{}) error {
+    sent = append(sent, *ptr.(*int))
+    return nil
+  }), new(int)))
+  s := &closeChecker{Stream: functional.Count()}
+  c.Consume(s)
+  verifyClosed(t, s)
+  if c.Error() != ErrNoDryRun || len(sent) != 0 {
+    t.Errorf("Expected ErrNoDryRun and nothing sent, got %v", c.Error())
+  }
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// PerGroup returns an ErrorReportingConsumer of T that consumes a Stream
+// of T sorted by key and feeds each run of consecutive values with the
+// same key to its own Consumer. For each group, PerGroup calls newConsumer
+// with the group's key and has the returned Consumer consume a Stream
+// of just that group's values. Once that Consumer returns, the group is
+// finished; values of the group it did not read are skipped. ptr is a *T
+// that temporarily holds values; copier is a Copier of T, nil meaning the
+// Copier registered for T or simple assignment. key returns the key of
+// the T value ptr points to; keys are compared with ==. The returned
+// ErrorReportingConsumer stops at the first group whose Consumer reports
+// an error and reports that error. It closes the Stream it consumes.
+func PerGroup(
+    ptr interface{},
+    copier functional.Copier,
+    key func(ptr interface{}) interface{},
+    newConsumer func(key interface{}) ErrorReportingConsumer) ErrorReportingConsumer {
+  if copier == nil {
+    copier = functional.CopierFor(ptr)
+  }
+  return &perGroupConsumer{
+      ptr: ptr, copier: copier, key: key, newConsumer: newConsumer}
+}
+
+type perGroupConsumer struct {
+  ptr interface{}
+  copier functional.Copier
+  key func(ptr interface{}) interface{}
+  newConsumer func(key interface{}) ErrorReportingConsumer
+  err error
+}
+
+func (p *perGroupConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  p.err = nil
+  err := s.Next(p.ptr)
+  for err == nil {
+    g := &groupStream{parent: p, s: s, key: p.key(p.ptr), pending: true}
+    c := p.newConsumer(g.key)
+    c.Consume(g)
+    if cerr := c.Error(); cerr != nil {
+      p.err = cerr
+      return
+    }
+    err = g.skipRest()
+  }
+  if err != functional.Done {
+    p.err = err
+  }
+}
+
+func (p *perGroupConsumer) Error() error {
+  return p.err
+}
+
+// groupStream emits the values of one group. When it is created, the
+// first value of the group is already at parent.ptr.
+type groupStream struct {
+  parent *perGroupConsumer
+  s functional.Stream
+  key interface{}
+  pending bool
+  done bool
+  err error
+}
+
+func (g *groupStream) Next(ptr interface{}) error {
+  if g.done {
+    return functional.Done
+  }
+  if !g.pending {
+    if err := g.s.Next(g.parent.ptr); err != nil {
+      g.done = true
+      g.err = err
+      return err
+    }
+    if g.parent.key(g.parent.ptr) != g.key {
+      g.done = true
+      return functional.Done
+    }
+  }
+  g.pending = false
+  g.parent.copier(g.parent.ptr, ptr)
+  return nil
+}
+
+func (g *groupStream) Close() error {
+  return nil
+}
+
+// skipRest skips the unread values of this group. It returns nil if the
+// first value of the next group is at parent.ptr.
+func (g *groupStream) skipRest() error {
+  if g.done {
+    return g.err
+  }
+  for {
+    if err := g.s.Next(g.parent.ptr); err != nil {
+      return err
+    }
+    if g.parent.key(g.parent.ptr) != g.key {
+      return nil
+    }
+  }
+}
@@ -0,0 +1,99 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestPerGroup(t *testing.T) {
+  var totals []string
+  c := PerGroup(
+      new(int),
+      nil,
+      func(ptr interface{}) interface{} { return *ptr.(*int) / 10 },
+      func(key interface{}) ErrorReportingConsumer {
+        return &groupTotaler{key: key, totals: &totals}
+      })
+  stream := &closeChecker{Stream: functional.NewStreamFromValues(
+      []int{1, 2, 13, 14, 15, 31}, nil)}
+  c.Consume(stream)
+  verifyClosed(t, stream)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", totals); output != "[0:3 1:42 3:31]" {
+    t.Errorf("Expected [0:3 1:42 3:31], got %v", output)
+  }
+}
+
+func TestPerGroupConsumerStopsEarly(t *testing.T) {
+  var firsts []int
+  c := PerGroup(
+      new(int),
+      nil,
+      func(ptr interface{}) interface{} { return *ptr.(*int) / 10 },
+      func(key interface{}) ErrorReportingConsumer {
+        return Modify(
+            &firstRecorder{firsts: &firsts},
+            func(s functional.Stream) functional.Stream {
+              return functional.Slice(s, 0, 1)
+            })
+      })
+  c.Consume(functional.NewStreamFromValues([]int{1, 2, 13, 14, 15, 31}, nil))
+  if output := fmt.Sprintf("%v", firsts); output != "[1 13 31]" {
+    t.Errorf("Expected [1 13 31], got %v", output)
+  }
+}
+
+func TestPerGroupConsumerError(t *testing.T) {
+  c := PerGroup(
+      new(int),
+      nil,
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      func(key interface{}) ErrorReportingConsumer {
+        return &errorReportingConsumerForTesting{e: consumerError}
+      })
+  c.Consume(functional.Count())
+  if err := c.Error(); err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+}
+
+type groupTotaler struct {
+  key interface{}
+  totals *[]string
+}
+
+func (g *groupTotaler) Consume(s functional.Stream) {
+  total := 0
+  var x int
+  for s.Next(&x) == nil {
+    total += x
+  }
+  *g.totals = append(*g.totals, fmt.Sprintf("%v:%d", g.key, total))
+}
+
+func (g *groupTotaler) Error() error {
+  return nil
+}
+
+type firstRecorder struct {
+  firsts *[]int
+}
+
+func (f *firstRecorder) Consume(s functional.Stream) {
+  var x int
+  for s.Next(&x) == nil {
+    *f.firsts = append(*f.firsts, x)
+  }
+}
+
+func (f *firstRecorder) Error() error {
+  return nil
+}
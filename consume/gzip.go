@@ -0,0 +1,82 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "os"
+)
+
+// NewGzipWriter returns an ErrorReportingConsumer of T that renders each
+// value it consumes and writes the result gzip compressed to w. ptr is
+// a *T that temporarily holds consumed values; render writes the T value
+// ptr points to. Once the Stream is exhausted, the consumer finishes the
+// gzip data but does not close w. Each call to Consume writes a complete
+// gzip member. The consumer stops at the first error but still finishes
+// the gzip data it has written.
+func NewGzipWriter(
+    w io.Writer,
+    ptr interface{},
+    render func(ptr interface{}, w io.Writer) error) ErrorReportingConsumer {
+  return &renderConsumer{
+      create: func() (*fileWriter, error) {
+        return newFileWriter(w, nil, true), nil
+      },
+      ptr: ptr,
+      render: render}
+}
+
+// NewGzipFile works like NewGzipWriter except that each call to Consume
+// creates the file at path, replacing any existing file, and closes it
+// when done. Errors closing the file are reported.
+func NewGzipFile(
+    path string,
+    ptr interface{},
+    render func(ptr interface{}, w io.Writer) error) ErrorReportingConsumer {
+  return &renderConsumer{
+      create: func() (*fileWriter, error) {
+        f, err := os.Create(path)
+        if err != nil {
+          return nil, err
+        }
+        return newFileWriter(f, f, true), nil
+      },
+      ptr: ptr,
+      render: render}
+}
+
+type renderConsumer struct {
+  create func() (*fileWriter, error)
+  ptr interface{}
+  render func(ptr interface{}, w io.Writer) error
+  err error
+}
+
+func (c *renderConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  var fw *fileWriter
+  if fw, c.err = c.create(); c.err != nil {
+    return
+  }
+  var err error
+  for err = s.Next(c.ptr); err == nil; err = s.Next(c.ptr) {
+    if err = c.render(c.ptr, fw); err != nil {
+      break
+    }
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  if cerr := fw.Close(); err == nil {
+    err = cerr
+  }
+  c.err = err
+}
+
+func (c *renderConsumer) Error() error {
+  return c.err
+}
@@ -0,0 +1,84 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "bytes"
+  "compress/gzip"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+func gunzip(t *testing.T, r io.Reader) string {
+  gz, err := gzip.NewReader(r)
+  if err != nil {
+    t.Fatalf("Error reading gzip: %v", err)
+  }
+  b, err := ioutil.ReadAll(gz)
+  if err != nil {
+    t.Fatalf("Error reading gzip: %v", err)
+  }
+  return string(b)
+}
+
+func TestGzipWriter(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewGzipWriter(&buf, new(int), renderInt)
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  c.Consume(s)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := gunzip(t, &buf); output != "0\n1\n2\n" {
+    t.Errorf("Expected 0 1 2, got %q", output)
+  }
+}
+
+func TestGzipWriterError(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewGzipWriter(&buf, new(int), renderInt)
+  c.Consume(errorStream{otherError})
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  if output := gunzip(t, &buf); output != "" {
+    t.Errorf("Expected complete empty gzip data, got %q", output)
+  }
+}
+
+func TestGzipFile(t *testing.T) {
+  dir, err := ioutil.TempDir("", "gziptest")
+  if err != nil {
+    t.Fatalf("Error creating temp dir: %v", err)
+  }
+  defer os.RemoveAll(dir)
+  path := filepath.Join(dir, "out.gz")
+  c := NewGzipFile(path, new(int), renderInt)
+  c.Consume(functional.Slice(functional.Count(), 0, 2))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  f, err := os.Open(path)
+  if err != nil {
+    t.Fatalf("Error opening file: %v", err)
+  }
+  defer f.Close()
+  if output := gunzip(t, f); output != "0\n1\n" {
+    t.Errorf("Expected 0 1, got %q", output)
+  }
+  c = NewGzipFile(filepath.Join(dir, "missing", "out.gz"), new(int), renderInt)
+  s := &closeChecker{Stream: functional.Count()}
+  c.Consume(s)
+  if c.Error() == nil {
+    t.Error("Expected error.")
+  }
+  verifyClosed(t, s)
+}
@@ -0,0 +1,59 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "sync"
+)
+
+// Pool returns an ErrorReportingConsumer that divides the values of the
+// Stream it consumes among n workers running in their own goroutines.
+// Unlike functional.MultiConsume which sends every value to every
+// Consumer, Pool sends each value to exactly one worker. Each worker
+// receives a Stream of T, pulls values from it until it sees Done or
+// decides to stop, and returns any error it encountered. Closing the Stream
+// a worker receives does not affect other workers. Once all workers
+// return, the returned ErrorReportingConsumer closes the consumed Stream.
+// Its Error method reports the first worker error, or if there is none,
+// any error from closing the consumed Stream.
+func Pool(n int, worker func(s functional.Stream) error) ErrorReportingConsumer {
+  if n < 1 {
+    panic("n must be greater than 0.")
+  }
+  return &poolConsumer{n: n, worker: worker}
+}
+
+type poolConsumer struct {
+  n int
+  worker func(s functional.Stream) error
+  err error
+}
+
+func (p *poolConsumer) Consume(s functional.Stream) {
+  shared := functional.Synchronized(s)
+  errs := make([]error, p.n)
+  var wg sync.WaitGroup
+  for i := range errs {
+    wg.Add(1)
+    go func(i int) {
+      defer wg.Done()
+      errs[i] = p.worker(functional.NoCloseStream(shared))
+    }(i)
+  }
+  wg.Wait()
+  p.err = shared.Close()
+  for _, err := range errs {
+    if err != nil {
+      p.err = err
+      break
+    }
+  }
+}
+
+func (p *poolConsumer) Error() error {
+  return p.err
+}
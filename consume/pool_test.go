@@ -0,0 +1,58 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "sync"
+  "testing"
+)
+
+func TestPool(t *testing.T) {
+  var mutex sync.Mutex
+  total, count := 0, 0
+  p := Pool(3, func(s functional.Stream) error {
+    var x int
+    err := s.Next(&x)
+    for ; err == nil; err = s.Next(&x) {
+      mutex.Lock()
+      total += x
+      count++
+      mutex.Unlock()
+    }
+    if err == functional.Done {
+      return nil
+    }
+    return err
+  })
+  stream := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 100)}
+  p.Consume(stream)
+  verifyClosed(t, stream)
+  if err := p.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if total != 4950 || count != 100 {
+    t.Errorf("Expected 4950 and 100, got %v and %v", total, count)
+  }
+}
+
+func TestPoolError(t *testing.T) {
+  p := Pool(2, func(s functional.Stream) error {
+    defer s.Close()
+    return s.Next(new(int))
+  })
+  p.Consume(closeErrorStream{errorStream{otherError}})
+  if err := p.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  p = Pool(2, func(s functional.Stream) error {
+    return nil
+  })
+  p.Consume(closeErrorStream{functional.Count()})
+  if err := p.Error(); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+}
@@ -0,0 +1,154 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "math"
+  "sort"
+)
+
+// Quantiles is an ErrorReportingConsumer of T that estimates quantiles of
+// a numeric quantity of T values, such as latency percentiles, in one pass
+// using a merging t-digest. It is most accurate near the extremes, where
+// percentiles like p99 are usually needed. Values accumulate across calls
+// to Consume.
+type Quantiles struct {
+  ptr interface{}
+  value func(ptr interface{}) float64
+  compression float64
+  centroids []centroid
+  buffer []float64
+  total float64
+  min float64
+  max float64
+  err error
+}
+
+type centroid struct {
+  mean float64
+  count float64
+}
+
+// NewQuantiles returns a new Quantiles. ptr is a *T that temporarily holds
+// consumed values; value returns the number to track for the T value ptr
+// points to. compression bounds the memory used, roughly compression
+// centroids; 100 is a reasonable choice.
+func NewQuantiles(
+    ptr interface{},
+    value func(ptr interface{}) float64,
+    compression float64) *Quantiles {
+  if compression <= 0 {
+    panic("compression must be greater than 0.")
+  }
+  return &Quantiles{
+      ptr: ptr,
+      value: value,
+      compression: compression,
+      min: math.Inf(1),
+      max: math.Inf(-1)}
+}
+
+// Consume adds the values in s, a Stream of T. Consume closes s.
+func (q *Quantiles) Consume(s functional.Stream) {
+  defer s.Close()
+  q.err = nil
+  err := s.Next(q.ptr)
+  for ; err == nil; err = s.Next(q.ptr) {
+    q.Add(q.value(q.ptr))
+  }
+  if err != functional.Done {
+    q.err = err
+  }
+}
+
+// Error returns any error from the last call to Consume.
+func (q *Quantiles) Error() error {
+  return q.err
+}
+
+// Add adds a single number.
+func (q *Quantiles) Add(x float64) {
+  q.buffer = append(q.buffer, x)
+  q.total++
+  if x < q.min {
+    q.min = x
+  }
+  if x > q.max {
+    q.max = x
+  }
+  if len(q.buffer) >= int(5 * q.compression) {
+    q.flush()
+  }
+}
+
+// Count returns the number of numbers added so far.
+func (q *Quantiles) Count() int {
+  return int(q.total)
+}
+
+// Quantile returns the estimated value at quantile phi which is between 0
+// and 1; for instance, Quantile(0.99) estimates the 99th percentile.
+// Quantile returns NaN if no numbers were added.
+func (q *Quantiles) Quantile(phi float64) float64 {
+  q.flush()
+  if len(q.centroids) == 0 {
+    return math.NaN()
+  }
+  if phi <= 0 {
+    return q.min
+  }
+  if phi >= 1 {
+    return q.max
+  }
+  target := phi * q.total
+  cumulative := 0.0
+  prevCenter, prevMean := 0.0, q.min
+  for _, c := range q.centroids {
+    center := cumulative + c.count / 2
+    if target < center {
+      return interpolate(target, prevCenter, center, prevMean, c.mean)
+    }
+    cumulative += c.count
+    prevCenter, prevMean = center, c.mean
+  }
+  return interpolate(target, prevCenter, q.total, prevMean, q.max)
+}
+
+func (q *Quantiles) flush() {
+  if len(q.buffer) == 0 {
+    return
+  }
+  all := make([]centroid, 0, len(q.centroids) + len(q.buffer))
+  all = append(all, q.centroids...)
+  for _, x := range q.buffer {
+    all = append(all, centroid{mean: x, count: 1})
+  }
+  q.buffer = q.buffer[:0]
+  sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+  merged := all[:1]
+  soFar := 0.0
+  for _, c := range all[1:] {
+    cur := &merged[len(merged) - 1]
+    proposed := cur.count + c.count
+    phi := (soFar + proposed / 2) / q.total
+    if proposed <= 4 * q.total * phi * (1 - phi) / q.compression {
+      cur.mean += (c.mean - cur.mean) * c.count / proposed
+      cur.count = proposed
+    } else {
+      soFar += cur.count
+      merged = append(merged, c)
+    }
+  }
+  q.centroids = merged
+}
+
+func interpolate(x, x0, x1, y0, y1 float64) float64 {
+  if x1 <= x0 {
+    return y1
+  }
+  return y0 + (y1 - y0) * (x - x0) / (x1 - x0)
+}
@@ -0,0 +1,50 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "math"
+  "math/rand"
+  "testing"
+)
+
+func TestQuantiles(t *testing.T) {
+  q := NewQuantiles(new(int), intValue, 100)
+  values := rand.New(rand.NewSource(5)).Perm(10000)
+  stream := &closeChecker{Stream: functional.NewStreamFromValues(values, nil)}
+  q.Consume(stream)
+  verifyClosed(t, stream)
+  if err := q.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := q.Count(); output != 10000 {
+    t.Errorf("Expected 10000, got %v", output)
+  }
+  verifyQuantile(t, q, 0.5, 5000, 100)
+  verifyQuantile(t, q, 0.99, 9900, 20)
+  verifyQuantile(t, q, 0.001, 10, 5)
+  verifyQuantile(t, q, 0, 0, 0)
+  verifyQuantile(t, q, 1, 9999, 0)
+}
+
+func TestQuantilesEmpty(t *testing.T) {
+  q := NewQuantiles(new(int), intValue, 100)
+  q.Consume(functional.NilStream())
+  if output := q.Quantile(0.5); !math.IsNaN(output) {
+    t.Errorf("Expected NaN, got %v", output)
+  }
+}
+
+func verifyQuantile(t *testing.T, q *Quantiles, phi, expected, tolerance float64) {
+  if output := q.Quantile(phi); math.Abs(output - expected) > tolerance {
+    t.Errorf("For %v expected about %v, got %v", phi, expected, output)
+  }
+}
+
+func intValue(ptr interface{}) float64 {
+  return float64(*ptr.(*int))
+}
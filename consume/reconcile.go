@@ -0,0 +1,124 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// ReconcileHandlers receive the values a Reconciler of T classifies.
+// ptr points to the consumed T value; existingPtr points to the T value
+// already stored under the same key. A nil handler means do nothing.
+// If a handler returns an error, the Reconciler stops.
+type ReconcileHandlers struct {
+  // Insert handles values whose key is not found.
+  Insert func(ptr interface{}) error
+  // Update handles values whose key is found with a different value.
+  Update func(ptr, existingPtr interface{}) error
+  // Unchanged handles values whose key is found with an equal value.
+  Unchanged func(ptr, existingPtr interface{}) error
+}
+
+// Reconciler is an ErrorReportingConsumer of T that compares each consumed
+// T value against what is already stored under its key, classifying it as
+// an insert, an update, or unchanged and passing it to the corresponding
+// handler.
+type Reconciler struct {
+  ptr interface{}
+  existingPtr interface{}
+  key func(ptr interface{}) interface{}
+  lookup func(key interface{}, existingPtr interface{}) (bool, error)
+  equal func(ptr, existingPtr interface{}) bool
+  handlers ReconcileHandlers
+  inserted int
+  updated int
+  unchanged int
+  err error
+}
+
+// NewReconciler returns a new Reconciler. ptr and existingPtr are *T
+// that temporarily hold the consumed value and the stored value
+// respectively. key returns the key of the T value ptr points to. lookup
+// stores the T value for key at existingPtr and returns true, or returns
+// false if there is no value for key. equal reports whether two T values
+// are the same.
+func NewReconciler(
+    ptr interface{},
+    existingPtr interface{},
+    key func(ptr interface{}) interface{},
+    lookup func(key interface{}, existingPtr interface{}) (bool, error),
+    equal func(ptr, existingPtr interface{}) bool,
+    handlers ReconcileHandlers) *Reconciler {
+  return &Reconciler{
+      ptr: ptr,
+      existingPtr: existingPtr,
+      key: key,
+      lookup: lookup,
+      equal: equal,
+      handlers: handlers}
+}
+
+// Consume reconciles the values in s, a Stream of T. Consume closes s.
+func (r *Reconciler) Consume(s functional.Stream) {
+  defer s.Close()
+  r.inserted, r.updated, r.unchanged = 0, 0, 0
+  r.err = nil
+  err := s.Next(r.ptr)
+  for ; err == nil; err = s.Next(r.ptr) {
+    if err = r.reconcile(); err != nil {
+      break
+    }
+  }
+  if err != functional.Done {
+    r.err = err
+  }
+}
+
+// Inserted returns the number of inserts from the last call to Consume.
+func (r *Reconciler) Inserted() int {
+  return r.inserted
+}
+
+// Updated returns the number of updates from the last call to Consume.
+func (r *Reconciler) Updated() int {
+  return r.updated
+}
+
+// Unchanged returns the number of unchanged values from the last call to
+// Consume.
+func (r *Reconciler) Unchanged() int {
+  return r.unchanged
+}
+
+// Error returns any error from the last call to Consume.
+func (r *Reconciler) Error() error {
+  return r.err
+}
+
+func (r *Reconciler) reconcile() error {
+  found, err := r.lookup(r.key(r.ptr), r.existingPtr)
+  if err != nil {
+    return err
+  }
+  switch {
+  case !found:
+    r.inserted++
+    if r.handlers.Insert != nil {
+      return r.handlers.Insert(r.ptr)
+    }
+  case r.equal(r.ptr, r.existingPtr):
+    r.unchanged++
+    if r.handlers.Unchanged != nil {
+      return r.handlers.Unchanged(r.ptr, r.existingPtr)
+    }
+  default:
+    r.updated++
+    if r.handlers.Update != nil {
+      return r.handlers.Update(r.ptr, r.existingPtr)
+    }
+  }
+  return nil
+}
@@ -0,0 +1,74 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+type keyValue struct {
+  key string
+  value int
+}
+
+func TestReconciler(t *testing.T) {
+  existing := map[string]int{"a": 1, "b": 2}
+  var inserts, updates []string
+  r := NewReconciler(
+      new(keyValue),
+      new(keyValue),
+      func(ptr interface{}) interface{} { return ptr.(*keyValue).key },
+      func(key interface{}, existingPtr interface{}) (bool, error) {
+        v, ok := existing[key.(string)]
+        *existingPtr.(*keyValue) = keyValue{key.(string), v}
+        return ok, nil
+      },
+      func(ptr, existingPtr interface{}) bool {
+        return *ptr.(*keyValue) == *existingPtr.(*keyValue)
+      },
+      ReconcileHandlers{
+          Insert: func(ptr interface{}) error {
+            inserts = append(inserts, ptr.(*keyValue).key)
+            return nil
+          },
+          Update: func(ptr, existingPtr interface{}) error {
+            updates = append(updates, fmt.Sprintf("%s:%d->%d", ptr.(*keyValue).key, existingPtr.(*keyValue).value, ptr.(*keyValue).value))
+            return nil
+          }})
+  stream := &closeChecker{Stream: functional.NewStreamFromValues(
+      []keyValue{{"a", 1}, {"b", 3}, {"c", 4}}, nil)}
+  r.Consume(stream)
+  verifyClosed(t, stream)
+  if err := r.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v %v", inserts, updates); output != "[c] [b:2->3]" {
+    t.Errorf("Expected [c] [b:2->3], got %v", output)
+  }
+  if r.Inserted() != 1 || r.Updated() != 1 || r.Unchanged() != 1 {
+    t.Errorf("Expected 1 1 1, got %v %v %v", r.Inserted(), r.Updated(), r.Unchanged())
+  }
+}
+
+func TestReconcilerLookupError(t *testing.T) {
+  r := NewReconciler(
+      new(int),
+      new(int),
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      func(key interface{}, existingPtr interface{}) (bool, error) {
+        return false, otherError
+      },
+      nil,
+      ReconcileHandlers{})
+  stream := &closeChecker{Stream: functional.Count()}
+  r.Consume(stream)
+  verifyClosed(t, stream)
+  if err := r.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
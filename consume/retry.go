@@ -0,0 +1,149 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "reflect"
+  "time"
+)
+
+// BackoffPolicy decides how long to wait between retries.
+type BackoffPolicy struct {
+  // Initial is the wait before the first retry.
+  Initial time.Duration
+  // Max caps the wait. 0 means no cap.
+  Max time.Duration
+  // Multiplier grows the wait after each retry. Values less than 1 are
+  // treated as 1, a constant wait.
+  Multiplier float64
+  // MaxRetries is how many times to retry the same value before giving
+  // up. Values less than 1 mean retry forever.
+  MaxRetries int
+}
+
+// Delay returns the wait before retry number attempt where the first
+// retry is attempt 0.
+func (b BackoffPolicy) Delay(attempt int) time.Duration {
+  d := float64(b.Initial)
+  for i := 0; i < attempt && b.Multiplier > 1; i++ {
+    d *= b.Multiplier
+    if b.Max > 0 && d >= float64(b.Max) {
+      return b.Max
+    }
+  }
+  if b.Max > 0 && time.Duration(d) > b.Max {
+    return b.Max
+  }
+  return time.Duration(d)
+}
+
+// Retrying returns an ErrorReportingConsumer of T that feeds the values
+// it consumes to c and, when c stops with an error for which isRetryable
+// returns true, waits as backoff says and has c consume again starting
+// with the value c was on when it failed. ptr is a *T used to remember
+// that value. Because only that one value is replayed, c should write each
+// value on its own rather than in batches that roll back together. If
+// instead the error came from reading the Stream, nothing is replayed and
+// c goes on reading the Stream so that no value is written twice. The
+// returned consumer reports the error of c once an error is not retryable
+// or backoff.MaxRetries is reached for the same value. With MaxRetries
+// less than 1, an error that stays retryable makes Consume retry forever,
+// so set MaxRetries or have isRetryable give up eventually. It closes the
+// Stream it consumes once, after c is through.
+func Retrying(
+    c ErrorReportingConsumer,
+    ptr interface{},
+    isRetryable func(err error) bool,
+    backoff BackoffPolicy) ErrorReportingConsumer {
+  return &retryingConsumer{
+      c: c,
+      ptr: ptr,
+      isRetryable: isRetryable,
+      backoff: backoff,
+      sleep: time.Sleep}
+}
+
+type retryingConsumer struct {
+  c ErrorReportingConsumer
+  ptr interface{}
+  isRetryable func(err error) bool
+  backoff BackoffPolicy
+  sleep func(d time.Duration)
+  err error
+}
+
+func (r *retryingConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  rs := &replayStream{
+      Stream: s,
+      last: reflect.New(reflect.TypeOf(r.ptr).Elem()).Interface(),
+      copier: functional.CopierFor(r.ptr)}
+  attempt := 0
+  for {
+    r.c.Consume(rs)
+    r.err = r.c.Error()
+    if r.err == nil || !r.isRetryable(r.err) {
+      return
+    }
+    if rs.progressed {
+      attempt = 0
+    }
+    if r.backoff.MaxRetries > 0 && attempt >= r.backoff.MaxRetries {
+      return
+    }
+    r.sleep(r.backoff.Delay(attempt))
+    attempt++
+    rs.replay()
+  }
+}
+
+func (r *retryingConsumer) Error() error {
+  return r.err
+}
+
+// replayStream remembers the last value it emitted so it can emit it
+// again. Close does nothing so that the consumer can be run again.
+type replayStream struct {
+  functional.Stream
+  last interface{}
+  copier functional.Copier
+  hasLast bool
+  replaying bool
+  // progressed is true if a value past the replayed one was emitted.
+  progressed bool
+  // nextFailed is true if the last call to Next of the underlying Stream
+  // returned an error other than Done.
+  nextFailed bool
+}
+
+func (s *replayStream) Next(ptr interface{}) error {
+  if s.replaying {
+    s.replaying = false
+    s.copier(s.last, ptr)
+    return nil
+  }
+  err := s.Stream.Next(ptr)
+  s.nextFailed = err != nil && err != functional.Done
+  if err == nil {
+    s.copier(ptr, s.last)
+    s.hasLast = true
+    s.progressed = true
+  }
+  return err
+}
+
+// replay arranges for the last value to be emitted again unless the
+// consumer failed on reading the Stream rather than on that value.
+func (s *replayStream) replay() {
+  s.replaying = s.hasLast && !s.nextFailed
+  s.nextFailed = false
+  s.progressed = false
+}
+
+func (s *replayStream) Close() error {
+  return nil
+}
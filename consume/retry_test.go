@@ -0,0 +1,152 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+  "time"
+)
+
+var transientError = errors.New("transient")
+
+// flakyWriter fails with transientError the first failures times it
+// sees each value in failOn.
+type flakyWriter struct {
+  failOn map[int]int
+  written []int
+  err error
+}
+
+func (w *flakyWriter) Consume(s functional.Stream) {
+  defer s.Close()
+  w.err = nil
+  var x int
+  for s.Next(&x) == nil {
+    if w.failOn[x] > 0 {
+      w.failOn[x]--
+      w.err = transientError
+      return
+    }
+    w.written = append(w.written, x)
+  }
+}
+
+func (w *flakyWriter) Error() error {
+  return w.err
+}
+
+func isTransient(err error) bool {
+  return err == transientError
+}
+
+func TestRetrying(t *testing.T) {
+  w := &flakyWriter{failOn: map[int]int{1: 2, 3: 1}}
+  var delays []time.Duration
+  c := Retrying(w, new(int), isTransient, BackoffPolicy{Initial: time.Second, Multiplier: 2, MaxRetries: 2})
+  c.(*retryingConsumer).sleep = func(d time.Duration) { delays = append(delays, d) }
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 5)}
+  c.Consume(s)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := fmt.Sprintf("%v", w.written); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  if output := fmt.Sprintf("%v", delays); output != "[1s 2s 1s]" {
+    t.Errorf("Expected [1s 2s 1s] got %v", output)
+  }
+}
+
+// flakySource emits 0 up to but not including end but fails with
+// transientError once just before emitting failAt.
+type flakySource struct {
+  next int
+  end int
+  failAt int
+  failed bool
+}
+
+func (s *flakySource) Next(ptr interface{}) error {
+  if s.next == s.failAt && !s.failed {
+    s.failed = true
+    return transientError
+  }
+  if s.next == s.end {
+    return functional.Done
+  }
+  *ptr.(*int) = s.next
+  s.next++
+  return nil
+}
+
+func (s *flakySource) Close() error {
+  return nil
+}
+
+// readingWriter writes each value and stops on any error from Next.
+type readingWriter struct {
+  written []int
+  err error
+}
+
+func (w *readingWriter) Consume(s functional.Stream) {
+  defer s.Close()
+  var x int
+  for w.err = s.Next(&x); w.err == nil; w.err = s.Next(&x) {
+    w.written = append(w.written, x)
+  }
+  if w.err == functional.Done {
+    w.err = nil
+  }
+}
+
+func (w *readingWriter) Error() error {
+  return w.err
+}
+
+func TestRetryingSourceError(t *testing.T) {
+  w := &readingWriter{}
+  c := Retrying(w, new(int), isTransient, BackoffPolicy{MaxRetries: 1})
+  c.(*retryingConsumer).sleep = func(d time.Duration) {}
+  c.Consume(&flakySource{end: 4, failAt: 2})
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", w.written); output != "[0 1 2 3]" {
+    t.Errorf("Expected [0 1 2 3] got %v", output)
+  }
+}
+
+func TestRetryingGivesUp(t *testing.T) {
+  w := &flakyWriter{failOn: map[int]int{1: 3}}
+  c := Retrying(w, new(int), isTransient, BackoffPolicy{MaxRetries: 2})
+  c.(*retryingConsumer).sleep = func(d time.Duration) {}
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if err := c.Error(); err != transientError {
+    t.Errorf("Expected transientError, got %v", err)
+  }
+  w = &flakyWriter{failOn: map[int]int{1: 1}}
+  c = Retrying(w, new(int), func(err error) bool { return false }, BackoffPolicy{})
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if output := fmt.Sprintf("%v %v", w.written, c.Error()); output != "[0] transient" {
+    t.Errorf("Expected [0] transient got %v", output)
+  }
+}
+
+func TestBackoffPolicyDelay(t *testing.T) {
+  b := BackoffPolicy{Initial: time.Second, Max: 5 * time.Second, Multiplier: 2}
+  var delays []time.Duration
+  for i := 0; i < 5; i++ {
+    delays = append(delays, b.Delay(i))
+  }
+  if output := fmt.Sprintf("%v", delays); output != "[1s 2s 4s 5s 5s]" {
+    t.Errorf("Expected [1s 2s 4s 5s 5s] got %v", output)
+  }
+}
@@ -0,0 +1,184 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "bufio"
+  "compress/gzip"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "io/ioutil"
+  "os"
+  "time"
+)
+
+// RollingFiles is an ErrorReportingConsumer of T that writes the values it
+// consumes to a series of files, starting a new file whenever its
+// CommitPolicy is due, much like log rotation.
+type RollingFiles struct {
+  pattern string
+  ptr interface{}
+  render func(ptr interface{}, w io.Writer) error
+  policy CommitPolicy
+  compress bool
+  dryRun bool
+  files []string
+  count int
+  err error
+}
+
+// NewRollingFiles returns a new RollingFiles. pattern is a format string
+// for fmt.Sprintf that names each file given its 0-based sequence number,
+// for instance "out-%04d.txt". ptr is a *T that temporarily holds
+// consumed values; render writes the T value ptr points to. policy says
+// when to start a new file. Its Checkpoint function, if any, is called
+// each time a file is complete with the total number of values written
+// to complete files. If compress is true, files are gzipped; pattern
+// should then end in ".gz". No file is created for an empty Stream.
+func NewRollingFiles(
+    pattern string,
+    ptr interface{},
+    render func(ptr interface{}, w io.Writer) error,
+    policy CommitPolicy,
+    compress bool) *RollingFiles {
+  return &RollingFiles{
+      pattern: pattern,
+      ptr: ptr,
+      render: render,
+      policy: policy,
+      compress: compress}
+}
+
+// Consume writes the values of s, a Stream of T, to files. Consume stops
+// at the first error leaving the file it was writing incomplete. Consume
+// closes s.
+func (r *RollingFiles) Consume(s functional.Stream) {
+  defer s.Close()
+  r.files = nil
+  r.count = 0
+  r.err = nil
+  var more bool
+  for more, r.err = r.writeFile(s); r.err == nil && more; more, r.err = r.writeFile(s) {
+  }
+}
+
+// DryRun returns a copy of r that renders values without writing any
+// files and never calls Checkpoint.
+func (r *RollingFiles) DryRun() ErrorReportingConsumer {
+  result := *r
+  result.dryRun = true
+  return &result
+}
+
+// Files returns the names of the files written during the last call to
+// Consume in the order they were written.
+func (r *RollingFiles) Files() []string {
+  return r.files
+}
+
+// Count returns the number of values written to complete files during the
+// last call to Consume.
+func (r *RollingFiles) Count() int {
+  return r.count
+}
+
+// Error returns the first error encountered during the last call to
+// Consume.
+func (r *RollingFiles) Error() error {
+  return r.err
+}
+
+// writeFile writes one file. more is false if the end of s was reached.
+func (r *RollingFiles) writeFile(s functional.Stream) (more bool, err error) {
+  err = s.Next(r.ptr)
+  if err == functional.Done {
+    return false, nil
+  }
+  if err != nil {
+    return false, err
+  }
+  fw, err := r.create()
+  if err != nil {
+    return false, err
+  }
+  n := 0
+  start := time.Now()
+  for err == nil {
+    if err = r.render(r.ptr, fw); err != nil {
+      break
+    }
+    n++
+    if r.policy.due(n, start) {
+      more = true
+      break
+    }
+    err = s.Next(r.ptr)
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  if cerr := fw.Close(); err == nil {
+    err = cerr
+  }
+  if err != nil {
+    return false, err
+  }
+  r.count += n
+  if r.policy.Checkpoint != nil && !r.dryRun {
+    if err = r.policy.Checkpoint(r.count); err != nil {
+      return false, err
+    }
+  }
+  return more, nil
+}
+
+func (r *RollingFiles) create() (*fileWriter, error) {
+  if r.dryRun {
+    return &fileWriter{Writer: bufio.NewWriter(ioutil.Discard)}, nil
+  }
+  name := fmt.Sprintf(r.pattern, len(r.files))
+  f, err := os.Create(name)
+  if err != nil {
+    return nil, err
+  }
+  r.files = append(r.files, name)
+  return newFileWriter(f, f, r.compress), nil
+}
+
+// fileWriter buffers and optionally compresses writes to a file.
+type fileWriter struct {
+  *bufio.Writer
+  gz *gzip.Writer
+  f io.Closer
+}
+
+// newFileWriter returns a fileWriter that writes to w and closes c, if
+// non-nil, when closed.
+func newFileWriter(w io.Writer, c io.Closer, compress bool) *fileWriter {
+  if !compress {
+    return &fileWriter{Writer: bufio.NewWriter(w), f: c}
+  }
+  gz := gzip.NewWriter(w)
+  return &fileWriter{Writer: bufio.NewWriter(gz), gz: gz, f: c}
+}
+
+// Close flushes everything to the file, finishing any compression, and
+// then closes it.
+func (w *fileWriter) Close() error {
+  err := w.Flush()
+  if w.gz != nil {
+    if gerr := w.gz.Close(); err == nil {
+      err = gerr
+    }
+  }
+  if w.f != nil {
+    if ferr := w.f.Close(); err == nil {
+      err = ferr
+    }
+  }
+  return err
+}
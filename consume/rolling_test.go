@@ -0,0 +1,96 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "compress/gzip"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+func renderInt(ptr interface{}, w io.Writer) error {
+  _, err := fmt.Fprintf(w, "%d\n", *ptr.(*int))
+  return err
+}
+
+func TestRollingFiles(t *testing.T) {
+  dir, err := ioutil.TempDir("", "rollingtest")
+  if err != nil {
+    t.Fatalf("Error creating temp dir: %v", err)
+  }
+  defer os.RemoveAll(dir)
+  var checkpoints []int
+  policy := CommitPolicy{Every: 2, Checkpoint: func(committed int) error {
+    checkpoints = append(checkpoints, committed)
+    return nil
+  }}
+  r := NewRollingFiles(filepath.Join(dir, "out-%02d.txt"), new(int), renderInt, policy, false)
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 5)}
+  r.Consume(s)
+  if err := r.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := fmt.Sprintf("%v %v", r.Count(), checkpoints); output != "5 [2 4 5]" {
+    t.Errorf("Expected 5 [2 4 5] got %v", output)
+  }
+  var contents []string
+  for _, name := range r.Files() {
+    b, err := ioutil.ReadFile(name)
+    if err != nil {
+      t.Fatalf("Error reading %s: %v", name, err)
+    }
+    contents = append(contents, filepath.Base(name) + ":" + string(b))
+  }
+  if output := fmt.Sprintf("%q", contents); output != `["out-00.txt:0\n1\n" "out-01.txt:2\n3\n" "out-02.txt:4\n"]` {
+    t.Errorf("Expected three files, got %v", output)
+  }
+  r.Consume(functional.NilStream())
+  if output := len(r.Files()); output != 0 {
+    t.Errorf("Expected no files, got %v", output)
+  }
+}
+
+func TestRollingFilesGzip(t *testing.T) {
+  dir, err := ioutil.TempDir("", "rollingtest")
+  if err != nil {
+    t.Fatalf("Error creating temp dir: %v", err)
+  }
+  defer os.RemoveAll(dir)
+  r := NewRollingFiles(filepath.Join(dir, "out-%d.gz"), new(int), renderInt, CommitPolicy{}, true)
+  r.Consume(functional.Slice(functional.Count(), 0, 3))
+  if output := len(r.Files()); output != 1 {
+    t.Fatalf("Expected 1 file, got %v", output)
+  }
+  f, err := os.Open(r.Files()[0])
+  if err != nil {
+    t.Fatalf("Error opening file: %v", err)
+  }
+  defer f.Close()
+  gz, err := gzip.NewReader(f)
+  if err != nil {
+    t.Fatalf("Error reading gzip: %v", err)
+  }
+  b, _ := ioutil.ReadAll(gz)
+  if output := string(b); output != "0\n1\n2\n" {
+    t.Errorf("Expected 0 1 2, got %q", output)
+  }
+}
+
+func TestRollingFilesError(t *testing.T) {
+  r := NewRollingFiles("/nonexistent/dir/out-%d.txt", new(int), renderInt, CommitPolicy{}, false)
+  s := &closeChecker{Stream: functional.Count()}
+  r.Consume(s)
+  if r.Error() == nil {
+    t.Error("Expected error.")
+  }
+  verifyClosed(t, s)
+}
@@ -0,0 +1,280 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "errors"
+  "github.com/keep94/gofunctional2/functional"
+  "math"
+  "sort"
+  "strconv"
+)
+
+// FieldType is the inferred type of a field.
+type FieldType int
+
+const (
+  // FieldUnknown means only empty or null values were seen.
+  FieldUnknown FieldType = iota
+  FieldBool
+  FieldInt
+  FieldFloat
+  FieldString
+  // FieldAny means values of incompatible kinds, such as nested JSON
+  // objects, were seen.
+  FieldAny
+)
+
+var fieldTypeNames = []string{"unknown", "bool", "int", "float", "string", "any"}
+
+func (t FieldType) String() string {
+  return fieldTypeNames[t]
+}
+
+// SchemaField describes one inferred field.
+type SchemaField struct {
+  Name string
+  Type FieldType
+  // Nullable is true if some sampled value was empty or missing.
+  Nullable bool
+}
+
+// Schema is an inferred list of fields in the order they were first seen.
+type Schema struct {
+  Fields []SchemaField
+}
+
+var errRecordLength = errors.New(
+    "consume: record length does not match schema.")
+
+// RecordMapper returns a Mapper from []string records laid out like the
+// sampled records to map[string]interface{} values. Each field is parsed
+// according to its inferred type as a bool, int64, float64, or string.
+// Empty fields map to nil. The Mapper returns an error if a record has
+// the wrong number of fields or a field cannot be parsed.
+func (s *Schema) RecordMapper() functional.Mapper {
+  return functional.NewMapper(func(srcPtr, destPtr interface{}) error {
+    record := *srcPtr.(*[]string)
+    if len(record) != len(s.Fields) {
+      return errRecordLength
+    }
+    result := make(map[string]interface{}, len(record))
+    for i, value := range record {
+      parsed, err := parseField(value, s.Fields[i].Type)
+      if err != nil {
+        return err
+      }
+      result[s.Fields[i].Name] = parsed
+    }
+    *destPtr.(*map[string]interface{}) = result
+    return nil
+  })
+}
+
+// SchemaInferrer infers a Schema from a sample of a Stream.
+type SchemaInferrer struct {
+  sampleSize int
+  header []string
+  records bool
+  schema Schema
+  index map[string]int
+  seen int
+  err error
+}
+
+// NewRecordSchemaInferrer returns a SchemaInferrer for a Stream of
+// []string such as CSV records. header names the fields; if header is nil,
+// the first record is taken to be the header. At most sampleSize records
+// after the header are inspected; sampleSize < 1 means all of them.
+func NewRecordSchemaInferrer(header []string, sampleSize int) *SchemaInferrer {
+  return &SchemaInferrer{header: header, sampleSize: sampleSize, records: true}
+}
+
+// NewMapSchemaInferrer returns a SchemaInferrer for a Stream of
+// map[string]interface{} such as decoded JSON objects. At most sampleSize
+// maps are inspected; sampleSize < 1 means all of them. Fields missing from
+// a map are nullable. Since map keys have no order, fields first seen in the
+// same map are in sorted order.
+func NewMapSchemaInferrer(sampleSize int) *SchemaInferrer {
+  return &SchemaInferrer{sampleSize: sampleSize}
+}
+
+// Consume inspects the sample.
+func (si *SchemaInferrer) Consume(s functional.Stream) {
+  defer s.Close()
+  si.schema = Schema{}
+  si.index = make(map[string]int)
+  si.seen = 0
+  si.err = nil
+  if si.records {
+    si.err = si.consumeRecords(s)
+  } else {
+    si.err = si.consumeMaps(s)
+  }
+  if si.err == functional.Done {
+    si.err = nil
+  }
+}
+
+// Schema returns the Schema inferred by the last call to Consume.
+func (si *SchemaInferrer) Schema() *Schema {
+  return &si.schema
+}
+
+// Sampled returns the number of records or maps inspected.
+func (si *SchemaInferrer) Sampled() int {
+  return si.seen
+}
+
+// Error returns any error from last call to Consume.
+func (si *SchemaInferrer) Error() error {
+  return si.err
+}
+
+func (si *SchemaInferrer) consumeRecords(s functional.Stream) error {
+  header := si.header
+  if header == nil {
+    if err := s.Next(&header); err != nil {
+      return err
+    }
+  }
+  for _, name := range header {
+    si.field(name)
+  }
+  var record []string
+  for !si.sampled() {
+    if err := s.Next(&record); err != nil {
+      return err
+    }
+    if len(record) != len(header) {
+      return errRecordLength
+    }
+    for i, value := range record {
+      si.observe(i, stringType(value))
+    }
+    si.seen++
+  }
+  return nil
+}
+
+func (si *SchemaInferrer) consumeMaps(s functional.Stream) error {
+  var m map[string]interface{}
+  for !si.sampled() {
+    // Decoders such as encoding/json merge into an existing map.
+    m = nil
+    if err := s.Next(&m); err != nil {
+      return err
+    }
+    for _, name := range sortedKeys(m) {
+      if _, ok := si.index[name]; !ok {
+        idx := si.field(name)
+        // Earlier maps lacked this field.
+        si.schema.Fields[idx].Nullable = si.seen > 0
+      }
+    }
+    for name, idx := range si.index {
+      value, ok := m[name]
+      if !ok {
+        si.schema.Fields[idx].Nullable = true
+        continue
+      }
+      si.observe(idx, valueType(value))
+    }
+    si.seen++
+  }
+  return nil
+}
+
+func (si *SchemaInferrer) sampled() bool {
+  return si.sampleSize > 0 && si.seen >= si.sampleSize
+}
+
+func (si *SchemaInferrer) field(name string) int {
+  si.index[name] = len(si.schema.Fields)
+  si.schema.Fields = append(si.schema.Fields, SchemaField{Name: name})
+  return si.index[name]
+}
+
+func (si *SchemaInferrer) observe(idx int, t FieldType) {
+  f := &si.schema.Fields[idx]
+  if t == FieldUnknown {
+    f.Nullable = true
+    return
+  }
+  f.Type = mergeTypes(f.Type, t)
+}
+
+func mergeTypes(a, b FieldType) FieldType {
+  if a == FieldUnknown || a == b {
+    return b
+  }
+  if (a == FieldInt && b == FieldFloat) || (a == FieldFloat && b == FieldInt) {
+    return FieldFloat
+  }
+  if a == FieldAny || b == FieldAny {
+    return FieldAny
+  }
+  return FieldString
+}
+
+func stringType(value string) FieldType {
+  if value == "" {
+    return FieldUnknown
+  }
+  if _, err := strconv.ParseInt(value, 10, 64); err == nil {
+    return FieldInt
+  }
+  if _, err := strconv.ParseFloat(value, 64); err == nil {
+    return FieldFloat
+  }
+  if value == "true" || value == "false" {
+    return FieldBool
+  }
+  return FieldString
+}
+
+func valueType(value interface{}) FieldType {
+  switch v := value.(type) {
+  case nil:
+    return FieldUnknown
+  case bool:
+    return FieldBool
+  case float64:
+    if !math.IsInf(v, 0) && v == math.Trunc(v) {
+      return FieldInt
+    }
+    return FieldFloat
+  case int, int64:
+    return FieldInt
+  case string:
+    return FieldString
+  }
+  return FieldAny
+}
+
+func parseField(value string, t FieldType) (interface{}, error) {
+  if value == "" {
+    return nil, nil
+  }
+  switch t {
+  case FieldBool:
+    return strconv.ParseBool(value)
+  case FieldInt:
+    return strconv.ParseInt(value, 10, 64)
+  case FieldFloat:
+    return strconv.ParseFloat(value, 64)
+  }
+  return value, nil
+}
+
+func sortedKeys(m map[string]interface{}) []string {
+  result := make([]string, 0, len(m))
+  for k := range m {
+    result = append(result, k)
+  }
+  sort.Strings(result)
+  return result
+}
@@ -0,0 +1,104 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "encoding/json"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "math"
+  "strings"
+  "testing"
+)
+
+func TestRecordSchemaInferrer(t *testing.T) {
+  records := [][]string{
+      {"id", "price", "name", "active", "note"},
+      {"1", "2", "apple", "true", ""},
+      {"2", "2.5", "kiwi", "false", ""},
+      {"3", "", "7", "true", ""},
+      {"x", "x", "x", "x", "x"}}
+  si := NewRecordSchemaInferrer(nil, 3)
+  stream := &closeChecker{Stream: functional.NewStreamFromValues(records, nil)}
+  si.Consume(stream)
+  verifyClosed(t, stream)
+  if err := si.Error(); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  expected := "[{id int false} {price float true} {name string false} {active bool false} {note unknown true}]"
+  if output := fmt.Sprintf("%v", si.Schema().Fields); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+  if si.Sampled() != 3 {
+    t.Errorf("Expected 3 sampled, got %d", si.Sampled())
+  }
+  m := si.Schema().RecordMapper()
+  var result map[string]interface{}
+  if err := m.Map(&[]string{"4", "1.25", "pear", "false", ""}, &result); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", result); output != "map[active:false id:4 name:pear note:<nil> price:1.25]" {
+    t.Errorf("Got %v", output)
+  }
+  if err := m.Map(&[]string{"x", "1", "a", "true", ""}, &result); err == nil {
+    t.Error("Expected parse error")
+  }
+  if err := m.Map(&[]string{"1"}, &result); err != errRecordLength {
+    t.Errorf("Expected errRecordLength, got %v", err)
+  }
+}
+
+func TestRecordSchemaInferrerHeader(t *testing.T) {
+  si := NewRecordSchemaInferrer([]string{"a"}, 0)
+  si.Consume(functional.NewStreamFromValues([][]string{{"1"}, {"b", "c"}}, nil))
+  if err := si.Error(); err != errRecordLength {
+    t.Errorf("Expected errRecordLength, got %v", err)
+  }
+}
+
+func TestMapSchemaInferrer(t *testing.T) {
+  maps := []map[string]interface{}{
+      {"b": 1.0, "a": "x"},
+      {"a": "y", "b": 2.5, "c": true},
+      {"a": nil, "b": 3.0, "c": false, "d": map[string]interface{}{}}}
+  si := NewMapSchemaInferrer(0)
+  si.Consume(functional.NewStreamFromValues(maps, nil))
+  if err := si.Error(); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  expected := "[{a string true} {b float false} {c bool true} {d any true}]"
+  if output := fmt.Sprintf("%v", si.Schema().Fields); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestMapSchemaInferrerFreshMaps(t *testing.T) {
+  r := strings.NewReader(`{"a": 1, "b": "x"} {"a": 2}`)
+  si := NewMapSchemaInferrer(0)
+  si.Consume(functional.ReadDecoded(
+      r,
+      func(r io.Reader) functional.ValueDecoder {
+        return json.NewDecoder(r)
+      }))
+  if err := si.Error(); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  expected := "[{a int false} {b string true}]"
+  if output := fmt.Sprintf("%v", si.Schema().Fields); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestMapSchemaInferrerInf(t *testing.T) {
+  maps := []map[string]interface{}{{"a": math.Inf(1)}, {"a": math.Inf(-1)}}
+  si := NewMapSchemaInferrer(0)
+  si.Consume(functional.NewStreamFromValues(maps, nil))
+  expected := "[{a float false}]"
+  if output := fmt.Sprintf("%v", si.Schema().Fields); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
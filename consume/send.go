@@ -0,0 +1,60 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// Sender represents the sending end of a stream of messages such as a
+// client-streaming RPC. RPC stubs with a method like
+// Send(*Message) error are usually adapted with SendFunc.
+type Sender interface {
+  // Send sends the T value ptr points to.
+  Send(ptr interface{}) error
+}
+
+// SendFunc adapts an ordinary function to a Sender.
+type SendFunc func(ptr interface{}) error
+
+// Send calls f.
+func (f SendFunc) Send(ptr interface{}) error {
+  return f(ptr)
+}
+
+// NewSendConsumer returns an ErrorReportingConsumer of T that sends each
+// value it consumes with s. ptr is a *T that temporarily holds consumed
+// values. The returned consumer stops at the first error. It does not
+// finish the RPC since that, such as calling CloseSend, depends on the
+// RPC framework.
+func NewSendConsumer(s Sender, ptr interface{}) ErrorReportingConsumer {
+  return &sendConsumer{sender: s, ptr: ptr}
+}
+
+type sendConsumer struct {
+  sender Sender
+  ptr interface{}
+  err error
+}
+
+func (c *sendConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  c.err = nil
+  var err error
+  for err = s.Next(c.ptr); err == nil; err = s.Next(c.ptr) {
+    if err = c.sender.Send(c.ptr); err != nil {
+      c.err = err
+      return
+    }
+  }
+  if err != functional.Done {
+    c.err = err
+  }
+}
+
+func (c *sendConsumer) Error() error {
+  return c.err
+}
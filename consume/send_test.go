@@ -0,0 +1,48 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestSendConsumer(t *testing.T) {
+  var sent []int
+  c := NewSendConsumer(SendFunc(func(ptr interface{}) error {
+    sent = append(sent, *ptr.(*int))
+    return nil
+  }), new(int))
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  c.Consume(s)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := fmt.Sprintf("%v", sent); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+}
+
+func TestSendConsumerError(t *testing.T) {
+  c := NewSendConsumer(SendFunc(func(ptr interface{}) error {
+    if *ptr.(*int) == 1 {
+      return consumerError
+    }
+    return nil
+  }), new(int))
+  s := &closeChecker{Stream: functional.Count()}
+  c.Consume(s)
+  if err := c.Error(); err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  verifyClosed(t, s)
+  c.Consume(errorStream{otherError})
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
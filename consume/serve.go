@@ -0,0 +1,170 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "encoding/csv"
+  "encoding/json"
+  "github.com/keep94/gofunctional2/functional"
+  "net/http"
+  "strings"
+)
+
+// Format is the format ServeStream writes values in.
+type Format int
+
+const (
+  // Negotiate chooses CSV if the Accept header of the request mentions
+  // text/csv and JSON otherwise.
+  Negotiate Format = iota
+  // CSV writes a header line followed by one line per value.
+  CSV
+  // JSON writes a JSON array with one element per value.
+  JSON
+)
+
+// ServeFlushEvery is how many values ServeStream writes between flushes
+// of the response.
+const ServeFlushEvery = 100
+
+// ServeStream writes the values of s, a Stream of T, as the response to r.
+// ptr is a *T where values are temporarily held. For CSV, columns supply
+// the header and the fields of each line; for JSON, each value is encoded
+// with encoding/json and columns are ignored. ServeStream sets the
+// Content-Type header and flushes the response every ServeFlushEvery
+// values if w is an http.Flusher so that clients see results as they are
+// produced. If reading the first value of s fails, ServeStream replies
+// with 500 Internal Server Error. Once the client goes away, ServeStream
+// stops reading s. ServeStream always closes s and returns the first
+// error encountered including the error of r's context if the client
+// went away. Errors after the first value are reported only through the
+// return value since the response status has already been sent.
+func ServeStream(
+    w http.ResponseWriter,
+    r *http.Request,
+    s functional.Stream,
+    ptr interface{},
+    format Format,
+    columns ...TableColumn) error {
+  defer s.Close()
+  err := s.Next(ptr)
+  if err != nil && err != functional.Done {
+    http.Error(w, "Error reading results", http.StatusInternalServerError)
+    return err
+  }
+  if format == Negotiate {
+    format = JSON
+    if strings.Contains(r.Header.Get("Accept"), "text/csv") {
+      format = CSV
+    }
+  }
+  var sw streamWriter
+  if format == CSV {
+    w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+    sw = &csvStreamWriter{w: csv.NewWriter(w), columns: columns}
+  } else {
+    w.Header().Set("Content-Type", "application/json")
+    sw = &jsonStreamWriter{w: w}
+  }
+  flusher, _ := w.(http.Flusher)
+  if berr := sw.Begin(); berr != nil {
+    return berr
+  }
+  ctx := r.Context()
+  for count := 1; err == nil; count++ {
+    if err = sw.Write(ptr); err != nil {
+      return err
+    }
+    if count % ServeFlushEvery == 0 {
+      if err = sw.Flush(); err != nil {
+        return err
+      }
+      if flusher != nil {
+        flusher.Flush()
+      }
+    }
+    if err = ctx.Err(); err != nil {
+      return err
+    }
+    err = s.Next(ptr)
+  }
+  if err != functional.Done {
+    return err
+  }
+  if err = sw.End(); err != nil {
+    return err
+  }
+  return s.Close()
+}
+
+type streamWriter interface {
+  Begin() error
+  Write(ptr interface{}) error
+  Flush() error
+  End() error
+}
+
+type csvStreamWriter struct {
+  w *csv.Writer
+  columns []TableColumn
+  record []string
+}
+
+func (c *csvStreamWriter) Begin() error {
+  c.record = make([]string, len(c.columns))
+  for i := range c.columns {
+    c.record[i] = c.columns[i].Header
+  }
+  return c.w.Write(c.record)
+}
+
+func (c *csvStreamWriter) Write(ptr interface{}) error {
+  for i := range c.columns {
+    c.record[i] = c.columns[i].Value(ptr)
+  }
+  return c.w.Write(c.record)
+}
+
+func (c *csvStreamWriter) Flush() error {
+  c.w.Flush()
+  return c.w.Error()
+}
+
+func (c *csvStreamWriter) End() error {
+  return c.Flush()
+}
+
+type jsonStreamWriter struct {
+  w http.ResponseWriter
+  started bool
+}
+
+func (j *jsonStreamWriter) Begin() error {
+  _, err := j.w.Write([]byte("["))
+  return err
+}
+
+func (j *jsonStreamWriter) Write(ptr interface{}) error {
+  b, err := json.Marshal(ptr)
+  if err != nil {
+    return err
+  }
+  if j.started {
+    b = append([]byte(","), b...)
+  }
+  j.started = true
+  _, err = j.w.Write(b)
+  return err
+}
+
+func (j *jsonStreamWriter) Flush() error {
+  return nil
+}
+
+func (j *jsonStreamWriter) End() error {
+  _, err := j.w.Write([]byte("]\n"))
+  return err
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "context"
+  "github.com/keep94/gofunctional2/functional"
+  "net/http"
+  "net/http/httptest"
+  "strconv"
+  "testing"
+)
+
+var intColumns = []TableColumn{
+    {Header: "n", Value: func(ptr interface{}) string { return strconv.Itoa(*ptr.(*int)) }},
+    {Header: "square", Value: func(ptr interface{}) string { return strconv.Itoa(*ptr.(*int) * *ptr.(*int)) }}}
+
+func TestServeStreamCSV(t *testing.T) {
+  r := httptest.NewRequest("GET", "/", nil)
+  r.Header.Set("Accept", "text/csv")
+  w := httptest.NewRecorder()
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  if err := ServeStream(w, r, s, new(int), Negotiate, intColumns...); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := w.Header().Get("Content-Type"); output != "text/csv; charset=utf-8" {
+    t.Errorf("Expected text/csv, got %v", output)
+  }
+  if output := w.Body.String(); output != "n,square\n0,0\n1,1\n2,4\n" {
+    t.Errorf("Expected CSV, got %q", output)
+  }
+}
+
+func TestServeStreamJSON(t *testing.T) {
+  r := httptest.NewRequest("GET", "/", nil)
+  w := httptest.NewRecorder()
+  if err := ServeStream(w, r, functional.Slice(functional.Count(), 0, 3), new(int), Negotiate); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := w.Header().Get("Content-Type"); output != "application/json" {
+    t.Errorf("Expected application/json, got %v", output)
+  }
+  if output := w.Body.String(); output != "[0,1,2]\n" {
+    t.Errorf("Expected JSON, got %q", output)
+  }
+  w = httptest.NewRecorder()
+  if err := ServeStream(w, r, functional.NilStream(), new(int), JSON); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := w.Body.String(); output != "[]\n" {
+    t.Errorf("Expected empty JSON array, got %q", output)
+  }
+}
+
+func TestServeStreamError(t *testing.T) {
+  r := httptest.NewRequest("GET", "/", nil)
+  w := httptest.NewRecorder()
+  if err := ServeStream(w, r, errorStream{otherError}, new(int), CSV); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  if w.Code != http.StatusInternalServerError {
+    t.Errorf("Expected 500, got %v", w.Code)
+  }
+}
+
+func TestServeStreamClientGone(t *testing.T) {
+  ctx, cancel := context.WithCancel(context.Background())
+  cancel()
+  r := httptest.NewRequest("GET", "/", nil).WithContext(ctx)
+  w := httptest.NewRecorder()
+  s := &closeChecker{Stream: functional.Count()}
+  if err := ServeStream(w, r, s, new(int), JSON); err != context.Canceled {
+    t.Errorf("Expected context.Canceled, got %v", err)
+  }
+  verifyClosed(t, s)
+  if output := w.Body.String(); output != "[0" {
+    t.Errorf("Expected one value, got %q", output)
+  }
+}
@@ -0,0 +1,239 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "hash/fnv"
+  "math"
+  "math/bits"
+  "sort"
+)
+
+// DistinctCounter is an ErrorReportingConsumer of T that estimates the
+// number of distinct T values in a Stream using the HyperLogLog algorithm.
+// Its memory use is fixed regardless of how many values it consumes.
+// Counts accumulate across calls to Consume until Reset is called.
+type DistinctCounter struct {
+  ptr interface{}
+  key func(ptr interface{}) string
+  precision uint
+  registers []uint8
+  err error
+}
+
+// NewDistinctCounter returns a new DistinctCounter. ptr is a *T that
+// temporarily holds consumed values; key returns the key of the T value
+// ptr points to. Two T values are the same if their keys are equal.
+// precision is between 4 and 16 inclusive; the DistinctCounter uses
+// 2^precision bytes and its typical relative error is
+// 1.04 / sqrt(2^precision).
+func NewDistinctCounter(
+    ptr interface{},
+    key func(ptr interface{}) string,
+    precision uint) *DistinctCounter {
+  if precision < 4 || precision > 16 {
+    panic("precision must be between 4 and 16.")
+  }
+  return &DistinctCounter{
+      ptr: ptr,
+      key: key,
+      precision: precision,
+      registers: make([]uint8, 1 << precision)}
+}
+
+// Consume adds the values in s, a Stream of T, to the estimate.
+// Consume closes s.
+func (d *DistinctCounter) Consume(s functional.Stream) {
+  defer s.Close()
+  d.err = nil
+  err := s.Next(d.ptr)
+  for ; err == nil; err = s.Next(d.ptr) {
+    d.add(hashKey(d.key(d.ptr), 0))
+  }
+  if err != functional.Done {
+    d.err = err
+  }
+}
+
+// Error returns any error from the last call to Consume.
+func (d *DistinctCounter) Error() error {
+  return d.err
+}
+
+// Estimate returns the estimated number of distinct values consumed.
+func (d *DistinctCounter) Estimate() float64 {
+  m := float64(len(d.registers))
+  sum := 0.0
+  zeros := 0
+  for _, r := range d.registers {
+    sum += 1.0 / float64(uint64(1) << r)
+    if r == 0 {
+      zeros++
+    }
+  }
+  estimate := hllAlpha(m) * m * m / sum
+  if estimate <= 2.5 * m && zeros > 0 {
+    return m * math.Log(m / float64(zeros))
+  }
+  return estimate
+}
+
+// Reset clears the estimate.
+func (d *DistinctCounter) Reset() {
+  for i := range d.registers {
+    d.registers[i] = 0
+  }
+}
+
+func (d *DistinctCounter) add(h uint64) {
+  idx := h >> (64 - d.precision)
+  rank := uint8(bits.LeadingZeros64(h << d.precision | 1 << (d.precision - 1)) + 1)
+  if rank > d.registers[idx] {
+    d.registers[idx] = rank
+  }
+}
+
+// HeavyHitter is a key along with its estimated count.
+type HeavyHitter struct {
+  Key string
+  Count uint64
+}
+
+// HeavyHitters is an ErrorReportingConsumer of T that tracks the most
+// frequent T values in a Stream using a Count-Min sketch. Its memory use is
+// fixed regardless of how many values it consumes. Estimated counts are
+// never too low but may be too high. Counts accumulate across calls to
+// Consume.
+type HeavyHitters struct {
+  ptr interface{}
+  key func(ptr interface{}) string
+  counts [][]uint64
+  k int
+  top map[string]uint64
+  err error
+}
+
+// NewHeavyHitters returns a new HeavyHitters that tracks the k most
+// frequent values. ptr is a *T that temporarily holds consumed values; key
+// returns the key of the T value ptr points to. width and depth size the
+// Count-Min sketch: estimates exceed true counts by at most about
+// 2N / width with probability 1 - 2^-depth where N is the number of values
+// consumed.
+func NewHeavyHitters(
+    ptr interface{},
+    key func(ptr interface{}) string,
+    width, depth, k int) *HeavyHitters {
+  if width <= 0 || depth <= 0 || k <= 0 {
+    panic("width, depth, and k must be greater than 0.")
+  }
+  counts := make([][]uint64, depth)
+  for i := range counts {
+    counts[i] = make([]uint64, width)
+  }
+  return &HeavyHitters{
+      ptr: ptr, key: key, counts: counts, k: k, top: make(map[string]uint64)}
+}
+
+// Consume adds the values in s, a Stream of T. Consume closes s.
+func (h *HeavyHitters) Consume(s functional.Stream) {
+  defer s.Close()
+  h.err = nil
+  err := s.Next(h.ptr)
+  for ; err == nil; err = s.Next(h.ptr) {
+    h.add(h.key(h.ptr))
+  }
+  if err != functional.Done {
+    h.err = err
+  }
+}
+
+// Error returns any error from the last call to Consume.
+func (h *HeavyHitters) Error() error {
+  return h.err
+}
+
+// Estimate returns the estimated count of values with key.
+func (h *HeavyHitters) Estimate(key string) uint64 {
+  h1, h2 := hashKey(key, 0), hashKey(key, 1)
+  var result uint64 = math.MaxUint64
+  width := uint64(len(h.counts[0]))
+  for i := range h.counts {
+    c := h.counts[i][(h1 + uint64(i) * h2) % width]
+    if c < result {
+      result = c
+    }
+  }
+  return result
+}
+
+// Top returns up to k of the most frequent keys with their estimated
+// counts, most frequent first.
+func (h *HeavyHitters) Top() []HeavyHitter {
+  result := make([]HeavyHitter, 0, len(h.top))
+  for key, count := range h.top {
+    result = append(result, HeavyHitter{Key: key, Count: count})
+  }
+  sort.Slice(result, func(i, j int) bool {
+    if result[i].Count != result[j].Count {
+      return result[i].Count > result[j].Count
+    }
+    return result[i].Key < result[j].Key
+  })
+  return result
+}
+
+func (h *HeavyHitters) add(key string) {
+  h1, h2 := hashKey(key, 0), hashKey(key, 1)
+  width := uint64(len(h.counts[0]))
+  for i := range h.counts {
+    h.counts[i][(h1 + uint64(i) * h2) % width]++
+  }
+  estimate := h.Estimate(key)
+  if _, ok := h.top[key]; ok || len(h.top) < h.k {
+    h.top[key] = estimate
+    return
+  }
+  minKey, minCount := "", uint64(math.MaxUint64)
+  for k, c := range h.top {
+    if c < minCount || (c == minCount && k > minKey) {
+      minKey, minCount = k, c
+    }
+  }
+  if estimate > minCount {
+    delete(h.top, minKey)
+    h.top[key] = estimate
+  }
+}
+
+func hashKey(key string, seed byte) uint64 {
+  h := fnv.New64a()
+  h.Write([]byte{seed})
+  h.Write([]byte(key))
+  return mix64(h.Sum64())
+}
+
+// mix64 improves the bit distribution of FNV hashes.
+func mix64(x uint64) uint64 {
+  x ^= x >> 33
+  x *= 0xff51afd7ed558ccd
+  x ^= x >> 33
+  x *= 0xc4ceb9fe1a85ec53
+  x ^= x >> 33
+  return x
+}
+
+func hllAlpha(m float64) float64 {
+  switch m {
+  case 16:
+    return 0.673
+  case 32:
+    return 0.697
+  case 64:
+    return 0.709
+  }
+  return 0.7213 / (1 + 1.079 / m)
+}
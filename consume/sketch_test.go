@@ -0,0 +1,69 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "math"
+  "testing"
+)
+
+func TestDistinctCounter(t *testing.T) {
+  d := NewDistinctCounter(new(int), intKey, 12)
+  // 20000 values but only 10000 distinct ones.
+  stream := &closeChecker{Stream: functional.Map(
+      functional.NewMapper(func(srcPtr, destPtr interface{}) error {
+        *destPtr.(*int) = *srcPtr.(*int) % 10000
+        return nil
+      }),
+      functional.Slice(functional.Count(), 0, 20000),
+      new(int))}
+  d.Consume(stream)
+  verifyClosed(t, stream)
+  if err := d.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if estimate := d.Estimate(); math.Abs(estimate - 10000) > 500 {
+    t.Errorf("Expected about 10000, got %v", estimate)
+  }
+  d.Reset()
+  d.Consume(functional.Slice(functional.Count(), 0, 10))
+  if estimate := d.Estimate(); math.Abs(estimate - 10) > 1 {
+    t.Errorf("Expected about 10, got %v", estimate)
+  }
+}
+
+func TestHeavyHitters(t *testing.T) {
+  h := NewHeavyHitters(new(int), intKey, 256, 4, 2)
+  // 7 appears 334 times, 3 appears 133 times, everything else once.
+  var values []int
+  for i := 0; i < 1000; i++ {
+    switch {
+    case i % 3 == 0:
+      values = append(values, 7)
+    case i % 5 == 0:
+      values = append(values, 3)
+    default:
+      values = append(values, 100 + i)
+    }
+  }
+  h.Consume(functional.NewStreamFromValues(values, nil))
+  top := h.Top()
+  if len(top) != 2 || top[0].Key != "7" || top[1].Key != "3" {
+    t.Errorf("Expected keys 7 and 3, got %v", top)
+  }
+  if top[0].Count < 334 {
+    t.Errorf("Expected count of at least 334, got %v", top[0].Count)
+  }
+  if output := h.Estimate("7"); output != top[0].Count {
+    t.Errorf("Expected %v, got %v", top[0].Count, output)
+  }
+}
+
+func intKey(ptr interface{}) string {
+  return fmt.Sprintf("%d", *ptr.(*int))
+}
@@ -0,0 +1,199 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "database/sql"
+  "github.com/keep94/gofunctional2/functional"
+  "time"
+)
+
+// CommitPolicy decides when a writing consumer commits what it has
+// written so far. A consumer always commits when it reaches the end of
+// its Stream. The zero value commits only then.
+type CommitPolicy struct {
+  // Every commits after this many values. Values less than 1 mean no
+  // limit on the number of values.
+  Every int
+  // Interval commits once this much time has passed since the first
+  // value of the current batch. 0 means no time limit.
+  Interval time.Duration
+  // Checkpoint, if non-nil, is called after each successful commit with
+  // the total number of values committed so far. Recording it lets a
+  // failed job resume by skipping that many source values, giving
+  // at-least-once processing. If Checkpoint returns an error, the
+  // consumer stops and reports it.
+  Checkpoint func(committed int) error
+}
+
+func (p *CommitPolicy) due(n int, start time.Time) bool {
+  if p.Every > 0 && n >= p.Every {
+    return true
+  }
+  return p.Interval > 0 && time.Since(start) >= p.Interval
+}
+
+// SQLInserter is an ErrorReportingConsumer of T that executes a statement,
+// typically an INSERT, for each T value it consumes. It executes the
+// statements in batches, one transaction per batch.
+type SQLInserter struct {
+  db *sql.DB
+  query string
+  ptr interface{}
+  args func(ptr interface{}) []interface{}
+  policy CommitPolicy
+  dryRun bool
+  count int
+  err error
+}
+
+// NewSQLInserter returns a new SQLInserter. query is the statement to
+// execute for each value; ptr is a *T that temporarily holds consumed
+// values; args returns the arguments for query given ptr. batchSize is the
+// number of statements to execute in each transaction. If batchSize is
+// less than 1, all values are written in a single transaction.
+func NewSQLInserter(
+    db *sql.DB,
+    query string,
+    ptr interface{},
+    args func(ptr interface{}) []interface{},
+    batchSize int) *SQLInserter {
+  return NewSQLInserterPolicy(db, query, ptr, args, CommitPolicy{Every: batchSize})
+}
+
+// NewSQLInserterPolicy works like NewSQLInserter except that policy
+// decides when each transaction commits.
+func NewSQLInserterPolicy(
+    db *sql.DB,
+    query string,
+    ptr interface{},
+    args func(ptr interface{}) []interface{},
+    policy CommitPolicy) *SQLInserter {
+  return &SQLInserter{
+      db: db, query: query, ptr: ptr, args: args, policy: policy}
+}
+
+// Consume writes the values of s, a Stream of T, to the database.
+// Consume stops at the first error, rolling back the current batch. Batches
+// committed before the error remain committed. Consume closes s.
+func (c *SQLInserter) Consume(s functional.Stream) {
+  defer s.Close()
+  c.count = 0
+  c.err = nil
+  var more bool
+  for more, c.err = c.writeBatch(s); c.err == nil && more; more, c.err = c.writeBatch(s) {
+  }
+}
+
+// DryRun returns a copy of c that executes its statements but rolls back
+// every transaction instead of committing it and never calls Checkpoint.
+// Its Count method reports how many values would have been committed.
+func (c *SQLInserter) DryRun() ErrorReportingConsumer {
+  result := *c
+  result.dryRun = true
+  return &result
+}
+
+// Count returns the number of values committed during the last call to
+// Consume.
+func (c *SQLInserter) Count() int {
+  return c.count
+}
+
+// Error returns the first error encountered during the last call to
+// Consume.
+func (c *SQLInserter) Error() error {
+  return c.err
+}
+
+// writeBatch writes one batch in a transaction. more is false if the end
+// of s was reached.
+func (c *SQLInserter) writeBatch(s functional.Stream) (more bool, err error) {
+  err = s.Next(c.ptr)
+  if err == functional.Done {
+    return false, nil
+  }
+  if err != nil {
+    return false, err
+  }
+  tx, err := c.db.Begin()
+  if err != nil {
+    return false, err
+  }
+  stmt, err := tx.Prepare(c.query)
+  if err != nil {
+    tx.Rollback()
+    return false, err
+  }
+  defer stmt.Close()
+  n := 0
+  start := time.Now()
+  for err == nil {
+    if _, err = stmt.Exec(c.args(c.ptr)...); err != nil {
+      break
+    }
+    n++
+    if c.policy.due(n, start) {
+      more = true
+      break
+    }
+    err = s.Next(c.ptr)
+  }
+  if err == functional.Done {
+    err = nil
+  }
+  if err != nil {
+    tx.Rollback()
+    return false, err
+  }
+  if c.dryRun {
+    tx.Rollback()
+    c.count += n
+    return more, nil
+  }
+  if err = tx.Commit(); err != nil {
+    return false, err
+  }
+  c.count += n
+  if c.policy.Checkpoint != nil {
+    if err = c.policy.Checkpoint(c.count); err != nil {
+      return false, err
+    }
+  }
+  return more, nil
+}
+
+// InTransaction begins a transaction on db and passes it to f which
+// returns the ErrorReportingConsumer and the Stream of the pipeline to
+// run within it. Typically the consumer writes to the transaction. Once
+// the consumer has consumed the Stream, InTransaction closes the Stream
+// and commits the transaction if neither the consumer nor closing the
+// Stream reported an error; otherwise it rolls the transaction back and
+// returns that error. If f returns an error, InTransaction rolls back
+// and returns it.
+func InTransaction(
+    db *sql.DB,
+    f func(tx *sql.Tx) (ErrorReportingConsumer, functional.Stream, error)) error {
+  tx, err := db.Begin()
+  if err != nil {
+    return err
+  }
+  c, s, err := f(tx)
+  if err != nil {
+    tx.Rollback()
+    return err
+  }
+  c.Consume(s)
+  closeErr := s.Close()
+  if err = c.Error(); err == nil {
+    err = closeErr
+  }
+  if err != nil {
+    tx.Rollback()
+    return err
+  }
+  return tx.Commit()
+}
@@ -0,0 +1,297 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "database/sql"
+  "database/sql/driver"
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "sync"
+  "testing"
+  "time"
+)
+
+var (
+  execError = errors.New("stream_util: exec error.")
+  fakeDbs = make(map[string]*fakeDb)
+  fakeDbsMutex sync.Mutex
+)
+
+func init() {
+  sql.Register("consumefake", fakeDriver{})
+}
+
+func TestSQLInserter(t *testing.T) {
+  db, fdb := openFakeDb(t, "inserter")
+  defer db.Close()
+  c := NewSQLInserter(db, "insert", new(int), intArgs, 2)
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := c.Count(); output != 5 {
+    t.Errorf("Expected 5, got %v", output)
+  }
+  if output := fmt.Sprintf("%v", fdb.committed); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4], got %v", output)
+  }
+  if output := fdb.commits; output != 3 {
+    t.Errorf("Expected 3 commits, got %v", output)
+  }
+}
+
+func TestSQLInserterError(t *testing.T) {
+  db, fdb := openFakeDb(t, "insertererror")
+  defer db.Close()
+  fdb.failOn = 3
+  c := NewSQLInserter(db, "insert", new(int), intArgs, 2)
+  stream := &closeChecker{Stream: functional.Count()}
+  c.Consume(stream)
+  verifyClosed(t, stream)
+  if err := c.Error(); err == nil {
+    t.Error("Expected error.")
+  }
+  if output := fmt.Sprintf("%v", fdb.committed); output != "[0 1]" {
+    t.Errorf("Expected [0 1], got %v", output)
+  }
+  if output := c.Count(); output != 2 {
+    t.Errorf("Expected 2, got %v", output)
+  }
+}
+
+func TestSQLInserterPolicy(t *testing.T) {
+  db, fdb := openFakeDb(t, "inserterpolicy")
+  defer db.Close()
+  var checkpoints []int
+  policy := CommitPolicy{Every: 2, Checkpoint: func(committed int) error {
+    checkpoints = append(checkpoints, committed)
+    return nil
+  }}
+  c := NewSQLInserterPolicy(db, "insert", new(int), intArgs, policy)
+  c.Consume(functional.Slice(functional.Count(), 0, 5))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", checkpoints); output != "[2 4 5]" {
+    t.Errorf("Expected [2 4 5], got %v", output)
+  }
+  checkpoints = nil
+  policy.Every = 0
+  policy.Interval = time.Nanosecond
+  c = NewSQLInserterPolicy(db, "insert", new(int), intArgs, policy)
+  c.Consume(functional.Slice(functional.Count(), 0, 3))
+  if output := fmt.Sprintf("%v", checkpoints); output != "[1 2 3]" {
+    t.Errorf("Expected [1 2 3], got %v", output)
+  }
+  if output := fdb.commits; output != 6 {
+    t.Errorf("Expected 6 commits, got %v", output)
+  }
+}
+
+func TestSQLInserterCheckpointError(t *testing.T) {
+  db, fdb := openFakeDb(t, "insertercheckpoint")
+  defer db.Close()
+  policy := CommitPolicy{Every: 2, Checkpoint: func(committed int) error {
+    return otherError
+  }}
+  c := NewSQLInserterPolicy(db, "insert", new(int), intArgs, policy)
+  stream := &closeChecker{Stream: functional.Count()}
+  c.Consume(stream)
+  verifyClosed(t, stream)
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", fdb.committed); output != "[0 1]" {
+    t.Errorf("Expected [0 1], got %v", output)
+  }
+}
+
+func TestInTransaction(t *testing.T) {
+  db, fdb := openFakeDb(t, "intransaction")
+  defer db.Close()
+  stream := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+  err := InTransaction(db, func(tx *sql.Tx) (ErrorReportingConsumer, functional.Stream, error) {
+    return &txInserter{tx: tx}, stream, nil
+  })
+  if err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyClosed(t, stream)
+  if output := fmt.Sprintf("%v %d", fdb.committed, fdb.commits); output != "[0 1 2] 1" {
+    t.Errorf("Expected [0 1 2] 1, got %v", output)
+  }
+}
+
+func TestInTransactionRollback(t *testing.T) {
+  db, fdb := openFakeDb(t, "intransactionrollback")
+  defer db.Close()
+  fdb.failOn = 2
+  err := InTransaction(db, func(tx *sql.Tx) (ErrorReportingConsumer, functional.Stream, error) {
+    return &txInserter{tx: tx}, functional.Count(), nil
+  })
+  if err != execError {
+    t.Errorf("Expected execError, got %v", err)
+  }
+  err = InTransaction(db, func(tx *sql.Tx) (ErrorReportingConsumer, functional.Stream, error) {
+    return nil, nil, otherError
+  })
+  if err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  if output := fmt.Sprintf("%v %d %d", fdb.committed, fdb.commits, fdb.rollbacks); output != "[] 0 2" {
+    t.Errorf("Expected [] 0 2, got %v", output)
+  }
+}
+
+// txInserter inserts the ints it consumes within tx.
+type txInserter struct {
+  tx *sql.Tx
+  err error
+}
+
+func (c *txInserter) Consume(s functional.Stream) {
+  defer s.Close()
+  var x int
+  for s.Next(&x) == nil {
+    if _, c.err = c.tx.Exec("insert", int64(x)); c.err != nil {
+      return
+    }
+  }
+}
+
+func (c *txInserter) Error() error {
+  return c.err
+}
+
+func intArgs(ptr interface{}) []interface{} {
+  return []interface{}{int64(*ptr.(*int))}
+}
+
+func openFakeDb(t *testing.T, name string) (*sql.DB, *fakeDb) {
+  fdb := &fakeDb{failOn: -1}
+  fakeDbsMutex.Lock()
+  fakeDbs[name] = fdb
+  fakeDbsMutex.Unlock()
+  db, err := sql.Open("consumefake", name)
+  if err != nil {
+    t.Fatalf("Error opening fake db: %v", err)
+  }
+  return db, fdb
+}
+
+// fakeDb records values passed to executed statements. The values become
+// committed only when the transaction commits.
+type fakeDb struct {
+  committed []int64
+  pending []int64
+  commits int
+  rollbacks int
+  // failOn is the value that makes Exec fail; -1 means never fail.
+  failOn int64
+  // rows are returned from queries.
+  rows [][]driver.Value
+}
+
+type fakeDriver struct {
+}
+
+func (d fakeDriver) Open(name string) (driver.Conn, error) {
+  fakeDbsMutex.Lock()
+  defer fakeDbsMutex.Unlock()
+  return &fakeConn{fakeDbs[name]}, nil
+}
+
+type fakeConn struct {
+  db *fakeDb
+}
+
+func (c *fakeConn) Prepare(query string) (driver.Stmt, error) {
+  return &fakeStmt{c.db}, nil
+}
+
+func (c *fakeConn) Close() error {
+  return nil
+}
+
+func (c *fakeConn) Begin() (driver.Tx, error) {
+  return &fakeTx{c.db}, nil
+}
+
+type fakeTx struct {
+  db *fakeDb
+}
+
+func (t *fakeTx) Commit() error {
+  t.db.committed = append(t.db.committed, t.db.pending...)
+  t.db.pending = nil
+  t.db.commits++
+  return nil
+}
+
+func (t *fakeTx) Rollback() error {
+  t.db.pending = nil
+  t.db.rollbacks++
+  return nil
+}
+
+type fakeStmt struct {
+  db *fakeDb
+}
+
+func (s *fakeStmt) Close() error {
+  return nil
+}
+
+func (s *fakeStmt) NumInput() int {
+  return -1
+}
+
+func (s *fakeStmt) Exec(args []driver.Value) (driver.Result, error) {
+  for _, arg := range args {
+    x := arg.(int64)
+    if x == s.db.failOn {
+      return nil, execError
+    }
+    s.db.pending = append(s.db.pending, x)
+  }
+  return driver.RowsAffected(1), nil
+}
+
+func (s *fakeStmt) Query(args []driver.Value) (driver.Rows, error) {
+  return &fakeDriverRows{rows: s.db.rows}, nil
+}
+
+type fakeDriverRows struct {
+  rows [][]driver.Value
+  idx int
+}
+
+func (r *fakeDriverRows) Columns() []string {
+  if len(r.rows) == 0 {
+    return nil
+  }
+  result := make([]string, len(r.rows[0]))
+  for i := range result {
+    result[i] = fmt.Sprintf("c%d", i)
+  }
+  return result
+}
+
+func (r *fakeDriverRows) Close() error {
+  return nil
+}
+
+func (r *fakeDriverRows) Next(dest []driver.Value) error {
+  if r.idx == len(r.rows) {
+    return io.EOF
+  }
+  copy(dest, r.rows[r.idx])
+  r.idx++
+  return nil
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "encoding/json"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "reflect"
+  "time"
+)
+
+// Summary describes one run of a consumer.
+type Summary struct {
+  // Count is the number of values the consumer read.
+  Count int
+  // First is the first value the consumer read or nil if none.
+  First interface{}
+  // Last is the last value the consumer read or nil if none.
+  Last interface{}
+  // Start is when the consumer started.
+  Start time.Time
+  // Duration is how long the consumer ran.
+  Duration time.Duration
+  // Err is the error the consumer reported.
+  Err error
+}
+
+// MarshalJSON encodes s with Duration in seconds and Err as its message
+// or null.
+func (s *Summary) MarshalJSON() ([]byte, error) {
+  var errMsg *string
+  if s.Err != nil {
+    msg := s.Err.Error()
+    errMsg = &msg
+  }
+  return json.Marshal(&struct {
+    Count int `json:"count"`
+    First interface{} `json:"first"`
+    Last interface{} `json:"last"`
+    Start time.Time `json:"start"`
+    Seconds float64 `json:"seconds"`
+    Error *string `json:"error"`
+  }{s.Count, s.First, s.Last, s.Start, s.Duration.Seconds(), errMsg})
+}
+
+// Summarized returns an ErrorReportingConsumer of T that passes the
+// values it consumes to c and, once c is through, calls finalize with a
+// Summary of the run. ptr is a *T; the First and Last fields of the
+// Summary are T values copied with the Copier functional.CopierFor
+// returns. The returned consumer reports the same error as c.
+func Summarized(
+    c ErrorReportingConsumer,
+    ptr interface{},
+    finalize func(s *Summary)) ErrorReportingConsumer {
+  return &summaryConsumer{ErrorReportingConsumer: c, ptr: ptr, finalize: finalize}
+}
+
+// WriteSummary returns a function for Summarized that writes each Summary
+// to w as a line of JSON. Write errors are ignored.
+func WriteSummary(w io.Writer) func(s *Summary) {
+  return func(s *Summary) {
+    if b, err := json.Marshal(s); err == nil {
+      w.Write(append(b, '\n'))
+    }
+  }
+}
+
+type summaryConsumer struct {
+  ErrorReportingConsumer
+  ptr interface{}
+  finalize func(s *Summary)
+}
+
+func (c *summaryConsumer) Consume(s functional.Stream) {
+  t := reflect.TypeOf(c.ptr).Elem()
+  ss := &summaryStream{
+      Stream: s,
+      first: reflect.New(t).Interface(),
+      last: reflect.New(t).Interface(),
+      copier: functional.CopierFor(c.ptr)}
+  summary := &Summary{Start: time.Now()}
+  c.ErrorReportingConsumer.Consume(ss)
+  summary.Duration = time.Since(summary.Start)
+  summary.Count = ss.count
+  if ss.count > 0 {
+    summary.First = reflect.ValueOf(ss.first).Elem().Interface()
+    summary.Last = reflect.ValueOf(ss.last).Elem().Interface()
+  }
+  summary.Err = c.Error()
+  c.finalize(summary)
+}
+
+type summaryStream struct {
+  functional.Stream
+  first interface{}
+  last interface{}
+  copier functional.Copier
+  count int
+}
+
+func (s *summaryStream) Next(ptr interface{}) error {
+  err := s.Stream.Next(ptr)
+  if err == nil {
+    if s.count == 0 {
+      s.copier(ptr, s.first)
+    }
+    s.copier(ptr, s.last)
+    s.count++
+  }
+  return err
+}
@@ -0,0 +1,42 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "bytes"
+  "github.com/keep94/gofunctional2/functional"
+  "strings"
+  "testing"
+)
+
+func TestSummarized(t *testing.T) {
+  var summary *Summary
+  b := NewGrowingBuffer(intSlice, 1)
+  c := Summarized(b, new(int), func(s *Summary) { summary = s })
+  s := &closeChecker{Stream: functional.Slice(functional.Count(), 3, 7)}
+  c.Consume(s)
+  verifyClosed(t, s)
+  verifyFetched(t, b, 3, 7)
+  if summary.Count != 4 || summary.First != 3 || summary.Last != 6 || summary.Err != nil {
+    t.Errorf("Expected 4 values from 3 to 6, got %+v", summary)
+  }
+  if summary.Start.IsZero() {
+    t.Error("Expected start time.")
+  }
+}
+
+func TestSummarizedError(t *testing.T) {
+  var buf bytes.Buffer
+  c := Summarized(&errorReportingConsumerForTesting{e: consumerError}, new(int), WriteSummary(&buf))
+  c.Consume(functional.NilStream())
+  if err := c.Error(); err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  output := buf.String()
+  if !strings.HasPrefix(output, `{"count":0,"first":null,"last":null,"start":"`) || !strings.HasSuffix(output, `"error":"stream_util: consumer error."}`+"\n") {
+    t.Errorf("Expected JSON summary, got %v", output)
+  }
+}
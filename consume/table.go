@@ -0,0 +1,101 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "reflect"
+  "strings"
+  "text/tabwriter"
+)
+
+// TableColumn describes one column of a table that NewTable renders.
+type TableColumn struct {
+  // Header is the column heading.
+  Header string
+  // Value returns the text of this column for the T value ptr points to.
+  Value func(ptr interface{}) string
+}
+
+// TupleColumns returns one TableColumn for each header. The ith column
+// shows the ith field of a functional.Tuple formatted with fmt.Sprint.
+func TupleColumns(headers ...string) []TableColumn {
+  result := make([]TableColumn, len(headers))
+  for i := range headers {
+    idx := i
+    result[i] = TableColumn{
+        Header: headers[i],
+        Value: func(ptr interface{}) string {
+          field := ptr.(functional.Tuple).Ptrs()[idx]
+          return fmt.Sprint(reflect.ValueOf(field).Elem().Interface())
+        }}
+  }
+  return result
+}
+
+// FieldColumns returns one TableColumn for each field name. Each column
+// shows the named field of a struct formatted with fmt.Sprint and uses the
+// field name as its header. The T values must be structs.
+func FieldColumns(fieldNames ...string) []TableColumn {
+  result := make([]TableColumn, len(fieldNames))
+  for i := range fieldNames {
+    name := fieldNames[i]
+    result[i] = TableColumn{
+        Header: name,
+        Value: func(ptr interface{}) string {
+          field := reflect.ValueOf(ptr).Elem().FieldByName(name)
+          return fmt.Sprint(field.Interface())
+        }}
+  }
+  return result
+}
+
+// NewTable returns an ErrorReportingConsumer of T that writes the values
+// it consumes to w as a text table with aligned columns. The first line
+// has the column headers. ptr is a *T where values are temporarily held.
+// The table is written once the Stream is exhausted since aligning
+// columns requires seeing every row.
+func NewTable(
+    w io.Writer, ptr interface{}, columns ...TableColumn) ErrorReportingConsumer {
+  return &tableConsumer{w: w, ptr: ptr, columns: columns}
+}
+
+type tableConsumer struct {
+  w io.Writer
+  ptr interface{}
+  columns []TableColumn
+  err error
+}
+
+func (t *tableConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  t.err = nil
+  tw := tabwriter.NewWriter(t.w, 0, 8, 2, ' ', 0)
+  headers := make([]string, len(t.columns))
+  for i := range t.columns {
+    headers[i] = t.columns[i].Header
+  }
+  fmt.Fprintln(tw, strings.Join(headers, "\t"))
+  row := make([]string, len(t.columns))
+  var err error
+  for err = s.Next(t.ptr); err == nil; err = s.Next(t.ptr) {
+    for i := range t.columns {
+      row[i] = t.columns[i].Value(t.ptr)
+    }
+    fmt.Fprintln(tw, strings.Join(row, "\t"))
+  }
+  if err != functional.Done {
+    t.err = err
+    return
+  }
+  t.err = tw.Flush()
+}
+
+func (t *tableConsumer) Error() error {
+  return t.err
+}
@@ -0,0 +1,62 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "bytes"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestTableFields(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewTable(&buf, new(tableRow), FieldColumns("Name", "Qty")...)
+  stream := &closeChecker{Stream: functional.NewStreamFromValues(
+      []tableRow{{"apple", 3}, {"kiwi", 12}}, nil)}
+  c.Consume(stream)
+  verifyClosed(t, stream)
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  expected := "Name   Qty\napple  3\nkiwi   12\n"
+  if output := buf.String(); output != expected {
+    t.Errorf("Expected %q, got %q", expected, output)
+  }
+}
+
+func TestTableTuples(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewTable(&buf, new(tableTuple), TupleColumns("ID", "Label")...)
+  c.Consume(functional.NewStreamFromValues(
+      []tableTuple{{7, "seven"}, {1000, "thousand"}}, nil))
+  expected := "ID    Label\n7     seven\n1000  thousand\n"
+  if output := buf.String(); output != expected {
+    t.Errorf("Expected %q, got %q", expected, output)
+  }
+}
+
+func TestTableError(t *testing.T) {
+  var buf bytes.Buffer
+  c := NewTable(&buf, new(tableRow), FieldColumns("Name")...)
+  c.Consume(errorStream{otherError})
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
+
+type tableRow struct {
+  Name string
+  Qty int
+}
+
+type tableTuple struct {
+  ID int
+  Label string
+}
+
+func (t *tableTuple) Ptrs() []interface{} {
+  return []interface{}{&t.ID, &t.Label}
+}
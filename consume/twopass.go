@@ -0,0 +1,41 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// TwoPass has pass1 consume a Stream from factory and then, if pass1
+// reports no error, has pass2 consume a fresh Stream from factory. Since
+// pass2 runs only after pass1 finishes, pass2 may use whatever pass1
+// computed, such as a total needed to compute a percentage of that total
+// for each value. TwoPass closes each Stream after its pass consumes it.
+// It returns the first error from factory, pass1, closing the first
+// Stream, pass2, or closing the second Stream.
+func TwoPass(
+    factory func() (functional.Stream, error),
+    pass1, pass2 ErrorReportingConsumer) error {
+  if err := runPass(factory, pass1); err != nil {
+    return err
+  }
+  return runPass(factory, pass2)
+}
+
+func runPass(
+    factory func() (functional.Stream, error),
+    c ErrorReportingConsumer) error {
+  s, err := factory()
+  if err != nil {
+    return err
+  }
+  c.Consume(s)
+  closeErr := s.Close()
+  if err := c.Error(); err != nil {
+    return err
+  }
+  return closeErr
+}
@@ -0,0 +1,129 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package consume
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestTwoPass(t *testing.T) {
+  var streams []*closeChecker
+  factory := func() (functional.Stream, error) {
+    s := &closeChecker{
+        Stream: functional.NewStreamFromValues([]int{1, 3, 4, 2}, nil)}
+    streams = append(streams, s)
+    return s, nil
+  }
+  total := &totaler{}
+  percents := &percentOf{total: total}
+  if err := TwoPass(factory, total, percents); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", percents.values); output != "[10 30 40 20]" {
+    t.Errorf("Expected [10 30 40 20], got %v", output)
+  }
+  if len(streams) != 2 {
+    t.Fatalf("Expected 2 streams, got %d", len(streams))
+  }
+  verifyClosed(t, streams[0])
+  verifyClosed(t, streams[1])
+}
+
+func TestTwoPassFirstPassError(t *testing.T) {
+  calls := 0
+  factory := func() (functional.Stream, error) {
+    calls++
+    return functional.Slice(functional.Count(), 0, 3), nil
+  }
+  pass1 := &errorReportingConsumerForTesting{e: consumerError}
+  pass2 := &errorReportingConsumerForTesting{}
+  if err := TwoPass(factory, pass1, pass2); err != consumerError {
+    t.Errorf("Expected consumerError, got %v", err)
+  }
+  if calls != 1 {
+    t.Errorf("Expected 1 call to factory, got %d", calls)
+  }
+}
+
+func TestTwoPassClosesStreams(t *testing.T) {
+  var streams []*closeChecker
+  factory := func() (functional.Stream, error) {
+    s := &closeChecker{Stream: functional.Slice(functional.Count(), 0, 3)}
+    streams = append(streams, s)
+    return s, nil
+  }
+  pass1 := &errorReportingConsumerForTesting{}
+  pass2 := &errorReportingConsumerForTesting{}
+  if err := TwoPass(factory, pass1, pass2); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if len(streams) != 2 {
+    t.Fatalf("Expected 2 streams, got %d", len(streams))
+  }
+  verifyClosed(t, streams[0])
+  verifyClosed(t, streams[1])
+}
+
+func TestTwoPassCloseError(t *testing.T) {
+  calls := 0
+  factory := func() (functional.Stream, error) {
+    calls++
+    return closeErrorStream{functional.Slice(functional.Count(), 0, 3)}, nil
+  }
+  pass1 := &errorReportingConsumerForTesting{}
+  pass2 := &errorReportingConsumerForTesting{}
+  if err := TwoPass(factory, pass1, pass2); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if calls != 1 {
+    t.Errorf("Expected 1 call to factory, got %d", calls)
+  }
+}
+
+func TestTwoPassFactoryError(t *testing.T) {
+  factory := func() (functional.Stream, error) {
+    return nil, otherError
+  }
+  if err := TwoPass(factory, &totaler{}, &totaler{}); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
+
+type totaler struct {
+  total int
+}
+
+func (t *totaler) Consume(s functional.Stream) {
+  defer s.Close()
+  t.total = 0
+  var x int
+  for s.Next(&x) == nil {
+    t.total += x
+  }
+}
+
+func (t *totaler) Error() error {
+  return nil
+}
+
+type percentOf struct {
+  total *totaler
+  values []int
+}
+
+func (p *percentOf) Consume(s functional.Stream) {
+  defer s.Close()
+  var x int
+  for s.Next(&x) == nil {
+    p.values = append(p.values, 100 * x / p.total.total)
+  }
+}
+
+func (p *percentOf) Error() error {
+  return nil
+}
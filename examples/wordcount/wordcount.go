@@ -0,0 +1,67 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// This program demonstrates counting the words read from standard input
+// and printing the most frequent ones.
+package main
+
+import (
+  "flag"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "log"
+  "os"
+  "sort"
+)
+
+var fTop = flag.Int("top", 10, "Number of words to print")
+
+// wordCount is a word and how many times it appears.
+type wordCount struct {
+  word string
+  count int
+}
+
+// CountWords returns how many times each word in s, a Stream of string,
+// appears.
+func CountWords(s functional.Stream) (map[string]int, error) {
+  counts := make(map[string]int)
+  var word string
+  err := s.Next(&word)
+  for ; err == nil; err = s.Next(&word) {
+    counts[word]++
+  }
+  if err != functional.Done {
+    return nil, err
+  }
+  return counts, nil
+}
+
+func main() {
+  flag.Parse()
+  words := functional.Tokenize(
+      functional.ReadLines(os.Stdin),
+      functional.TokenOptions{Lower: true})
+  counts, err := CountWords(words)
+  if err != nil {
+    log.Fatal(err)
+  }
+  sorted := make([]wordCount, 0, len(counts))
+  for word, count := range counts {
+    sorted = append(sorted, wordCount{word, count})
+  }
+  sort.Slice(sorted, func(i, j int) bool {
+    if sorted[i].count != sorted[j].count {
+      return sorted[i].count > sorted[j].count
+    }
+    return sorted[i].word < sorted[j].word
+  })
+  if len(sorted) > *fTop {
+    sorted = sorted[:*fTop]
+  }
+  for _, wc := range sorted {
+    fmt.Printf("%7d %s\n", wc.count, wc.word)
+  }
+}
@@ -0,0 +1,74 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+)
+
+// AssembleRecords merges continuation lines, such as the lines of a stack
+// trace or a wrapped log entry, with the line they continue. s is a
+// Stream of string. isContinuation is a Filterer of string that returns
+// nil for a line that continues the previous one and Skipped for a line
+// that starts a new record; any other error is returned. joiner turns the
+// lines of one record into a T value, and the returned Stream is a Stream
+// of T. If s starts with continuation lines, they form the first record.
+// Calling Close on returned Stream closes s.
+func AssembleRecords(
+    isContinuation Filterer,
+    joiner func(lines []string) (interface{}, error),
+    s Stream) Stream {
+  return &assembleStream{
+      Stream: s, isContinuation: isContinuation, joiner: joiner}
+}
+
+type assembleStream struct {
+  Stream
+  isContinuation Filterer
+  joiner func(lines []string) (interface{}, error)
+  lines []string
+  done bool
+}
+
+func (s *assembleStream) Next(ptr interface{}) error {
+  for !s.done {
+    var line string
+    err := s.Stream.Next(&line)
+    if err == Done {
+      s.done = true
+      break
+    }
+    if err != nil {
+      return err
+    }
+    ferr := s.isContinuation.Filter(&line)
+    if ferr != nil && ferr != Skipped {
+      return ferr
+    }
+    if ferr == nil || len(s.lines) == 0 {
+      s.lines = append(s.lines, line)
+      continue
+    }
+    lines := s.lines
+    s.lines = []string{line}
+    return s.emit(lines, ptr)
+  }
+  if len(s.lines) == 0 {
+    return Done
+  }
+  lines := s.lines
+  s.lines = nil
+  return s.emit(lines, ptr)
+}
+
+func (s *assembleStream) emit(lines []string, ptr interface{}) error {
+  record, err := s.joiner(lines)
+  if err != nil {
+    return err
+  }
+  assignFromValue(reflect.ValueOf(record), ptr)
+  return nil
+}
@@ -0,0 +1,66 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+func TestAssembleRecords(t *testing.T) {
+  lines := NewStreamFromValues(
+      []string{"  orphan", "ERROR a", "  at x", "  at y", "INFO b", "WARN c", "  at z"},
+      nil)
+  stream := AssembleRecords(indented, joinLines, lines)
+  results, err := toStringArray(stream)
+  expected := "[orphan ERROR a|at x|at y INFO b WARN c|at z]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestAssembleRecordsEmpty(t *testing.T) {
+  stream := AssembleRecords(indented, joinLines, NilStream())
+  _, err := toStringArray(stream)
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestAssembleRecordsErrors(t *testing.T) {
+  lines := NewStreamFromValues([]string{"a", "b"}, nil)
+  stream := AssembleRecords(
+      errFilterer, joinLines, lines)
+  if _, err := toStringArray(stream); err != filterError {
+    t.Errorf("Expected filterError, got %v", err)
+  }
+  lines = NewStreamFromValues([]string{"a"}, nil)
+  stream = AssembleRecords(
+      indented,
+      func(lines []string) (interface{}, error) { return nil, mapError },
+      lines)
+  if _, err := toStringArray(stream); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestAssembleRecordsClose(t *testing.T) {
+  s := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  stream := AssembleRecords(indented, joinLines, s)
+  stream.Close()
+  verifyCloseCalled(t, s)
+}
+
+var indented = NewBoolFilterer(func(ptr interface{}) bool {
+  return strings.HasPrefix(*ptr.(*string), " ")
+})
+
+func joinLines(lines []string) (interface{}, error) {
+  for i := range lines {
+    lines[i] = strings.TrimSpace(lines[i])
+  }
+  return strings.Join(lines, "|"), nil
+}
@@ -0,0 +1,113 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "sync"
+  "time"
+)
+
+// ErrCircuitOpen is returned in place of calling through an open
+// CircuitBreaker.
+var ErrCircuitOpen = errors.New("functional: Circuit breaker open.")
+
+// CircuitBreaker stops calling a failing dependency for a while so that
+// a pipeline fails fast instead of waiting on the dependency for every
+// value. After threshold consecutive failures, the breaker opens and
+// every call fails with ErrCircuitOpen for the cool down period. Then the
+// breaker lets one call through as a probe: if it succeeds, the breaker
+// closes; if it fails, the breaker stays open for another cool down
+// period. Skipped does not count as a failure. Only the probe can close
+// an open breaker; calls that started before the breaker opened and
+// finish while it is open do not change its state. A CircuitBreaker may be
+// shared by multiple goroutines.
+type CircuitBreaker struct {
+  threshold int
+  coolDown time.Duration
+  now func() time.Time
+  mutex sync.Mutex
+  failures int
+  openedAt time.Time
+  probing bool
+}
+
+// NewCircuitBreaker returns a new, closed CircuitBreaker. threshold must
+// be greater than 0.
+func NewCircuitBreaker(threshold int, coolDown time.Duration) *CircuitBreaker {
+  if threshold < 1 {
+    panic("threshold must be greater than 0.")
+  }
+  return &CircuitBreaker{threshold: threshold, coolDown: coolDown, now: time.Now}
+}
+
+// Call calls f and returns its error unless b is open in which case Call
+// returns ErrCircuitOpen without calling f. Call can wrap any per value
+// operation such as the Send method of a consume.Sender.
+func (b *CircuitBreaker) Call(f func() error) error {
+  allowed, probe := b.allow()
+  if !allowed {
+    return ErrCircuitOpen
+  }
+  err := f()
+  b.record(err == nil || err == Skipped, probe)
+  return err
+}
+
+// Open returns true if b is failing calls fast.
+func (b *CircuitBreaker) Open() bool {
+  b.mutex.Lock()
+  defer b.mutex.Unlock()
+  return b.failures >= b.threshold
+}
+
+// Mapper returns a Mapper that calls m through b.
+func (b *CircuitBreaker) Mapper(m Mapper) Mapper {
+  return NewMapper(func(srcPtr, destPtr interface{}) error {
+    return b.Call(func() error { return m.Map(srcPtr, destPtr) })
+  })
+}
+
+// Filterer returns a Filterer that calls f through b.
+func (b *CircuitBreaker) Filterer(f Filterer) Filterer {
+  return NewFilterer(func(ptr interface{}) error {
+    return b.Call(func() error { return f.Filter(ptr) })
+  })
+}
+
+// allow reports whether a call may go through and whether that call is
+// the probe of an open breaker.
+func (b *CircuitBreaker) allow() (allowed, probe bool) {
+  b.mutex.Lock()
+  defer b.mutex.Unlock()
+  if b.failures < b.threshold {
+    return true, false
+  }
+  if b.probing || b.now().Sub(b.openedAt) < b.coolDown {
+    return false, false
+  }
+  b.probing = true
+  return true, true
+}
+
+func (b *CircuitBreaker) record(success, probe bool) {
+  b.mutex.Lock()
+  defer b.mutex.Unlock()
+  if probe {
+    b.probing = false
+  } else if b.failures >= b.threshold {
+    // A call that started before the breaker opened.
+    return
+  }
+  if success {
+    b.failures = 0
+    return
+  }
+  b.failures++
+  if b.failures >= b.threshold {
+    b.openedAt = b.now()
+  }
+}
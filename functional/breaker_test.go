@@ -0,0 +1,94 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "testing"
+    "time"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+  now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+  b := NewCircuitBreaker(2, time.Minute)
+  b.now = func() time.Time { return now }
+  calls := 0
+  fail := true
+  m := b.Mapper(NewMapper(func(srcPtr, destPtr interface{}) error {
+    calls++
+    if fail {
+      return mapError
+    }
+    *destPtr.(*int) = *srcPtr.(*int)
+    return nil
+  }))
+  var x int
+  for i := 0; i < 2; i++ {
+    if err := m.Map(ptrInt(3), &x); err != mapError {
+      t.Errorf("Expected mapError, got %v", err)
+    }
+  }
+  if !b.Open() {
+    t.Error("Expected breaker to be open.")
+  }
+  if err := m.Map(ptrInt(3), &x); err != ErrCircuitOpen || calls != 2 {
+    t.Errorf("Expected ErrCircuitOpen without a call, got %v", err)
+  }
+  now = now.Add(time.Minute)
+  if err := m.Map(ptrInt(3), &x); err != mapError || calls != 3 {
+    t.Errorf("Expected failed probe, got %v", err)
+  }
+  if err := m.Map(ptrInt(3), &x); err != ErrCircuitOpen {
+    t.Errorf("Expected ErrCircuitOpen, got %v", err)
+  }
+  now = now.Add(time.Minute)
+  fail = false
+  if err := m.Map(ptrInt(3), &x); err != nil || x != 3 {
+    t.Errorf("Expected successful probe, got %v", err)
+  }
+  if b.Open() {
+    t.Error("Expected breaker to be closed.")
+  }
+}
+
+func TestCircuitBreakerSkippedNotFailure(t *testing.T) {
+  b := NewCircuitBreaker(1, time.Minute)
+  f := b.Filterer(lessThan(5))
+  if err := f.Filter(ptrInt(7)); err != Skipped {
+    t.Errorf("Expected Skipped, got %v", err)
+  }
+  if err := f.Filter(ptrInt(3)); err != nil {
+    t.Errorf("Expected nil, got %v", err)
+  }
+  if b.Open() {
+    t.Error("Expected breaker to be closed.")
+  }
+}
+
+func TestCircuitBreakerSlowCallDuringProbe(t *testing.T) {
+  now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+  b := NewCircuitBreaker(2, time.Minute)
+  b.now = func() time.Time { return now }
+  _, slowProbe := b.allow()
+  for i := 0; i < 2; i++ {
+    b.Call(func() error { return mapError })
+  }
+  now = now.Add(time.Minute)
+  if allowed, probe := b.allow(); !allowed || !probe {
+    t.Fatal("Expected probe to be allowed.")
+  }
+  // The slow call succeeds while the probe is in flight.
+  b.record(true, slowProbe)
+  if !b.Open() {
+    t.Error("Expected breaker to stay open.")
+  }
+  if allowed, _ := b.allow(); allowed {
+    t.Error("Expected no second probe.")
+  }
+}
+
+func TestCircuitBreakerBadThreshold(t *testing.T) {
+  verifyPanics(t, func() { NewCircuitBreaker(0, time.Minute) })
+}
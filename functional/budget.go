@@ -0,0 +1,59 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+  "time"
+)
+
+// SkipOnTimeout is a fallback Mapper for WithBudget that skips values.
+var SkipOnTimeout Mapper = NewMapper(func(srcPtr, destPtr interface{}) error {
+  return Skipped
+})
+
+// WithBudget returns a Mapper that works like m except that if m takes
+// longer than budget for a value, the returned Mapper stops waiting for
+// m and maps the value with fallback instead. fallback can store a
+// default value in destPtr or return Skipped as SkipOnTimeout does. Since
+// m keeps running in the background after a timeout, WithBudget hands m
+// private copies of the values, made with the Copiers that CopierFor
+// returns, so that m cannot interfere with later values. Later values
+// do not wait for a call to m that timed out, so m must be safe to call
+// concurrently with itself. A call to m that never returns leaks its
+// goroutine, so m should itself give up eventually, for example through
+// a timeout on the request it makes. Use WithBudget for Mappers that call
+// external services and must not stall a pipeline.
+func WithBudget(m Mapper, budget time.Duration, fallback Mapper) Mapper {
+  return &budgetMapper{m: m, budget: budget, fallback: fallback}
+}
+
+type budgetMapper struct {
+  m Mapper
+  budget time.Duration
+  fallback Mapper
+}
+
+func (b *budgetMapper) Map(srcPtr, destPtr interface{}) error {
+  src := reflect.New(reflect.TypeOf(srcPtr).Elem()).Interface()
+  CopierFor(srcPtr)(srcPtr, src)
+  dest := reflect.New(reflect.TypeOf(destPtr).Elem()).Interface()
+  result := make(chan error, 1)
+  go func() {
+    result <- b.m.Map(src, dest)
+  }()
+  timer := time.NewTimer(b.budget)
+  defer timer.Stop()
+  select {
+    case err := <-result:
+      if err == nil {
+        CopierFor(destPtr)(dest, destPtr)
+      }
+      return err
+    case <-timer.C:
+      return b.fallback.Map(srcPtr, destPtr)
+  }
+}
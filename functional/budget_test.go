@@ -0,0 +1,43 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+    "time"
+)
+
+func TestWithBudget(t *testing.T) {
+  release := make(chan struct{})
+  defer close(release)
+  slowOnOdd := NewMapper(func(srcPtr, destPtr interface{}) error {
+    x := *srcPtr.(*int)
+    if x % 2 == 1 {
+      <-release
+    }
+    *destPtr.(*int) = x * 10
+    return nil
+  })
+  minusOne := NewMapper(func(srcPtr, destPtr interface{}) error {
+    *destPtr.(*int) = -1
+    return nil
+  })
+  s := Map(WithBudget(slowOnOdd, 10 * time.Millisecond, minusOne), xrange(0, 4), new(int))
+  results, _ := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[0 -1 20 -1]" {
+    t.Errorf("Expected [0 -1 20 -1] got %v", output)
+  }
+  s = Map(WithBudget(slowOnOdd, 10 * time.Millisecond, SkipOnTimeout), xrange(0, 4), new(int))
+  results, _ = toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[0 20]" {
+    t.Errorf("Expected [0 20] got %v", output)
+  }
+  m := WithBudget(errMapper, time.Second, minusOne)
+  if err := m.Map(ptrInt(1), new(int)); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
@@ -0,0 +1,102 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+  "sync"
+  "time"
+)
+
+// CachedDeferred returns a function that returns a Stream of T emitting
+// the values of the Stream f returns. The first time a returned Stream is
+// read, CachedDeferred reads all the values of f's Stream into a []T and
+// closes it. Streams returned within ttl after that emit those cached
+// values without calling f. Once ttl passes, the next Stream read calls f
+// again to refresh the cache. aSlice is a []T; its value is never read,
+// but CachedDeferred needs it to create the cache via reflection. If
+// reading f's Stream fails, the error is reported through Next and the
+// cache is left as it was. Emitted values are copied from the cache with
+// simple assignment. The returned function may be called from multiple
+// goroutines.
+func CachedDeferred(
+    f func() Stream, ttl time.Duration, aSlice interface{}) func() Stream {
+  return newStreamCache(f, ttl, aSlice, time.Now).stream
+}
+
+func newStreamCache(
+    f func() Stream,
+    ttl time.Duration,
+    aSlice interface{},
+    now func() time.Time) *streamCache {
+  return &streamCache{
+      f: f, ttl: ttl, sliceType: reflect.TypeOf(aSlice), now: now}
+}
+
+type streamCache struct {
+  f func() Stream
+  ttl time.Duration
+  sliceType reflect.Type
+  now func() time.Time
+  mutex sync.Mutex
+  values interface{}
+  expires time.Time
+}
+
+func (c *streamCache) stream() Stream {
+  return Deferred(func() Stream {
+    values, err := c.get()
+    if err != nil {
+      return errorStream{err}
+    }
+    return NewStreamFromValues(values, assignCopier)
+  })
+}
+
+func (c *streamCache) get() (interface{}, error) {
+  c.mutex.Lock()
+  defer c.mutex.Unlock()
+  if c.values != nil && c.now().Before(c.expires) {
+    return c.values, nil
+  }
+  values, err := c.load()
+  if err != nil {
+    return nil, err
+  }
+  c.values = values
+  c.expires = c.now().Add(c.ttl)
+  return values, nil
+}
+
+func (c *streamCache) load() (result interface{}, err error) {
+  s := c.f()
+  defer func() {
+    if cerr := s.Close(); err == nil {
+      err = cerr
+    }
+  }()
+  values := reflect.MakeSlice(c.sliceType, 0, 0)
+  ptr := reflect.New(c.sliceType.Elem())
+  for err = s.Next(ptr.Interface()); err == nil; err = s.Next(ptr.Interface()) {
+    values = reflect.Append(values, ptr.Elem())
+  }
+  if err != Done {
+    return nil, err
+  }
+  return values.Interface(), nil
+}
+
+type errorStream struct {
+  err error
+}
+
+func (s errorStream) Next(ptr interface{}) error {
+  return s.err
+}
+
+func (s errorStream) Close() error {
+  return nil
+}
@@ -0,0 +1,71 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+  "time"
+)
+
+func TestCachedDeferred(t *testing.T) {
+  calls := 0
+  now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+  var closed []*streamCloseChecker
+  factory := newStreamCache(func() Stream {
+    calls++
+    s := &streamCloseChecker{xrange(0, calls + 1), &simpleCloseChecker{}}
+    closed = append(closed, s)
+    return s
+  }, time.Minute, []int(nil), func() time.Time { return now }).stream
+  verifyCached(t, factory(), "[0 1]")
+  verifyCached(t, factory(), "[0 1]")
+  if calls != 1 {
+    t.Errorf("Expected 1 call, got %d", calls)
+  }
+  verifyCloseCalled(t, closed[0])
+  now = now.Add(time.Minute)
+  verifyCached(t, factory(), "[0 1 2]")
+  if calls != 2 {
+    t.Errorf("Expected 2 calls, got %d", calls)
+  }
+}
+
+func TestCachedDeferredLazy(t *testing.T) {
+  calls := 0
+  factory := CachedDeferred(func() Stream {
+    calls++
+    return xrange(0, 2)
+  }, time.Minute, []int(nil))
+  stream := factory()
+  stream.Close()
+  if calls != 0 {
+    t.Errorf("Expected no calls, got %d", calls)
+  }
+}
+
+func TestCachedDeferredError(t *testing.T) {
+  fail := true
+  factory := CachedDeferred(func() Stream {
+    if fail {
+      return Map(errMapper, Count(), new(int))
+    }
+    return xrange(0, 2)
+  }, time.Minute, []int(nil))
+  if _, err := toIntArray(factory()); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+  fail = false
+  verifyCached(t, factory(), "[0 1]")
+}
+
+func verifyCached(t *testing.T, s Stream, expected string) {
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+  verifyDone(t, s, new(int), err)
+}
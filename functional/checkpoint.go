@@ -0,0 +1,83 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// Checkpointed returns a Stream that emits the same values as s while
+// periodically saving a resume token. positionOf returns the resume token
+// for the value ptr points to, such as a primary key or line number.
+// After every values have been consumed, Checkpointed passes the token of
+// the last consumed value to save. A value counts as consumed only once the
+// caller asks for the next value, so a saved token never gets ahead of the
+// work actually done. When the end of s is reached, Checkpointed saves the
+// token of the last value if not already saved. Any error from save is
+// reported through Next. Calling Close on returned Stream closes s.
+//
+// To resume after a crash, load the last saved token and rebuild the source
+// to start after it, for example:
+//
+//   s := functional.Deferred(func() functional.Stream {
+//     return openAfter(loadToken())
+//   })
+//   s = functional.Checkpointed(s, 1000, positionOf, saveToken)
+//
+// Consumers must tolerate seeing up to every values again after resuming.
+func Checkpointed(
+    s Stream,
+    every int,
+    positionOf func(ptr interface{}) interface{},
+    save func(position interface{}) error) Stream {
+  if every < 1 {
+    every = 1
+  }
+  return &checkpointStream{
+      Stream: s, every: every, positionOf: positionOf, save: save}
+}
+
+type checkpointStream struct {
+  Stream
+  every int
+  positionOf func(ptr interface{}) interface{}
+  save func(position interface{}) error
+  count int
+  last interface{}
+  unsaved bool
+  done bool
+}
+
+func (s *checkpointStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  if s.unsaved && s.count % s.every == 0 {
+    if err := s.flush(); err != nil {
+      return err
+    }
+  }
+  err := s.Stream.Next(ptr)
+  if err == nil {
+    s.count++
+    s.last = s.positionOf(ptr)
+    s.unsaved = true
+    return nil
+  }
+  if err == Done {
+    s.done = true
+    if s.unsaved {
+      if serr := s.flush(); serr != nil {
+        return serr
+      }
+    }
+  }
+  return err
+}
+
+func (s *checkpointStream) flush() error {
+  if err := s.save(s.last); err != nil {
+    return err
+  }
+  s.unsaved = false
+  return nil
+}
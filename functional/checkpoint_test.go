@@ -0,0 +1,58 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestCheckpointed(t *testing.T) {
+  var saved []interface{}
+  stream := Checkpointed(
+      xrange(10, 17),
+      3,
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      func(position interface{}) error {
+        saved = append(saved, position)
+        return nil
+      })
+  var x int
+  for i := 0; i < 3; i++ {
+    stream.Next(&x)
+  }
+  if len(saved) != 0 {
+    t.Errorf("Expected nothing saved before value is consumed, got %v", saved)
+  }
+  stream.Next(&x)
+  if output := fmt.Sprintf("%v", saved); output != "[12]" {
+    t.Errorf("Expected [12] got %v", output)
+  }
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[14 15 16]" {
+    t.Errorf("Expected [14 15 16] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  if output := fmt.Sprintf("%v", saved); output != "[12 15 16]" {
+    t.Errorf("Expected [12 15 16] got %v", output)
+  }
+}
+
+func TestCheckpointedSaveError(t *testing.T) {
+  stream := Checkpointed(
+      xrange(0, 5),
+      2,
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      func(position interface{}) error { return mapError })
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1]" {
+    t.Errorf("Expected [0 1] got %v", output)
+  }
+  if err != mapError {
+    t.Errorf("Expected mapError got %v", err)
+  }
+  stream.Close()
+}
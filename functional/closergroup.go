@@ -0,0 +1,97 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "io"
+  "os"
+  "os/signal"
+  "sync"
+)
+
+// CloserGroup closes a set of Streams and other io.Closers all at once
+// so that long running pipelines can shut down cleanly. Since Shutdown
+// usually runs in a different goroutine than the one reading a Stream,
+// Streams being read should be wrapped with Synchronized before they are
+// added. The zero value is an empty CloserGroup ready to use.
+type CloserGroup struct {
+  mutex sync.Mutex
+  closers []io.Closer
+  shutdown bool
+  err error
+  done chan struct{}
+}
+
+// Add adds c to g. If g has already been shut down, Add closes c right
+// away.
+func (g *CloserGroup) Add(c io.Closer) {
+  g.mutex.Lock()
+  if !g.shutdown {
+    g.closers = append(g.closers, c)
+    g.mutex.Unlock()
+    return
+  }
+  g.mutex.Unlock()
+  c.Close()
+}
+
+// Shutdown closes everything added to g in the reverse order it was added
+// and returns all the errors from closing joined with errors.Join or nil
+// if there were none. Calling Shutdown more than once closes nothing
+// more and returns the same result as the first call.
+func (g *CloserGroup) Shutdown() error {
+  g.mutex.Lock()
+  defer g.mutex.Unlock()
+  if g.shutdown {
+    return g.err
+  }
+  g.shutdown = true
+  close(g.doneChan())
+  var errs []error
+  for i := len(g.closers) - 1; i >= 0; i-- {
+    if err := g.closers[i].Close(); err != nil {
+      errs = append(errs, err)
+    }
+  }
+  g.closers = nil
+  g.err = errors.Join(errs...)
+  return g.err
+}
+
+// ShutdownOn calls Shutdown as soon as the process receives one of sigs.
+// report, if non-nil, gets the result of Shutdown. ShutdownOn stops
+// listening for sigs once g is shut down for any reason.
+func (g *CloserGroup) ShutdownOn(report func(err error), sigs ...os.Signal) {
+  ch := make(chan os.Signal, 1)
+  signal.Notify(ch, sigs...)
+  done := g.Done()
+  go func() {
+    defer signal.Stop(ch)
+    select {
+      case <-ch:
+        err := g.Shutdown()
+        if report != nil {
+          report(err)
+        }
+      case <-done:
+    }
+  }()
+}
+
+// Done returns a channel that is closed once g begins shutting down.
+func (g *CloserGroup) Done() <-chan struct{} {
+  g.mutex.Lock()
+  defer g.mutex.Unlock()
+  return g.doneChan()
+}
+
+func (g *CloserGroup) doneChan() chan struct{} {
+  if g.done == nil {
+    g.done = make(chan struct{})
+  }
+  return g.done
+}
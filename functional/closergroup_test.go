@@ -0,0 +1,71 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "syscall"
+    "testing"
+)
+
+type orderCloser struct {
+  name string
+  closed *[]string
+  err error
+}
+
+func (c orderCloser) Close() error {
+  *c.closed = append(*c.closed, c.name)
+  return c.err
+}
+
+func TestCloserGroup(t *testing.T) {
+  var closed []string
+  var g CloserGroup
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  g.Add(s)
+  g.Add(orderCloser{"a", &closed, nil})
+  g.Add(orderCloser{"b", &closed, closeError})
+  g.Add(orderCloser{"c", &closed, scanError})
+  err := g.Shutdown()
+  if !errors.Is(err, closeError) || !errors.Is(err, scanError) {
+    t.Errorf("Expected both errors, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", closed); output != "[c b a]" {
+    t.Errorf("Expected [c b a] got %v", output)
+  }
+  verifyCloseCalled(t, s)
+  if g.Shutdown() != err {
+    t.Error("Expected same result from second Shutdown.")
+  }
+  select {
+    case <-g.Done():
+    default:
+      t.Error("Expected Done to be closed.")
+  }
+  g.Add(orderCloser{"d", &closed, nil})
+  if output := fmt.Sprintf("%v", closed); output != "[c b a d]" {
+    t.Errorf("Expected [c b a d] got %v", output)
+  }
+}
+
+func TestCloserGroupShutdownOn(t *testing.T) {
+  var g CloserGroup
+  s := Synchronized(&streamCloseChecker{Count(), &simpleCloseChecker{}})
+  g.Add(s)
+  reported := make(chan error)
+  g.ShutdownOn(func(err error) { reported <- err }, syscall.SIGUSR1)
+  syscall.Kill(syscall.Getpid(), syscall.SIGUSR1)
+  if err := <-reported; err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  verifyDone(t, s, new(int), Done)
+  var empty CloserGroup
+  if err := empty.Shutdown(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+}
@@ -5,6 +5,10 @@
 
 package functional
 
+import (
+  "reflect"
+)
+
 // A Consumer of T consumes the T values from a Stream of T.
 type Consumer interface {
 
@@ -25,14 +29,59 @@ func ModifyConsumerStream(c Consumer, f func(s Stream) Stream) Consumer {
 // until no Consumer in consumers is accepting values.
 // ptr is a *T that receives the values from s. copier is a Copier
 // of T used to copy T values to the Streams sent to each Consumer in
-// consumers. Passing null for copier means use simple assignment.
+// consumers. Passing nil for copier means use the Copier registered for T
+// or simple assignment if there is none.
 // Finally MultiConsume closes s and returns the result.
 func MultiConsume(s Stream, ptr interface{}, copier Copier, consumers ...Consumer) (closeError error) {
+  copiers := make([]Copier, len(consumers))
+  for i := range copiers {
+    copiers[i] = copier
+  }
+  return multiConsume(s, ptr, consumers, copiers)
+}
+
+// CopierSpec pairs a Consumer with the Copier MultiConsumeOpt uses to copy
+// values to it.
+type CopierSpec struct {
+  // Consumer is a Consumer of T.
+  Consumer Consumer
+  // Copier is a Copier of T. nil means use the Copier registered for T
+  // or simple assignment if there is none.
+  Copier Copier
+  // ReadOnly, if true, means Consumer promises not to modify the values
+  // it reads. In that case, rather than getting a copy of each T value,
+  // Consumer is a Consumer of *T that gets ptr itself, and Copier is
+  // ignored. Consumer may also call Next with a *interface{} which avoids
+  // reflection altogether. Either way, the *T Consumer gets is valid only
+  // until it calls Next again.
+  ReadOnly bool
+}
+
+// MultiConsumeOpt works like MultiConsume except that each Consumer gets
+// its own Copier. This way an expensive deep copy is done only for
+// the consumers that need it, and read-only consumers need no copy at all.
+func MultiConsumeOpt(s Stream, ptr interface{}, specs ...CopierSpec) (closeError error) {
+  consumers := make([]Consumer, len(specs))
+  copiers := make([]Copier, len(specs))
+  for i := range specs {
+    consumers[i] = specs[i].Consumer
+    if specs[i].ReadOnly {
+      copiers[i] = shareCopier
+    } else {
+      copiers[i] = specs[i].Copier
+    }
+  }
+  return multiConsume(s, ptr, consumers, copiers)
+}
+
+func multiConsume(s Stream, ptr interface{}, consumers []Consumer, copiers []Copier) (closeError error) {
   defer func() {
     closeError = s.Close()
   }()
-  if copier == nil {
-    copier = assignCopier
+  for i := range copiers {
+    if copiers[i] == nil {
+      copiers[i] = CopierFor(ptr)
+    }
   }
   streams := make([]splitStream, len(consumers))
   for i := range streams {
@@ -49,13 +98,23 @@ func MultiConsume(s Stream, ptr interface{}, copier Copier, consumers ...Consume
     for i := range streams {
       if !streams[i].isClosed() {
         p := streams[i].EmitPtr()
-        copier(ptr, p)
+        copiers[i](ptr, p)
       }
     }
   }
   return
 }
 
+// shareCopier stores the pointer src itself at dest, a **T or a
+// *interface{}.
+func shareCopier(src, dest interface{}) {
+  if p, ok := dest.(*interface{}); ok {
+    *p = src
+    return
+  }
+  reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(src))
+}
+
 type modifiedConsumerStream struct {
   c Consumer
   f func(s Stream) Stream
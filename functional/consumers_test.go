@@ -106,6 +106,88 @@ func TestReadPastEndConsumer(t *testing.T) {
   }
 }
 
+func TestMultiConsumeOpt(t *testing.T) {
+  s := &streamCloseChecker{Slice(Count(), 0, 5), &simpleCloseChecker{}}
+  ec := newEvenNumberConsumer()
+  oc := newOddNumberConsumer()
+  if output := MultiConsumeOpt(
+      s,
+      new(int),
+      CopierSpec{Consumer: ec},
+      CopierSpec{Consumer: oc, Copier: squareIntCopier}); output != nil {
+    t.Errorf("Expected MultiConsumeOpt to return nil, got %v", output)
+  }
+  if output := fmt.Sprintf("%v", ec.results); output != "[0 2 4]" {
+    t.Errorf("Expected [0 2 4] got %v", output)
+  }
+  if output := fmt.Sprintf("%v", oc.results); output != "[1 9]" {
+    t.Errorf("Expected [1 9] got %v", output)
+  }
+  verifyCloseCalled(t, s)
+}
+
+func TestMultiConsumeOptReadOnly(t *testing.T) {
+  s := Slice(Count(), 0, 3)
+  ptr := new(int)
+  tc := &typedPtrConsumer{}
+  ic := &interfacePtrConsumer{}
+  ec := newEvenNumberConsumer()
+  if output := MultiConsumeOpt(
+      s,
+      ptr,
+      CopierSpec{Consumer: tc, ReadOnly: true},
+      CopierSpec{Consumer: ic, ReadOnly: true, Copier: squareIntCopier},
+      CopierSpec{Consumer: ec}); output != nil {
+    t.Errorf("Expected MultiConsumeOpt to return nil, got %v", output)
+  }
+  if output := fmt.Sprintf("%v", tc.results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  if output := fmt.Sprintf("%v", ic.results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  if !tc.shared || !ic.shared {
+    t.Error("Expected read-only consumers to get the shared pointer.")
+  }
+  if output := fmt.Sprintf("%v", ec.results); output != "[0 2]" {
+    t.Errorf("Expected [0 2] got %v", output)
+  }
+}
+
+type typedPtrConsumer struct {
+  results []int
+  shared bool
+}
+
+func (c *typedPtrConsumer) Consume(s Stream) {
+  var p *int
+  var first *int
+  for s.Next(&p) == nil {
+    if first == nil {
+      first = p
+    }
+    c.shared = p == first
+    c.results = append(c.results, *p)
+  }
+}
+
+type interfacePtrConsumer struct {
+  results []int
+  shared bool
+}
+
+func (c *interfacePtrConsumer) Consume(s Stream) {
+  var p interface{}
+  var first interface{}
+  for s.Next(&p) == nil {
+    if first == nil {
+      first = p
+    }
+    c.shared = p == first
+    c.results = append(c.results, *p.(*int))
+  }
+}
+
 type filterConsumer struct {
   f Filterer
   results []int
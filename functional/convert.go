@@ -0,0 +1,98 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "strconv"
+)
+
+var (
+  // IntToFloat64 maps int values to float64 values.
+  IntToFloat64 Mapper = intToFloat64{}
+  // Float64ToInt maps float64 values to int values truncating toward zero.
+  Float64ToInt Mapper = float64ToInt{}
+  // IntToInt64 maps int values to int64 values.
+  IntToInt64 Mapper = intToInt64{}
+  // Int64ToInt maps int64 values to int values.
+  Int64ToInt Mapper = int64ToInt{}
+  // IntToString maps int values to their decimal string representation.
+  IntToString Mapper = intToString{}
+)
+
+// Float64ToString returns a Mapper that maps float64 values to strings
+// using strconv.FormatFloat with format fmt and precision prec.
+func Float64ToString(fmt byte, prec int) Mapper {
+  return float64ToString{fmt: fmt, prec: prec}
+}
+
+// IntsToFloats converts a Stream of int into a Stream of float64.
+// Calling Close on returned Stream closes s.
+func IntsToFloats(s Stream) Stream {
+  return Map(IntToFloat64, s, new(int))
+}
+
+// IntsToStrings converts a Stream of int into a Stream of string.
+// Calling Close on returned Stream closes s.
+func IntsToStrings(s Stream) Stream {
+  return Map(IntToString, s, new(int))
+}
+
+// FloatsToStrings converts a Stream of float64 into a Stream of string
+// formatting each value as strconv.FormatFloat would with format fmt
+// and precision prec. Calling Close on returned Stream closes s.
+func FloatsToStrings(s Stream, fmt byte, prec int) Stream {
+  return Map(Float64ToString(fmt, prec), s, new(float64))
+}
+
+type intToFloat64 struct {
+}
+
+func (m intToFloat64) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*float64) = float64(*srcPtr.(*int))
+  return nil
+}
+
+type float64ToInt struct {
+}
+
+func (m float64ToInt) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*int) = int(*srcPtr.(*float64))
+  return nil
+}
+
+type intToInt64 struct {
+}
+
+func (m intToInt64) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*int64) = int64(*srcPtr.(*int))
+  return nil
+}
+
+type int64ToInt struct {
+}
+
+func (m int64ToInt) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*int) = int(*srcPtr.(*int64))
+  return nil
+}
+
+type intToString struct {
+}
+
+func (m intToString) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*string) = strconv.Itoa(*srcPtr.(*int))
+  return nil
+}
+
+type float64ToString struct {
+  fmt byte
+  prec int
+}
+
+func (m float64ToString) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*string) = strconv.FormatFloat(*srcPtr.(*float64), m.fmt, m.prec, 64)
+  return nil
+}
@@ -0,0 +1,42 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "strings"
+    "testing"
+)
+
+func TestIntsToFloatsToStrings(t *testing.T) {
+  stream := FloatsToStrings(IntsToFloats(xrange(1, 4)), 'f', 2)
+  results, err := toStringArray(stream)
+  if output := strings.Join(results, ","); output != "1.00,2.00,3.00" {
+    t.Errorf("Expected 1.00,2.00,3.00 got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestIntsToStrings(t *testing.T) {
+  stream := IntsToStrings(xrange(8, 11))
+  results, err := toStringArray(stream)
+  if output := strings.Join(results, ","); output != "8,9,10" {
+    t.Errorf("Expected 8,9,10 got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestInt64Conversions(t *testing.T) {
+  var x int64
+  IntToInt64.Map(ptrInt(7), &x)
+  var y int
+  Int64ToInt.Map(&x, &y)
+  f := -2.7
+  var z int
+  Float64ToInt.Map(&f, &z)
+  if x != 7 || y != 7 || z != -2 {
+    t.Errorf("Expected 7 7 -2, got %v %v %v", x, y, z)
+  }
+}
@@ -0,0 +1,211 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+  "sync"
+)
+
+var (
+  deepCopyMutex sync.Mutex
+  deepCopyFuncs = make(map[reflect.Type]*deepCopyFunc)
+  // deepCopyBuilt caches the deepCopyFuncs that are fully built so that
+  // they can be looked up without taking deepCopyMutex.
+  deepCopyBuilt sync.Map
+  registryMutex sync.RWMutex
+  registry = make(map[reflect.Type]Copier)
+)
+
+// RegisterCopier registers c as the Copier of T to use wherever a nil
+// Copier of T is passed to this package, where example is a T. This way
+// a project can decide once how its types are copied. Passing nil for c
+// removes any registered Copier of T. RegisterCopier is typically called
+// from an init function.
+func RegisterCopier(example interface{}, c Copier) {
+  t := reflect.TypeOf(example)
+  registryMutex.Lock()
+  defer registryMutex.Unlock()
+  if c == nil {
+    delete(registry, t)
+  } else {
+    registry[t] = c
+  }
+}
+
+// CopierFor returns the Copier of T registered with RegisterCopier or,
+// if there is none, a Copier that uses simple assignment. ptr is a *T.
+func CopierFor(ptr interface{}) Copier {
+  if c := registeredCopier(reflect.TypeOf(ptr).Elem()); c != nil {
+    return c
+  }
+  return assignCopier
+}
+
+func registeredCopier(t reflect.Type) Copier {
+  registryMutex.RLock()
+  defer registryMutex.RUnlock()
+  return registry[t]
+}
+
+// DeepCopier returns a Copier of T where example is a T. Unlike simple
+// assignment, the returned Copier follows pointers and copies slices, maps,
+// and interface values so that the source and destination share no
+// memory. A pointer or map reached more than once within the value, as in
+// cyclic structures, is copied once and the copy shared the same way.
+// Unexported struct fields cannot be reached with reflection and are
+// copied by simple assignment. The copy routine for each type is built
+// once and cached.
+func DeepCopier(example interface{}) Copier {
+  f := deepCopyFuncFor(reflect.TypeOf(example))
+  return func(src, dest interface{}) {
+    f.copy(reflect.ValueOf(dest).Elem(), reflect.ValueOf(src).Elem(), &copyState{})
+  }
+}
+
+// deepCopyFunc copies src to dst. Its copy field is filled in after
+// creation so that recursive types can refer to themselves.
+type deepCopyFunc struct {
+  copy func(dst, src reflect.Value, state *copyState)
+}
+
+// copyKey identifies a pointer or map already copied. The type is part
+// of the key since a struct and its first field share an address.
+type copyKey struct {
+  ptr uintptr
+  t reflect.Type
+}
+
+// copyState tracks what one deep copy has copied so far.
+type copyState struct {
+  copied map[copyKey]reflect.Value
+}
+
+func (s *copyState) lookup(src reflect.Value) (reflect.Value, bool) {
+  v, ok := s.copied[copyKey{src.Pointer(), src.Type()}]
+  return v, ok
+}
+
+func (s *copyState) add(src, dst reflect.Value) {
+  if s.copied == nil {
+    s.copied = make(map[copyKey]reflect.Value)
+  }
+  s.copied[copyKey{src.Pointer(), src.Type()}] = dst
+}
+
+func deepCopyFuncFor(t reflect.Type) *deepCopyFunc {
+  if f, ok := deepCopyBuilt.Load(t); ok {
+    return f.(*deepCopyFunc)
+  }
+  deepCopyMutex.Lock()
+  defer deepCopyMutex.Unlock()
+  f := buildDeepCopyFunc(t)
+  deepCopyBuilt.Store(t, f)
+  return f
+}
+
+// buildDeepCopyFunc must be called with deepCopyMutex held.
+func buildDeepCopyFunc(t reflect.Type) *deepCopyFunc {
+  if f, ok := deepCopyFuncs[t]; ok {
+    return f
+  }
+  f := &deepCopyFunc{}
+  deepCopyFuncs[t] = f
+  switch t.Kind() {
+  case reflect.Ptr:
+    elem := buildDeepCopyFunc(t.Elem())
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      if src.IsNil() {
+        dst.Set(src)
+        return
+      }
+      if p, ok := state.lookup(src); ok {
+        dst.Set(p)
+        return
+      }
+      p := reflect.New(t.Elem())
+      state.add(src, p)
+      elem.copy(p.Elem(), src.Elem(), state)
+      dst.Set(p)
+    }
+  case reflect.Slice:
+    elem := buildDeepCopyFunc(t.Elem())
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      if src.IsNil() {
+        dst.Set(src)
+        return
+      }
+      s := reflect.MakeSlice(t, src.Len(), src.Len())
+      for i := 0; i < src.Len(); i++ {
+        elem.copy(s.Index(i), src.Index(i), state)
+      }
+      dst.Set(s)
+    }
+  case reflect.Array:
+    elem := buildDeepCopyFunc(t.Elem())
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      for i := 0; i < src.Len(); i++ {
+        elem.copy(dst.Index(i), src.Index(i), state)
+      }
+    }
+  case reflect.Map:
+    key := buildDeepCopyFunc(t.Key())
+    elem := buildDeepCopyFunc(t.Elem())
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      if src.IsNil() {
+        dst.Set(src)
+        return
+      }
+      if m, ok := state.lookup(src); ok {
+        dst.Set(m)
+        return
+      }
+      m := reflect.MakeMapWithSize(t, src.Len())
+      state.add(src, m)
+      iter := src.MapRange()
+      for iter.Next() {
+        k := reflect.New(t.Key()).Elem()
+        key.copy(k, iter.Key(), state)
+        v := reflect.New(t.Elem()).Elem()
+        elem.copy(v, iter.Value(), state)
+        m.SetMapIndex(k, v)
+      }
+      dst.Set(m)
+    }
+  case reflect.Struct:
+    var fields []int
+    var fieldFuncs []*deepCopyFunc
+    for i := 0; i < t.NumField(); i++ {
+      if t.Field(i).PkgPath == "" {
+        fields = append(fields, i)
+        fieldFuncs = append(fieldFuncs, buildDeepCopyFunc(t.Field(i).Type))
+      }
+    }
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      dst.Set(src)
+      for i, idx := range fields {
+        fieldFuncs[i].copy(dst.Field(idx), src.Field(idx), state)
+      }
+    }
+  case reflect.Interface:
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      if src.IsNil() {
+        dst.Set(src)
+        return
+      }
+      actual := src.Elem()
+      c := deepCopyFuncFor(actual.Type())
+      v := reflect.New(actual.Type()).Elem()
+      c.copy(v, actual, state)
+      dst.Set(v)
+    }
+  default:
+    f.copy = func(dst, src reflect.Value, state *copyState) {
+      dst.Set(src)
+    }
+  }
+  return f
+}
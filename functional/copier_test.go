@@ -0,0 +1,148 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "testing"
+)
+
+func TestDeepCopier(t *testing.T) {
+  n := 5
+  src := deepRecord{
+      Name: "a",
+      Tags: []string{"x", "y"},
+      Counts: map[string]int{"k": 1},
+      Ptr: &n,
+      Any: []int{1},
+      Arr: [2][]int{{1}, {2}},
+      Next: &deepRecord{Name: "b", Tags: []string{"z"}},
+      hidden: 3}
+  var dest deepRecord
+  copier := DeepCopier(src)
+  copier(&src, &dest)
+  src.Tags[0] = "changed"
+  src.Counts["k"] = 2
+  *src.Ptr = 6
+  src.Any.([]int)[0] = 2
+  src.Arr[0][0] = 3
+  src.Next.Tags[0] = "changed"
+  if dest.Name != "a" || dest.Tags[0] != "x" || dest.Counts["k"] != 1 {
+    t.Errorf("Expected deep copy, got %v", dest)
+  }
+  if *dest.Ptr != 5 || dest.Any.([]int)[0] != 1 || dest.Arr[0][0] != 1 {
+    t.Errorf("Expected deep copy, got %v", dest)
+  }
+  if dest.Next.Name != "b" || dest.Next.Tags[0] != "z" || dest.Next.Next != nil {
+    t.Errorf("Expected deep copy of Next, got %v", dest.Next)
+  }
+  if dest.hidden != 3 {
+    t.Errorf("Expected unexported field assigned, got %d", dest.hidden)
+  }
+}
+
+func TestDeepCopierNils(t *testing.T) {
+  src := deepRecord{Name: "a"}
+  dest := deepRecord{Tags: []string{"old"}, Counts: map[string]int{}}
+  DeepCopier(src)(&src, &dest)
+  if dest.Tags != nil || dest.Counts != nil || dest.Ptr != nil || dest.Any != nil {
+    t.Errorf("Expected nils copied, got %v", dest)
+  }
+}
+
+func TestDeepCopierCycles(t *testing.T) {
+  shared := 7
+  first := &deepRecord{Name: "first", Ptr: &shared}
+  second := &deepRecord{Name: "second", Ptr: &shared, Next: first}
+  first.Next = second
+  first.Any = first
+  var dest deepRecord
+  DeepCopier(deepRecord{})(first, &dest)
+  copied := dest.Next.Next
+  if copied == first || copied.Name != "first" || copied.Next != dest.Next {
+    t.Errorf("Expected cycle copied, got %v", copied)
+  }
+  if copied.Any.(*deepRecord) != copied {
+    t.Error("Expected pointer in interface to refer to the copy")
+  }
+  if dest.Ptr == &shared || dest.Ptr != dest.Next.Ptr {
+    t.Error("Expected shared pointer copied once")
+  }
+}
+
+func TestDeepCopierWithStream(t *testing.T) {
+  values := [][]int{{1, 2}, {3}}
+  stream := NewStreamFromValues(values, DeepCopier([]int(nil)))
+  var x []int
+  stream.Next(&x)
+  x[0] = 100
+  if values[0][0] != 1 {
+    t.Error("Expected stream to emit deep copies.")
+  }
+}
+
+type deepRecord struct {
+  Name string
+  Tags []string
+  Counts map[string]int
+  Ptr *int
+  Any interface{}
+  Arr [2][]int
+  Next *deepRecord
+  hidden int
+}
+
+func TestRegisterCopier(t *testing.T) {
+  RegisterCopier(registeredInt(0), doubleRegisteredInt)
+  defer RegisterCopier(registeredInt(0), nil)
+  results := make([]registeredInt, 0)
+  stream := NewStreamFromValues([]registeredInt{1, 2}, nil)
+  var x registeredInt
+  for stream.Next(&x) == nil {
+    results = append(results, x)
+  }
+  if len(results) != 2 || results[0] != 2 || results[1] != 4 {
+    t.Errorf("Expected [2 4], got %v", results)
+  }
+  one := registeredInt(1)
+  stream = NewStreamFromPtrs([]*registeredInt{&one}, nil)
+  stream.Next(&x)
+  if x != 2 {
+    t.Errorf("Expected 2, got %v", x)
+  }
+  CopierFor(new(registeredInt))(&one, &x)
+  if x != 2 {
+    t.Errorf("Expected 2, got %v", x)
+  }
+}
+
+func TestRegisterCopierRemoved(t *testing.T) {
+  RegisterCopier(registeredInt(0), doubleRegisteredInt)
+  RegisterCopier(registeredInt(0), nil)
+  one, x := registeredInt(1), registeredInt(0)
+  CopierFor(&x)(&one, &x)
+  if x != 1 {
+    t.Errorf("Expected 1, got %v", x)
+  }
+}
+
+func TestRegisterCopierValueMapper(t *testing.T) {
+  RegisterCopier(registeredInt(0), doubleRegisteredInt)
+  defer RegisterCopier(registeredInt(0), nil)
+  m := NewValueMapper(func(srcPtr interface{}) (interface{}, bool) {
+    return registeredInt(*srcPtr.(*int)), true
+  }, nil)
+  var x registeredInt
+  m.Map(ptrInt(3), &x)
+  if x != 6 {
+    t.Errorf("Expected 6, got %v", x)
+  }
+}
+
+type registeredInt int
+
+func doubleRegisteredInt(src, dest interface{}) {
+  *dest.(*registeredInt) = 2 * *src.(*registeredInt)
+}
@@ -0,0 +1,134 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "bufio"
+  "io"
+  "unicode/utf16"
+  "unicode/utf8"
+)
+
+// Decoder converts text in some encoding read from r to UTF-8. Decoders
+// from packages such as golang.org/x/text/encoding can be adapted with a
+// function literal calling transform.NewReader.
+type Decoder func(r io.Reader) io.Reader
+
+var (
+  // Latin1 decodes ISO-8859-1 text.
+  Latin1 Decoder = func(r io.Reader) io.Reader {
+    return &decodeReader{r: bufio.NewReader(r), next: nextLatin1}
+  }
+  // UTF16LE decodes little endian UTF-16 text such as files exported on
+  // Windows.
+  UTF16LE Decoder = func(r io.Reader) io.Reader {
+    return &decodeReader{r: bufio.NewReader(r), next: nextUTF16(false)}
+  }
+  // UTF16BE decodes big endian UTF-16 text.
+  UTF16BE Decoder = func(r io.Reader) io.Reader {
+    return &decodeReader{r: bufio.NewReader(r), next: nextUTF16(true)}
+  }
+)
+
+// DetectBOM decodes text that may start with a byte order mark. A UTF-16
+// mark selects UTF16LE or UTF16BE; otherwise the text is taken to be UTF-8.
+// ReadLines already does this on its own; use DetectBOM when reading such
+// text some other way. The mark itself becomes a leading U+FEFF.
+var DetectBOM Decoder = func(r io.Reader) io.Reader {
+  br := bufio.NewReader(r)
+  b, _ := br.Peek(2)
+  if len(b) == 2 && b[0] == 0xff && b[1] == 0xfe {
+    return &decodeReader{r: br, next: nextUTF16(false)}
+  }
+  if len(b) == 2 && b[0] == 0xfe && b[1] == 0xff {
+    return &decodeReader{r: br, next: nextUTF16(true)}
+  }
+  return br
+}
+
+const utf8BOM = "\ufeff"
+
+// decode returns r decoded with d keeping the ability to close r.
+func decode(r io.Reader, d Decoder) io.Reader {
+  decoded := d(r)
+  if c, ok := r.(io.Closer); ok {
+    return struct {
+      io.Reader
+      io.Closer
+    }{decoded, c}
+  }
+  return decoded
+}
+
+// decodeReader converts one rune at a time using next.
+type decodeReader struct {
+  r *bufio.Reader
+  next func(r *bufio.Reader) (rune, error)
+  pending []byte
+  err error
+}
+
+func (d *decodeReader) Read(p []byte) (int, error) {
+  var buf [utf8.UTFMax]byte
+  for len(d.pending) < len(p) && d.err == nil {
+    var ch rune
+    ch, d.err = d.next(d.r)
+    if d.err == nil {
+      n := utf8.EncodeRune(buf[:], ch)
+      d.pending = append(d.pending, buf[:n]...)
+    }
+  }
+  n := copy(p, d.pending)
+  d.pending = d.pending[n:]
+  if len(d.pending) == 0 && d.err != nil {
+    return n, d.err
+  }
+  return n, nil
+}
+
+func nextLatin1(r *bufio.Reader) (rune, error) {
+  b, err := r.ReadByte()
+  return rune(b), err
+}
+
+func nextUTF16(bigEndian bool) func(r *bufio.Reader) (rune, error) {
+  readUnit := func(r *bufio.Reader) (rune, error) {
+    var b [2]byte
+    if _, err := io.ReadFull(r, b[:]); err != nil {
+      if err == io.ErrUnexpectedEOF {
+        return utf8.RuneError, nil
+      }
+      return 0, err
+    }
+    if bigEndian {
+      return rune(b[0]) << 8 | rune(b[1]), nil
+    }
+    return rune(b[1]) << 8 | rune(b[0]), nil
+  }
+  return func(r *bufio.Reader) (rune, error) {
+    unit, err := readUnit(r)
+    if err != nil || !utf16.IsSurrogate(unit) {
+      return unit, err
+    }
+    // A high surrogate should be followed by a low one.
+    if unit >= 0xdc00 {
+      return utf8.RuneError, nil
+    }
+    b, err := r.Peek(2)
+    if err != nil {
+      return utf8.RuneError, nil
+    }
+    low := rune(b[1]) << 8 | rune(b[0])
+    if bigEndian {
+      low = rune(b[0]) << 8 | rune(b[1])
+    }
+    if low < 0xdc00 || low > 0xdfff {
+      return utf8.RuneError, nil
+    }
+    r.Discard(2)
+    return utf16.DecodeRune(unit, low), nil
+  }
+}
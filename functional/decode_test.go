@@ -0,0 +1,104 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "bytes"
+  "fmt"
+  "io/ioutil"
+  "testing"
+  "unicode/utf16"
+)
+
+func TestLatin1(t *testing.T) {
+  r := Latin1(bytes.NewReader([]byte{'c', 'a', 'f', 0xe9}))
+  if output := readAllString(t, r); output != "café" {
+    t.Errorf("Expected café, got %q", output)
+  }
+}
+
+func TestUTF16(t *testing.T) {
+  text := "hé\r\n𝄞x"
+  if output := readAllString(t, UTF16LE(bytes.NewReader(utf16Bytes(text, false)))); output != text {
+    t.Errorf("Expected %q, got %q", text, output)
+  }
+  if output := readAllString(t, UTF16BE(bytes.NewReader(utf16Bytes(text, true)))); output != text {
+    t.Errorf("Expected %q, got %q", text, output)
+  }
+}
+
+func TestUTF16Malformed(t *testing.T) {
+  // Lone low surrogate, lone high surrogate, then an odd trailing byte.
+  data := []byte{0x00, 0xdc, 0x00, 0xd8, 'a', 0x00, 'b'}
+  if output := readAllString(t, UTF16LE(bytes.NewReader(data))); output != "��a�" {
+    t.Errorf("Got %q", output)
+  }
+}
+
+func TestReadLinesOptDecoder(t *testing.T) {
+  r := &readerCloseChecker{
+      bytes.NewReader(utf16Bytes("a\r\nb\r\n", false)), &simpleCloseChecker{}}
+  stream := ReadLinesOpt(r, LineOptions{Decoder: UTF16LE})
+  results, err := toStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[a b]" {
+    t.Errorf("Expected [a b] got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+  verifyCloseCalled(t, r)
+}
+
+func readAllString(t *testing.T, r interface{ Read([]byte) (int, error) }) string {
+  b, err := ioutil.ReadAll(r)
+  if err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  return string(b)
+}
+
+func utf16Bytes(s string, bigEndian bool) []byte {
+  var result []byte
+  for _, u := range utf16.Encode([]rune(s)) {
+    if bigEndian {
+      result = append(result, byte(u >> 8), byte(u))
+    } else {
+      result = append(result, byte(u), byte(u >> 8))
+    }
+  }
+  return result
+}
+
+func TestDetectBOM(t *testing.T) {
+  text := "\ufeffa\nb"
+  inputs := [][]byte{
+      []byte(text),
+      utf16Bytes(text, false),
+      utf16Bytes(text, true),
+      []byte("a\nb")}
+  for _, input := range inputs {
+    stream := ReadLinesOpt(bytes.NewReader(input), LineOptions{Decoder: DetectBOM})
+    results, _ := toStringArray(stream)
+    if output := fmt.Sprintf("%q", results); output != `["a" "b"]` {
+      t.Errorf("Expected [\"a\" \"b\"] got %v for %v", output, input)
+    }
+  }
+}
+
+func TestReadLinesDetectsUTF16(t *testing.T) {
+  text := "\ufeffcafé\r\nb"
+  for _, input := range [][]byte{utf16Bytes(text, false), utf16Bytes(text, true)} {
+    results, err := toStringArray(ReadLines(bytes.NewReader(input)))
+    if output := fmt.Sprintf("%q", results); output != `["café" "b"]` || err != Done {
+      t.Errorf("Expected [\"café\" \"b\"] got %v and %v", output, err)
+    }
+  }
+}
+
+func TestReadLinesDropsBOM(t *testing.T) {
+  results, _ := toStringArray(ReadLines(bytes.NewReader([]byte("\ufeffa\n\ufeffb"))))
+  if output := fmt.Sprintf("%q", results); output != "[\"a\" \"\\ufeffb\"]" {
+    t.Errorf("Got %v", output)
+  }
+}
@@ -0,0 +1,79 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "container/list"
+  "time"
+)
+
+// DedupeByKey returns a Stream that emits the values of s, a Stream of T,
+// leaving out values whose key was emitted within the last ttl. key
+// returns the key of the T value ptr points to; keys must be usable as
+// map keys. Since a key's age counts from when it was last emitted, a
+// value that keeps repeating is emitted again once every ttl, which suits
+// suppressing repeated alerts. A ttl of 0 means keys never expire. To
+// bound memory, at most maxEntries keys are remembered, forgetting the
+// least recently seen keys first; maxEntries less than 1 means no limit.
+// Unlike extsort.Deduper, DedupeByKey only suppresses duplicates that are
+// close together. Calling Close on returned Stream closes s.
+func DedupeByKey(
+    key func(ptr interface{}) interface{},
+    maxEntries int,
+    ttl time.Duration,
+    s Stream) Stream {
+  return Filter(newDedupeFilterer(key, maxEntries, ttl, time.Now), s)
+}
+
+type dedupeEntry struct {
+  key interface{}
+  emitted time.Time
+}
+
+type dedupeFilterer struct {
+  key func(ptr interface{}) interface{}
+  maxEntries int
+  ttl time.Duration
+  now func() time.Time
+  // lru has the most recently seen entries at the front.
+  lru *list.List
+  entries map[interface{}]*list.Element
+}
+
+func newDedupeFilterer(
+    key func(ptr interface{}) interface{},
+    maxEntries int,
+    ttl time.Duration,
+    now func() time.Time) *dedupeFilterer {
+  return &dedupeFilterer{
+      key: key,
+      maxEntries: maxEntries,
+      ttl: ttl,
+      now: now,
+      lru: list.New(),
+      entries: make(map[interface{}]*list.Element)}
+}
+
+func (f *dedupeFilterer) Filter(ptr interface{}) error {
+  k := f.key(ptr)
+  now := f.now()
+  if e, ok := f.entries[k]; ok {
+    f.lru.MoveToFront(e)
+    entry := e.Value.(*dedupeEntry)
+    if f.ttl <= 0 || now.Sub(entry.emitted) < f.ttl {
+      return Skipped
+    }
+    entry.emitted = now
+    return nil
+  }
+  f.entries[k] = f.lru.PushFront(&dedupeEntry{key: k, emitted: now})
+  if f.maxEntries > 0 && f.lru.Len() > f.maxEntries {
+    oldest := f.lru.Back()
+    f.lru.Remove(oldest)
+    delete(f.entries, oldest.Value.(*dedupeEntry).key)
+  }
+  return nil
+}
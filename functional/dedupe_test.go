@@ -0,0 +1,51 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+    "time"
+)
+
+func intKey(ptr interface{}) interface{} {
+  return *ptr.(*int)
+}
+
+func TestDedupeByKey(t *testing.T) {
+  s := &streamCloseChecker{
+      NewStreamFromValues([]int{1, 2, 1, 3, 2, 1}, nil),
+      &simpleCloseChecker{}}
+  stream := DedupeByKey(intKey, 0, 0, s)
+  results, _ := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3]" {
+    t.Errorf("Expected [1 2 3] got %v", output)
+  }
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s)
+}
+
+func TestDedupeByKeyMaxEntries(t *testing.T) {
+  stream := DedupeByKey(intKey, 2, 0, NewStreamFromValues([]int{1, 2, 1, 3, 2, 1}, nil))
+  results, _ := toIntArray(stream)
+  // 3 evicts 2 since 1 was seen more recently; then 2 evicts 1.
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 2 1]" {
+    t.Errorf("Expected [1 2 3 2 1] got %v", output)
+  }
+}
+
+func TestDedupeByKeyTTL(t *testing.T) {
+  now := time.Date(2013, 5, 1, 0, 0, 0, 0, time.UTC)
+  f := newDedupeFilterer(intKey, 0, time.Minute, func() time.Time { return now })
+  var results []error
+  for _, advance := range []time.Duration{0, 30 * time.Second, 30 * time.Second, 59 * time.Second} {
+    now = now.Add(advance)
+    results = append(results, f.Filter(ptrInt(7)))
+  }
+  if output := fmt.Sprintf("%v", results); output != fmt.Sprintf("[<nil> %v <nil> %v]", Skipped, Skipped) {
+    t.Errorf("Expected emit, skip, emit, skip got %v", output)
+  }
+}
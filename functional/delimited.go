@@ -0,0 +1,72 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "bufio"
+  "encoding/binary"
+  "errors"
+  "io"
+)
+
+// MaxDelimitedSize is the largest message ReadDelimited accepts. It
+// guards against allocating huge buffers when reading corrupt data.
+const MaxDelimitedSize = 64 << 20
+
+// Unmarshaler is implemented by messages that can decode themselves such
+// as those generated by many protocol buffer compilers.
+type Unmarshaler interface {
+  // Unmarshal replaces the contents of the message with the decoded b.
+  Unmarshal(b []byte) error
+}
+
+// ReadDelimited returns the messages in r as a Stream of T where each
+// message is preceded by its length encoded as a varint, the standard
+// framing for streams of protocol buffer messages. The pointer passed to
+// Next must be a *T that implements Unmarshaler. Next returns
+// io.ErrUnexpectedEOF if r ends in the middle of a message. When end of
+// returned Stream is reached, it closes r if r implements io.Closer
+// propagating any Close error through Next. Calling Close on returned
+// Stream closes r if r implements io.Closer.
+func ReadDelimited(r io.Reader) Stream {
+  c, _ := r.(io.Closer)
+  return &delimitedStream{bufio: bufio.NewReader(r), maybeCloser: maybeCloser{c: c}}
+}
+
+type delimitedStream struct {
+  bufio *bufio.Reader
+  maybeCloser
+  buf []byte
+  done bool
+}
+
+func (s *delimitedStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  size, err := binary.ReadUvarint(s.bufio)
+  if err == io.EOF {
+    s.done = true
+    return finish(s.Close())
+  }
+  if err != nil {
+    return err
+  }
+  if size > MaxDelimitedSize {
+    return errors.New("functional: delimited message too large.")
+  }
+  if uint64(cap(s.buf)) < size {
+    s.buf = make([]byte, size)
+  }
+  s.buf = s.buf[:size]
+  if _, err = io.ReadFull(s.bufio, s.buf); err != nil {
+    if err == io.EOF {
+      return io.ErrUnexpectedEOF
+    }
+    return err
+  }
+  return ptr.(Unmarshaler).Unmarshal(s.buf)
+}
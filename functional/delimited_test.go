@@ -0,0 +1,53 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "bytes"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+)
+
+type textMessage string
+
+func (m *textMessage) Unmarshal(b []byte) error {
+  *m = textMessage(b)
+  return nil
+}
+
+func TestReadDelimited(t *testing.T) {
+  r := &readerCloseChecker{
+      bytes.NewReader([]byte{3, 'a', 'b', 'c', 0, 2, 'x', 'y'}),
+      &simpleCloseChecker{}}
+  s := ReadDelimited(r)
+  var m textMessage
+  var results []string
+  var err error
+  for err = s.Next(&m); err == nil; err = s.Next(&m) {
+    results = append(results, string(m))
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := fmt.Sprintf("%q", results); output != `["abc" "" "xy"]` {
+    t.Errorf("Expected abc, empty, xy got %v", output)
+  }
+  verifyCloseCalled(t, r)
+}
+
+func TestReadDelimitedErrors(t *testing.T) {
+  var m textMessage
+  s := ReadDelimited(bytes.NewReader([]byte{3, 'a'}))
+  if err := s.Next(&m); err != io.ErrUnexpectedEOF {
+    t.Errorf("Expected io.ErrUnexpectedEOF, got %v", err)
+  }
+  s = ReadDelimited(bytes.NewReader([]byte{0xff, 0xff, 0xff, 0xff, 0x7f}))
+  if err := s.Next(&m); err == nil || !strings.Contains(err.Error(), "too large") {
+    t.Errorf("Expected too large error, got %v", err)
+  }
+}
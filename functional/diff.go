@@ -0,0 +1,154 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// DiffOp is the kind of edit in a DiffLine.
+type DiffOp int
+
+const (
+  // DiffKeep means the line is in both Streams.
+  DiffKeep DiffOp = iota
+  // DiffRemove means the line is only in the first Stream.
+  DiffRemove
+  // DiffAdd means the line is only in the second Stream.
+  DiffAdd
+)
+
+// String returns the prefix diff uses for op: " ", "-", or "+".
+func (op DiffOp) String() string {
+  switch op {
+  case DiffRemove:
+    return "-"
+  case DiffAdd:
+    return "+"
+  }
+  return " "
+}
+
+// DiffLine is a single edit emitted by DiffLines.
+type DiffLine struct {
+  Op DiffOp
+  Text string
+}
+
+// String returns d as it would appear in diff output, e.g "+added line".
+func (d DiffLine) String() string {
+  return d.Op.String() + d.Text
+}
+
+// DiffLines returns a Stream of DiffLine that edits a, a Stream of
+// string, into b, a Stream of string. It is the same as calling
+// DiffLinesWindow(a, b, 100).
+func DiffLines(a, b Stream) Stream {
+  return DiffLinesWindow(a, b, 100)
+}
+
+// DiffLinesWindow returns a Stream of DiffLine that edits a, a Stream of
+// string, into b, a Stream of string. Rather than reading a and b
+// entirely, DiffLinesWindow looks at most window lines ahead in each to
+// find where they agree again after they differ, so memory stays bounded
+// but a block of changes longer than window may come out as a removal
+// and addition of lines the two Streams share. Calling Close on returned
+// Stream closes both a and b.
+func DiffLinesWindow(a, b Stream, window int) Stream {
+  if window < 1 {
+    window = 1
+  }
+  return &diffStream{a: diffSide{s: a}, b: diffSide{s: b}, window: window}
+}
+
+type diffSide struct {
+  s Stream
+  buf []string
+  done bool
+}
+
+func (d *diffSide) fill(n int) error {
+  for !d.done && len(d.buf) < n {
+    var line string
+    err := d.s.Next(&line)
+    if err == Done {
+      d.done = true
+    } else if err != nil {
+      return err
+    } else {
+      d.buf = append(d.buf, line)
+    }
+  }
+  return nil
+}
+
+func (d *diffSide) pop() string {
+  result := d.buf[0]
+  d.buf = d.buf[1:]
+  return result
+}
+
+type diffStream struct {
+  a diffSide
+  b diffSide
+  window int
+  removes int
+  adds int
+  done bool
+}
+
+func (s *diffStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  if err := s.a.fill(s.window); err != nil {
+    return err
+  }
+  if err := s.b.fill(s.window); err != nil {
+    return err
+  }
+  p := ptr.(*DiffLine)
+  if s.removes == 0 && s.adds == 0 {
+    if len(s.a.buf) == 0 && len(s.b.buf) == 0 {
+      s.done = true
+      return Done
+    }
+    if len(s.a.buf) > 0 && len(s.b.buf) > 0 && s.a.buf[0] == s.b.buf[0] {
+      s.b.pop()
+      *p = DiffLine{Op: DiffKeep, Text: s.a.pop()}
+      return nil
+    }
+    s.removes, s.adds = s.resync()
+  }
+  if s.removes > 0 {
+    s.removes--
+    *p = DiffLine{Op: DiffRemove, Text: s.a.pop()}
+    return nil
+  }
+  s.adds--
+  *p = DiffLine{Op: DiffAdd, Text: s.b.pop()}
+  return nil
+}
+
+func (s *diffStream) Close() error {
+  result := s.a.s.Close()
+  if err := s.b.s.Close(); result == nil {
+    result = err
+  }
+  return result
+}
+
+// resync returns how many lines to remove from a and add from b before
+// a and b agree again, preferring the fewest edits. If they never agree
+// within the buffered lines, resync returns all buffered lines.
+func (s *diffStream) resync() (removes, adds int) {
+  alen, blen := len(s.a.buf), len(s.b.buf)
+  for d := 1; d < alen + blen; d++ {
+    for i := 0; i <= d; i++ {
+      j := d - i
+      if i < alen && j < blen && s.a.buf[i] == s.b.buf[j] {
+        return i, j
+      }
+    }
+  }
+  return alen, blen
+}
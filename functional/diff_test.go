@@ -0,0 +1,74 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestDiffLines(t *testing.T) {
+  a := NewStreamFromValues([]string{"a", "b", "c", "d", "e"}, nil)
+  b := NewStreamFromValues([]string{"a", "c", "d", "x", "e", "f"}, nil)
+  stream := DiffLines(a, b)
+  results, err := toDiffArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[ a -b  c  d +x  e +f]" {
+    t.Errorf("Expected [ a -b  c  d +x  e +f] got %v", output)
+  }
+  verifyDone(t, stream, new(DiffLine), err)
+}
+
+func TestDiffLinesReplace(t *testing.T) {
+  a := NewStreamFromValues([]string{"a", "b", "c"}, nil)
+  b := NewStreamFromValues([]string{"a", "x", "y", "c"}, nil)
+  results, _ := toDiffArray(DiffLines(a, b))
+  if output := fmt.Sprintf("%v", results); output != "[ a -b +x +y  c]" {
+    t.Errorf("Expected [ a -b +x +y  c] got %v", output)
+  }
+}
+
+func TestDiffLinesSmallWindow(t *testing.T) {
+  a := NewStreamFromValues([]string{"a", "b", "c", "d"}, nil)
+  b := NewStreamFromValues([]string{"d"}, nil)
+  results, _ := toDiffArray(DiffLinesWindow(a, b, 2))
+  if output := fmt.Sprintf("%v", results); output != "[-a -b +d -c -d]" {
+    t.Errorf("Expected [-a -b +d -c -d] got %v", output)
+  }
+}
+
+func TestDiffLinesEmpty(t *testing.T) {
+  results, _ := toDiffArray(DiffLines(NilStream(), NilStream()))
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+}
+
+func TestDiffLinesError(t *testing.T) {
+  a := NewStreamFromValues([]string{"a"}, nil)
+  b := Map(errMapper, Count(), new(int))
+  if _, err := toDiffArray(DiffLines(a, b)); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestDiffLinesClose(t *testing.T) {
+  a := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  b := &streamCloseChecker{NilStream(), &simpleCloseChecker{closeError: closeError}}
+  stream := DiffLines(a, b)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, a)
+  verifyCloseCalled(t, b)
+}
+
+func toDiffArray(s Stream) ([]DiffLine, error) {
+  var result []DiffLine
+  var x DiffLine
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    result = append(result, x)
+  }
+  return result, err
+}
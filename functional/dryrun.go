@@ -0,0 +1,131 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "reflect"
+  "runtime"
+  "strings"
+)
+
+// DryRun checks that the types of the stages of s line up without reading
+// any values from s. s is a Stream of T; ptr is a *T of the kind that would
+// be passed to Next. DryRun walks the Map and Filter stages that s is built
+// from along with the Slice, AutoClose, Safe, Single, Synchronized,
+// Progress, and Typed wrappers around them, stopping at the first Stream it
+// does not recognize, which is normally the source. DryRun calls each
+// Mapper and Filterer once with zero values of the types that stage would
+// see and reports the first stage, counting from 0 at the source end, that
+// fails a type assertion or a reflect type check. Other panics and the
+// errors that Mappers and Filterers return are ignored since they often
+// reject zero values legitimately. DryRun never calls Next or Close on s.
+//
+// Because DryRun really calls the Mappers and Filterers, any side effects
+// they have happen too: a Mapper that writes to a database writes a zero
+// value, and a Filterer that remembers what it has seen remembers the zero
+// value. Use DryRun only on pipelines whose stages are free of side
+// effects, or on a pipeline built just for the dry run. DryRun skips the
+// Filterers DedupeByKey returns since probing them would make the real
+// Stream drop its first value with the zero value's key.
+func DryRun(s Stream, ptr interface{}) error {
+  var stages []dryRunStage
+  t := reflect.TypeOf(ptr)
+  for {
+    switch i := s.(type) {
+      case *mapStream:
+        stages = append(stages, dryRunStage{"Map", i.mapper, reflect.TypeOf(i.ptr), t})
+        t = reflect.TypeOf(i.ptr)
+        s = i.Stream
+        continue
+      case *filterStream:
+        if _, ok := i.filterer.(*dedupeFilterer); !ok {
+          stages = append(stages, dryRunStage{"Filter", i.filterer, t, nil})
+        }
+        s = i.Stream
+        continue
+      case *typedStream:
+        if t != i.ptrType {
+          stages = append(stages, dryRunStage{"Typed", nil, t, i.ptrType})
+        }
+        s = i.Stream
+        continue
+      case *sliceStream:
+        s = i.Stream
+        continue
+      case *autoCloseStream:
+        s = i.Stream
+        continue
+      case safeStream:
+        s = i.Stream
+        continue
+      case *singleStream:
+        s = i.Stream
+        continue
+      case *synchronizedStream:
+        s = i.Stream
+        continue
+      case *progressStream:
+        s = i.Stream
+        continue
+    }
+    break
+  }
+  for i := len(stages) - 1; i >= 0; i-- {
+    if err := stages[i].probe(); err != nil {
+      return fmt.Errorf("functional: DryRun stage %d (%s): %v", len(stages) - 1 - i, stages[i].kind, err)
+    }
+  }
+  return nil
+}
+
+type dryRunStage struct {
+  kind string
+  // stage is a Mapper or a Filterer or nil for a Typed check
+  stage interface{}
+  src reflect.Type
+  dest reflect.Type
+}
+
+func (d dryRunStage) probe() (err error) {
+  defer func() {
+    if r := recover(); r != nil {
+      if isTypePanic(r) {
+        err = fmt.Errorf("%v", r)
+      }
+    }
+  }()
+  switch stage := d.stage.(type) {
+    case Mapper:
+      stage.Map(newPtr(d.src), newPtr(d.dest))
+    case Filterer:
+      stage.Filter(newPtr(d.src))
+    default:
+      return fmt.Errorf("expects a %v but got a %v", d.dest, d.src)
+  }
+  return nil
+}
+
+func newPtr(t reflect.Type) interface{} {
+  if t == nil || t.Kind() != reflect.Ptr {
+    return nil
+  }
+  return reflect.New(t.Elem()).Interface()
+}
+
+// isTypePanic reports whether r, a recovered panic value, comes from a
+// failed type assertion or a reflect type check.
+func isTypePanic(r interface{}) bool {
+  switch v := r.(type) {
+    case *runtime.TypeAssertionError:
+      return true
+    case *reflect.ValueError:
+      return true
+    case string:
+      return strings.HasPrefix(v, "reflect")
+  }
+  return false
+}
@@ -0,0 +1,63 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestDryRun(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := Safe(Slice(Map(IntToString, Filter(lessThan(5), s), new(int)), 0, 3))
+  if err := DryRun(stream, new(string)); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if s.closeCalled() {
+    t.Error("Expected DryRun not to close the source.")
+  }
+}
+
+func TestDryRunMismatch(t *testing.T) {
+  stream := Filter(lessThan(5), Map(IntToString, Count(), new(int)))
+  if err := DryRun(stream, new(string)); err == nil || err.Error()[:39] != "functional: DryRun stage 1 (Filter): in" {
+    t.Errorf("Expected Filter stage error, got %v", err)
+  }
+  stream = Map(IntToString, Count(), new(string))
+  if err := DryRun(stream, new(string)); err == nil || err.Error()[:36] != "functional: DryRun stage 0 (Map): in" {
+    t.Errorf("Expected Map stage error, got %v", err)
+  }
+  stream = Filter(lessThan(5), Typed(Count(), ""))
+  if err := DryRun(stream, new(int)); err == nil || err.Error() != "functional: DryRun stage 0 (Typed): expects a *string but got a *int" {
+    t.Errorf("Expected Typed stage error, got %v", err)
+  }
+}
+
+func TestDryRunOtherPanics(t *testing.T) {
+  deref := NewMapper(func(srcPtr, destPtr interface{}) error {
+    *destPtr.(*int) = **srcPtr.(**int)
+    return nil
+  })
+  stream := Map(deref, NilStream(), new(*int))
+  if err := DryRun(stream, new(int)); err != nil {
+    t.Errorf("Expected nil pointer panic ignored, got %v", err)
+  }
+}
+
+func TestDryRunSkipsDedupe(t *testing.T) {
+  stream := DedupeByKey(
+      func(ptr interface{}) interface{} { return *ptr.(*int) },
+      10,
+      0,
+      xrange(0, 3))
+  if err := DryRun(stream, new(int)); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  results, _ := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2], got %v", output)
+  }
+}
@@ -0,0 +1,115 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "fmt"
+)
+
+// IsDone returns true if err is Done or wraps Done in the sense of
+// errors.Is.
+func IsDone(err error) bool {
+  return err == Done || errors.Is(err, Done)
+}
+
+// IsSkipped returns true if err is Skipped or wraps Skipped in the sense of
+// errors.Is.
+func IsSkipped(err error) bool {
+  return err == Skipped || errors.Is(err, Skipped)
+}
+
+// Annotate returns an error that prefixes the message of err with msg
+// while still satisfying errors.Is and errors.As for err. Use it to add
+// context to errors that Mappers, Filterers, and Streams return. Annotate
+// returns err unchanged if err is nil, Done, or Skipped because Streams in
+// this package recognize Done and Skipped by identity.
+func Annotate(err error, msg string) error {
+  if err == nil || err == Done || err == Skipped {
+    return err
+  }
+  return &annotatedError{msg: msg, err: err}
+}
+
+// NamedMapper returns a Mapper that works like m except that it prefixes
+// the message of any error m returns with name as Annotate does. If m
+// panics, the returned Mapper panics with an error whose message is
+// prefixed with name as well. Use it to tell which stage of a long
+// pipeline failed.
+func NamedMapper(name string, m Mapper) Mapper {
+  return namedMapper{name: name, mapper: m}
+}
+
+// NamedFilterer works like NamedMapper but for Filterers.
+func NamedFilterer(name string, f Filterer) Filterer {
+  return namedFilterer{name: name, filterer: f}
+}
+
+// NamedStream returns a Stream that emits the same values as s except
+// that errors from Next and Close are prefixed with name as Annotate does.
+// Panics within s are prefixed with name as in NamedMapper. Calling
+// Close on returned Stream closes s.
+func NamedStream(name string, s Stream) Stream {
+  return namedStream{name: name, Stream: s}
+}
+
+type namedMapper struct {
+  name string
+  mapper Mapper
+}
+
+func (m namedMapper) Map(srcPtr, destPtr interface{}) error {
+  defer annotatePanic(m.name)
+  return Annotate(m.mapper.Map(srcPtr, destPtr), m.name)
+}
+
+type namedFilterer struct {
+  name string
+  filterer Filterer
+}
+
+func (f namedFilterer) Filter(ptr interface{}) error {
+  defer annotatePanic(f.name)
+  return Annotate(f.filterer.Filter(ptr), f.name)
+}
+
+type namedStream struct {
+  name string
+  Stream
+}
+
+func (s namedStream) Next(ptr interface{}) error {
+  defer annotatePanic(s.name)
+  return Annotate(s.Stream.Next(ptr), s.name)
+}
+
+func (s namedStream) Close() error {
+  defer annotatePanic(s.name)
+  return Annotate(s.Stream.Close(), s.name)
+}
+
+func annotatePanic(name string) {
+  if r := recover(); r != nil {
+    err, ok := r.(error)
+    if !ok {
+      err = fmt.Errorf("%v", r)
+    }
+    panic(&annotatedError{msg: name, err: err})
+  }
+}
+
+type annotatedError struct {
+  msg string
+  err error
+}
+
+func (e *annotatedError) Error() string {
+  return e.msg + ": " + e.err.Error()
+}
+
+func (e *annotatedError) Unwrap() error {
+  return e.err
+}
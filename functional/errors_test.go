@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func TestIsDoneIsSkipped(t *testing.T) {
+  if !IsDone(Done) || !IsDone(fmt.Errorf("wrapped: %w", Done)) {
+    t.Error("Expected IsDone to be true.")
+  }
+  if IsDone(Skipped) || IsDone(nil) {
+    t.Error("Expected IsDone to be false.")
+  }
+  if !IsSkipped(Skipped) || !IsSkipped(fmt.Errorf("wrapped: %w", Skipped)) {
+    t.Error("Expected IsSkipped to be true.")
+  }
+  if IsSkipped(Done) || IsSkipped(nil) {
+    t.Error("Expected IsSkipped to be false.")
+  }
+}
+
+func TestAnnotate(t *testing.T) {
+  err := Annotate(mapError, "parse-amount")
+  if output := err.Error(); output != "parse-amount: map error." {
+    t.Errorf("Expected 'parse-amount: map error.' got %v", output)
+  }
+  if !errors.Is(err, mapError) {
+    t.Error("Expected annotated error to wrap mapError.")
+  }
+  if Annotate(Done, "x") != Done || Annotate(Skipped, "x") != Skipped || Annotate(nil, "x") != nil {
+    t.Error("Expected Done, Skipped, and nil to pass through unchanged.")
+  }
+}
+
+func TestNamed(t *testing.T) {
+  s := NamedStream("source", Slice(Count(), 0, 2))
+  s = Filter(NamedFilterer("small", errFilterer), s)
+  var x int
+  if output := s.Next(&x).Error(); output != "small: filter error." {
+    t.Errorf("Expected 'small: filter error.' got %v", output)
+  }
+  s = NamedStream("source", &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}})
+  if err := s.Close(); !errors.Is(err, closeError) || err.Error() != "source: error closing." {
+    t.Errorf("Expected annotated closeError, got %v", err)
+  }
+  m := NamedMapper("parse-amount", IntToString)
+  if err := m.Map(ptrInt(3), new(string)); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  err := Safe(Map(m, Count(), new(int))).Next(new(int))
+  if output := err.Error(); output != "functional: recovered panic: parse-amount: interface conversion: interface {} is *int, not *string" {
+    t.Errorf("Expected recovered panic naming stage, got %v", output)
+  }
+}
@@ -0,0 +1,205 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package extsort
+
+import (
+  "crypto/cipher"
+  "encoding/gob"
+  "github.com/keep94/gofunctional2/functional"
+  "hash/fnv"
+  "io/ioutil"
+  "os"
+)
+
+// Deduper is an ErrorReportingConsumer of T that removes duplicate T values
+// from arbitrarily large Streams. It hashes the key of each T value it
+// consumes into one of several partition files so that only the keys of
+// one partition need to be held in memory at a time. T values must be
+// encodable with encoding/gob. A Deduper cannot be used by multiple
+// goroutines simultaneously.
+type Deduper struct {
+  // Dir is the directory for partition files. Empty means the default
+  // directory for temporary files.
+  Dir string
+  // AEAD, if not nil, encrypts partition files so that the values never
+  // reach disk in plaintext. Reading a partition file that fails
+  // authentication reports ErrSpillAuth.
+  AEAD cipher.AEAD
+  creater functional.Creater
+  key func(ptr interface{}) string
+  partitions int
+  paths []string
+  err error
+}
+
+// NewDeduper returns a new Deduper. creater is a Creater of T; key returns
+// the key of the T value ptr points to. Two T values are duplicates if
+// their keys are equal. partitions is the number of partition files and
+// must be greater than 0. The memory used is roughly the number of
+// distinct keys divided by partitions.
+func NewDeduper(
+    creater functional.Creater,
+    key func(ptr interface{}) string,
+    partitions int) *Deduper {
+  if partitions <= 0 {
+    panic("partitions must be greater than 0.")
+  }
+  return &Deduper{creater: creater, key: key, partitions: partitions}
+}
+
+// Consume partitions the values of s, a Stream of T, into files.
+// Consume closes s.
+func (d *Deduper) Consume(s functional.Stream) {
+  defer s.Close()
+  d.paths = nil
+  d.err = nil
+  files := make([]*os.File, d.partitions)
+  writers := make([]*spillWriter, d.partitions)
+  encoders := make([]*gob.Encoder, d.partitions)
+  for i := range files {
+    f, err := ioutil.TempFile(d.Dir, "extdistinct")
+    if err != nil {
+      d.fail(files, err)
+      return
+    }
+    files[i] = f
+    d.paths = append(d.paths, f.Name())
+    writers[i] = newSpillWriter(f, d.AEAD)
+    encoders[i] = gob.NewEncoder(writers[i])
+  }
+  ptr := d.creater()
+  err := s.Next(ptr)
+  for ; err == nil; err = s.Next(ptr) {
+    if err = encoders[d.partition(ptr)].Encode(ptr); err != nil {
+      break
+    }
+  }
+  if err != functional.Done {
+    d.fail(files, err)
+    return
+  }
+  for i := range files {
+    if err = writers[i].Flush(); err != nil {
+      d.fail(files, err)
+      return
+    }
+  }
+  if err = closeAll(files); err != nil {
+    d.fail(nil, err)
+  }
+}
+
+// Error returns any error from the last call to Consume.
+func (d *Deduper) Error() error {
+  return d.err
+}
+
+// Distinct returns a Stream of T that emits each distinct value from the
+// last call to Consume once. When values are duplicates, the one consumed
+// first is emitted. Values are emitted one partition at a time, so their
+// original order is not preserved. Distinct should be called at most once
+// after each call to Consume. The returned Stream owns the partition files
+// and removes them when it reaches the end or is closed.
+func (d *Deduper) Distinct() (functional.Stream, error) {
+  if d.err != nil {
+    return nil, d.err
+  }
+  paths := d.paths
+  d.paths = nil
+  return &distinctStream{
+      paths: paths, creater: d.creater, key: d.key, aead: d.AEAD}, nil
+}
+
+func (d *Deduper) partition(ptr interface{}) int {
+  h := fnv.New32a()
+  h.Write([]byte(d.key(ptr)))
+  return int(h.Sum32() % uint32(d.partitions))
+}
+
+func (d *Deduper) fail(files []*os.File, err error) {
+  d.err = err
+  closeAll(files)
+  removeAll(d.paths)
+  d.paths = nil
+}
+
+type distinctStream struct {
+  paths []string
+  creater functional.Creater
+  key func(ptr interface{}) string
+  aead cipher.AEAD
+  idx int
+  current *runReader
+  seen map[string]bool
+  closed bool
+  closeErr error
+}
+
+func (s *distinctStream) Next(ptr interface{}) error {
+  if s.closed {
+    return functional.Done
+  }
+  for {
+    if s.current == nil {
+      if s.idx == len(s.paths) {
+        if err := s.Close(); err != nil {
+          return err
+        }
+        return functional.Done
+      }
+      r, err := openRun(s.paths[s.idx], s.creater, s.aead)
+      if err != nil {
+        return err
+      }
+      s.current = r
+      s.seen = make(map[string]bool)
+    }
+    err := s.current.advance()
+    if err == functional.Done {
+      s.current.f.Close()
+      s.current = nil
+      s.idx++
+      continue
+    }
+    if err != nil {
+      return err
+    }
+    k := s.key(s.current.ptr)
+    if !s.seen[k] {
+      s.seen[k] = true
+      assign(s.current.ptr, ptr)
+      return nil
+    }
+  }
+}
+
+func (s *distinctStream) Close() error {
+  if s.closed {
+    return s.closeErr
+  }
+  s.closed = true
+  if s.current != nil {
+    s.closeErr = s.current.f.Close()
+    s.current = nil
+  }
+  if err := removeAll(s.paths); s.closeErr == nil {
+    s.closeErr = err
+  }
+  return s.closeErr
+}
+
+func closeAll(files []*os.File) error {
+  var result error
+  for _, f := range files {
+    if f == nil {
+      continue
+    }
+    if err := f.Close(); result == nil {
+      result = err
+    }
+  }
+  return result
+}
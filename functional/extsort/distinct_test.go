@@ -0,0 +1,82 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package extsort
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "os"
+  "sort"
+  "testing"
+)
+
+func TestDeduper(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  d := NewDeduper(newInt, intKey, 4)
+  d.Dir = dir
+  d.Consume(functional.NewStreamFromValues(
+      []int{5, 3, 5, 8, 1, 3, 3, 9, 8, 5}, nil))
+  if err := d.Error(); err != nil {
+    t.Fatalf("Got error consuming: %v", err)
+  }
+  s, err := d.Distinct()
+  if err != nil {
+    t.Fatalf("Got error opening distinct stream: %v", err)
+  }
+  results, err := toIntArray(s)
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  sort.Ints(results)
+  if output := fmt.Sprintf("%v", results); output != "[1 3 5 8 9]" {
+    t.Errorf("Expected [1 3 5 8 9], got %v", output)
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected partition files removed, got %v", output)
+  }
+}
+
+func TestDeduperCloseEarly(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  d := NewDeduper(newInt, intKey, 3)
+  d.Dir = dir
+  d.Consume(functional.Slice(functional.Count(), 0, 20))
+  s, _ := d.Distinct()
+  s.Next(new(int))
+  if output := s.Close(); output != nil {
+    t.Errorf("Expected nil on close, got %v", output)
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected partition files removed, got %v", output)
+  }
+}
+
+func TestDeduperEncrypted(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  d := NewDeduper(newInt, intKey, 2)
+  d.Dir = dir
+  d.AEAD = newAEAD(t, 1)
+  d.Consume(functional.NewStreamFromValues([]int{7, 2, 7, 4, 2}, nil))
+  if err := d.Error(); err != nil {
+    t.Fatalf("Got error consuming: %v", err)
+  }
+  s, _ := d.Distinct()
+  results, err := toIntArray(s)
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  sort.Ints(results)
+  if output := fmt.Sprintf("%v", results); output != "[2 4 7]" {
+    t.Errorf("Expected [2 4 7], got %v", output)
+  }
+}
+
+func intKey(ptr interface{}) string {
+  return fmt.Sprintf("%d", *ptr.(*int))
+}
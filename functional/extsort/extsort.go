@@ -0,0 +1,416 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// Package extsort sorts Streams too large to fit in memory by spilling
+// sorted runs to temporary files and merging them.
+package extsort
+
+import (
+  "container/heap"
+  "crypto/cipher"
+  "encoding/gob"
+  "github.com/keep94/gofunctional2/functional"
+  "io"
+  "io/ioutil"
+  "os"
+  "reflect"
+  "sort"
+)
+
+// Sorter is an ErrorReportingConsumer of T that sorts the T values it
+// consumes using a bounded amount of memory. T values must be encodable
+// with encoding/gob, so only exported struct fields survive sorting.
+// A Sorter cannot be used by multiple goroutines simultaneously.
+type Sorter struct {
+  // Dir is the directory for spill files. Empty means the default
+  // directory for temporary files.
+  Dir string
+  // AEAD, if not nil, encrypts spill files so that the values never
+  // reach disk in plaintext. Reading a spill file that fails
+  // authentication reports ErrSpillAuth. AEAD must not be changed
+  // between Consume and reading the Stream Sorted returns.
+  AEAD cipher.AEAD
+  // MaxFanIn is the most spill files opened at once while merging. If
+  // there are more runs, Sorted first merges them in groups of MaxFanIn
+  // into longer runs. Values less than 2 mean 64.
+  MaxFanIn int
+  creater functional.Creater
+  less func(aPtr, bPtr interface{}) bool
+  runSize int
+  mem []interface{}
+  runs []string
+  err error
+}
+
+// NewSorter returns a new Sorter. creater is a Creater of T; less reports
+// whether the T value aPtr points to sorts before the one bPtr points to;
+// runSize is the maximum number of T values held in memory at once and
+// must be greater than 0.
+func NewSorter(
+    creater functional.Creater,
+    less func(aPtr, bPtr interface{}) bool,
+    runSize int) *Sorter {
+  if runSize <= 0 {
+    panic("runSize must be greater than 0.")
+  }
+  return &Sorter{creater: creater, less: less, runSize: runSize}
+}
+
+// Consume reads all the values of s, a Stream of T, writing a sorted run
+// to a spill file each time runSize values have been read. If s has no
+// more than runSize values, no spill files are written. Consume first
+// removes any spill files left from an earlier call to Consume that were
+// never passed on to Sorted. Consume closes s.
+func (so *Sorter) Consume(s functional.Stream) {
+  defer s.Close()
+  so.Discard()
+  so.err = nil
+  var next interface{}
+  for {
+    run, err := so.readRun(s, next)
+    if err == nil {
+      // Peek at the next value so that a Stream of exactly runSize
+      // values stays in memory.
+      next = so.creater()
+      err = s.Next(next)
+    }
+    if err != nil && err != functional.Done {
+      so.fail(err)
+      return
+    }
+    so.sortRun(run)
+    if err == functional.Done && len(so.runs) == 0 {
+      so.mem = run
+      return
+    }
+    if len(run) > 0 {
+      if serr := so.spill(run); serr != nil {
+        so.fail(serr)
+        return
+      }
+    }
+    if err == functional.Done {
+      return
+    }
+  }
+}
+
+// Error returns any error from the last call to Consume.
+func (so *Sorter) Error() error {
+  return so.err
+}
+
+// Sorted returns a Stream of T that emits the values from the last call to
+// Consume in sorted order. Values that compare equal are emitted in no
+// particular order. Sorted should be called at most once after each call
+// to Consume. The returned Stream owns any spill files and removes them
+// when it reaches the end or is closed. If Sorted will not be called after
+// Consume, call Discard to remove the spill files.
+func (so *Sorter) Sorted() (functional.Stream, error) {
+  if so.err != nil {
+    return nil, so.err
+  }
+  if len(so.runs) == 0 {
+    result := &memStream{values: so.mem}
+    so.mem = nil
+    return result, nil
+  }
+  runs := so.runs
+  so.runs = nil
+  fanIn := so.MaxFanIn
+  if fanIn < 2 {
+    fanIn = 64
+  }
+  for len(runs) > fanIn {
+    var merged []string
+    for len(runs) > 0 {
+      n := fanIn
+      if n > len(runs) {
+        n = len(runs)
+      }
+      if n == 1 {
+        merged = append(merged, runs[0])
+        runs = nil
+        break
+      }
+      path, err := so.mergeToFile(runs[:n])
+      if err != nil {
+        removeAll(runs[n:])
+        removeAll(merged)
+        return nil, err
+      }
+      merged = append(merged, path)
+      runs = runs[n:]
+    }
+    runs = merged
+  }
+  return so.merge(runs)
+}
+
+// Discard removes any spill files from the last call to Consume. Sorted
+// removes them when the Stream it returns is done, so Discard is needed
+// only when Sorted will not be called.
+func (so *Sorter) Discard() error {
+  so.mem = nil
+  err := removeAll(so.runs)
+  so.runs = nil
+  return err
+}
+
+// merge returns a Stream of T that merges the runs in paths. The returned
+// Stream removes the files in paths when it is closed.
+func (so *Sorter) merge(paths []string) (*mergeStream, error) {
+  result := &mergeStream{paths: paths}
+  for _, path := range paths {
+    r, err := openRun(path, so.creater, so.AEAD)
+    if err != nil {
+      result.Close()
+      return nil, err
+    }
+    result.readers = append(result.readers, r)
+  }
+  result.heap.less = so.less
+  for _, r := range result.readers {
+    if err := r.advance(); err != nil {
+      if err == functional.Done {
+        continue
+      }
+      result.Close()
+      return nil, err
+    }
+    result.heap.readers = append(result.heap.readers, r)
+  }
+  heap.Init(&result.heap)
+  return result, nil
+}
+
+// mergeToFile merges the runs in paths into a new spill file returning its
+// path. mergeToFile removes the files in paths.
+func (so *Sorter) mergeToFile(paths []string) (string, error) {
+  m, err := so.merge(paths)
+  if err != nil {
+    return "", err
+  }
+  path, err := so.writeRun(m)
+  if cerr := m.Close(); err == nil && cerr != nil {
+    os.Remove(path)
+    err = cerr
+  }
+  if err != nil {
+    return "", err
+  }
+  return path, nil
+}
+
+// readRun reads a run of up to runSize values from s beginning with
+// first if first is not nil.
+func (so *Sorter) readRun(
+    s functional.Stream, first interface{}) ([]interface{}, error) {
+  var result []interface{}
+  if first != nil {
+    result = append(result, first)
+  }
+  for len(result) < so.runSize {
+    ptr := so.creater()
+    if err := s.Next(ptr); err != nil {
+      return result, err
+    }
+    result = append(result, ptr)
+  }
+  return result, nil
+}
+
+func (so *Sorter) sortRun(run []interface{}) {
+  sort.Slice(run, func(i, j int) bool {
+    return so.less(run[i], run[j])
+  })
+}
+
+func (so *Sorter) spill(run []interface{}) error {
+  path, err := so.writeRun(&memStream{values: run})
+  if err != nil {
+    return err
+  }
+  so.runs = append(so.runs, path)
+  return nil
+}
+
+// writeRun writes the values of s, a Stream of T, to a new spill file
+// returning its path. On error, writeRun removes the spill file.
+func (so *Sorter) writeRun(s functional.Stream) (path string, err error) {
+  f, err := ioutil.TempFile(so.Dir, "extsort")
+  if err != nil {
+    return "", err
+  }
+  defer func() {
+    if cerr := f.Close(); err == nil {
+      err = cerr
+    }
+    if err != nil {
+      os.Remove(f.Name())
+      path = ""
+    }
+  }()
+  w := newSpillWriter(f, so.AEAD)
+  enc := gob.NewEncoder(w)
+  ptr := so.creater()
+  for err = s.Next(ptr); err == nil; err = s.Next(ptr) {
+    if err = enc.Encode(ptr); err != nil {
+      return
+    }
+  }
+  if err != functional.Done {
+    return
+  }
+  return f.Name(), w.Flush()
+}
+
+func (so *Sorter) fail(err error) {
+  so.err = err
+  so.Discard()
+}
+
+type memStream struct {
+  values []interface{}
+}
+
+func (s *memStream) Next(ptr interface{}) error {
+  if len(s.values) == 0 {
+    return functional.Done
+  }
+  assign(s.values[0], ptr)
+  s.values = s.values[1:]
+  return nil
+}
+
+func (s *memStream) Close() error {
+  s.values = nil
+  return nil
+}
+
+type runReader struct {
+  f *os.File
+  dec *gob.Decoder
+  creater functional.Creater
+  ptr interface{}
+}
+
+func openRun(
+    path string,
+    creater functional.Creater,
+    aead cipher.AEAD) (*runReader, error) {
+  f, err := os.Open(path)
+  if err != nil {
+    return nil, err
+  }
+  return &runReader{
+      f: f,
+      dec: gob.NewDecoder(newSpillReader(f, aead)),
+      creater: creater}, nil
+}
+
+// advance decodes the next value of the run into a fresh T value returning
+// Done at the end of the run.
+func (r *runReader) advance() error {
+  r.ptr = r.creater()
+  err := r.dec.Decode(r.ptr)
+  if err == nil {
+    return nil
+  }
+  if err == io.EOF {
+    return functional.Done
+  }
+  return err
+}
+
+type runHeap struct {
+  readers []*runReader
+  less func(aPtr, bPtr interface{}) bool
+}
+
+func (h *runHeap) Len() int {
+  return len(h.readers)
+}
+
+func (h *runHeap) Less(i, j int) bool {
+  return h.less(h.readers[i].ptr, h.readers[j].ptr)
+}
+
+func (h *runHeap) Swap(i, j int) {
+  h.readers[i], h.readers[j] = h.readers[j], h.readers[i]
+}
+
+func (h *runHeap) Push(x interface{}) {
+  h.readers = append(h.readers, x.(*runReader))
+}
+
+func (h *runHeap) Pop() interface{} {
+  l := len(h.readers)
+  result := h.readers[l - 1]
+  h.readers = h.readers[:l - 1]
+  return result
+}
+
+type mergeStream struct {
+  paths []string
+  readers []*runReader
+  heap runHeap
+  closed bool
+  closeErr error
+}
+
+func (s *mergeStream) Next(ptr interface{}) error {
+  if s.closed {
+    return functional.Done
+  }
+  if s.heap.Len() == 0 {
+    if err := s.Close(); err != nil {
+      return err
+    }
+    return functional.Done
+  }
+  r := s.heap.readers[0]
+  assign(r.ptr, ptr)
+  err := r.advance()
+  if err == functional.Done {
+    heap.Pop(&s.heap)
+    return nil
+  }
+  if err != nil {
+    return err
+  }
+  heap.Fix(&s.heap, 0)
+  return nil
+}
+
+func (s *mergeStream) Close() error {
+  if s.closed {
+    return s.closeErr
+  }
+  s.closed = true
+  for _, r := range s.readers {
+    if err := r.f.Close(); s.closeErr == nil {
+      s.closeErr = err
+    }
+  }
+  if err := removeAll(s.paths); s.closeErr == nil {
+    s.closeErr = err
+  }
+  return s.closeErr
+}
+
+func assign(src, dest interface{}) {
+  reflect.ValueOf(dest).Elem().Set(reflect.ValueOf(src).Elem())
+}
+
+func removeAll(paths []string) error {
+  var result error
+  for _, path := range paths {
+    if err := os.Remove(path); result == nil {
+      result = err
+    }
+  }
+  return result
+}
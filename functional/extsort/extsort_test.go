@@ -0,0 +1,287 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package extsort
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io/ioutil"
+  "math/rand"
+  "os"
+  "path/filepath"
+  "strings"
+  "testing"
+)
+
+var otherError = errors.New("extsort: other error.")
+
+func TestSorter(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  values := rand.New(rand.NewSource(3)).Perm(100)
+  so := NewSorter(newInt, intLess, 7)
+  so.Dir = dir
+  so.Consume(functional.NewStreamFromValues(values, nil))
+  if err := so.Error(); err != nil {
+    t.Fatalf("Got error consuming: %v", err)
+  }
+  if output := countFiles(t, dir); output != 15 {
+    t.Errorf("Expected 15 spill files, got %v", output)
+  }
+  s, err := so.Sorted()
+  if err != nil {
+    t.Fatalf("Got error opening sorted stream: %v", err)
+  }
+  results, err := toIntArray(s)
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if len(results) != 100 {
+    t.Fatalf("Expected 100 results, got %v", len(results))
+  }
+  for i := range results {
+    if results[i] != i {
+      t.Fatalf("Expected %v at %v, got %v", i, i, results[i])
+    }
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected spill files removed, got %v", output)
+  }
+  if output := s.Close(); output != nil {
+    t.Errorf("Expected nil on close, got %v", output)
+  }
+}
+
+func TestSorterFanIn(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  values := rand.New(rand.NewSource(3)).Perm(100)
+  so := NewSorter(newInt, intLess, 7)
+  so.Dir = dir
+  so.MaxFanIn = 3
+  so.Consume(functional.NewStreamFromValues(values, nil))
+  s, err := so.Sorted()
+  if err != nil {
+    t.Fatalf("Got error opening sorted stream: %v", err)
+  }
+  // 15 runs merge into 5 and then into 2.
+  if output := countFiles(t, dir); output != 2 {
+    t.Errorf("Expected 2 spill files, got %v", output)
+  }
+  results, err := toIntArray(s)
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if len(results) != 100 {
+    t.Fatalf("Expected 100 results, got %v", len(results))
+  }
+  for i := range results {
+    if results[i] != i {
+      t.Fatalf("Expected %v at %v, got %v", i, i, results[i])
+    }
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected spill files removed, got %v", output)
+  }
+}
+
+func TestSorterDiscard(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  so := NewSorter(newInt, intLess, 7)
+  so.Dir = dir
+  so.Consume(functional.NewStreamFromValues(make([]int, 20), nil))
+  so.Consume(functional.NewStreamFromValues(make([]int, 20), nil))
+  if output := countFiles(t, dir); output != 3 {
+    t.Errorf("Expected 3 spill files, got %v", output)
+  }
+  if err := so.Discard(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected spill files removed, got %v", output)
+  }
+}
+
+func TestSorterInMemory(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  so := NewSorter(newInt, intLess, 10)
+  so.Dir = dir
+  so.Consume(functional.NewStreamFromValues([]int{5, 3, 8, 1}, nil))
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected no spill files, got %v", output)
+  }
+  s, _ := so.Sorted()
+  results, _ := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 3 5 8]" {
+    t.Errorf("Expected [1 3 5 8], got %v", output)
+  }
+}
+
+func TestSorterExactlyRunSize(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  so := NewSorter(newInt, intLess, 4)
+  so.Dir = dir
+  so.Consume(functional.NewStreamFromValues([]int{5, 3, 8, 1}, nil))
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected no spill files, got %v", output)
+  }
+  s, _ := so.Sorted()
+  results, _ := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 3 5 8]" {
+    t.Errorf("Expected [1 3 5 8], got %v", output)
+  }
+  so.Consume(functional.NewStreamFromValues([]int{5, 3, 8, 1, 7, 2, 6, 4}, nil))
+  if output := countFiles(t, dir); output != 2 {
+    t.Errorf("Expected 2 spill files, got %v", output)
+  }
+  s, _ = so.Sorted()
+  results, _ = toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3 4 5 6 7 8]" {
+    t.Errorf("Expected [1 2 3 4 5 6 7 8], got %v", output)
+  }
+}
+
+func TestSorterCloseEarly(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  so := NewSorter(newInt, intLess, 2)
+  so.Dir = dir
+  so.Consume(functional.NewStreamFromValues([]int{5, 3, 8, 1, 4}, nil))
+  s, _ := so.Sorted()
+  var x int
+  s.Next(&x)
+  if x != 1 {
+    t.Errorf("Expected 1, got %v", x)
+  }
+  s.Close()
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected spill files removed, got %v", output)
+  }
+}
+
+func TestSorterError(t *testing.T) {
+  so := NewSorter(newInt, intLess, 2)
+  so.Consume(functional.Concat(
+      functional.NewStreamFromValues([]int{5, 3, 8}, nil),
+      functional.Filter(functional.NewFilterer(func(ptr interface{}) error { return otherError }), functional.Count())))
+  if err := so.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+  if _, err := so.Sorted(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
+
+func TestSorterEncrypted(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  values := make([]string, 20)
+  for i := range values {
+    values[i] = fmt.Sprintf("secret%02d", 19 - i)
+  }
+  so := NewSorter(newString, stringLess, 3)
+  so.Dir = dir
+  so.AEAD = newAEAD(t, 1)
+  so.Consume(functional.NewStreamFromValues(values, nil))
+  if err := so.Error(); err != nil {
+    t.Fatalf("Got error consuming: %v", err)
+  }
+  if filesContain(t, dir, "secret") {
+    t.Error("Expected no plaintext in spill files")
+  }
+  s, _ := so.Sorted()
+  var x string
+  var results []string
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    results = append(results, x)
+  }
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if len(results) != 20 || results[0] != "secret00" || results[19] != "secret19" {
+    t.Errorf("Expected sorted values, got %v", results)
+  }
+}
+
+func TestSorterEncryptedWrongKey(t *testing.T) {
+  dir := tempDir(t)
+  defer os.RemoveAll(dir)
+  so := NewSorter(newInt, intLess, 2)
+  so.Dir = dir
+  so.AEAD = newAEAD(t, 1)
+  so.Consume(functional.NewStreamFromValues([]int{5, 3, 8, 1, 4}, nil))
+  so.AEAD = newAEAD(t, 2)
+  if _, err := so.Sorted(); err != ErrSpillAuth {
+    t.Errorf("Expected ErrSpillAuth, got %v", err)
+  }
+  if output := countFiles(t, dir); output != 0 {
+    t.Errorf("Expected spill files removed, got %v", output)
+  }
+}
+
+func newInt() interface{} {
+  return new(int)
+}
+
+func intLess(aPtr, bPtr interface{}) bool {
+  return *aPtr.(*int) < *bPtr.(*int)
+}
+
+func newString() interface{} {
+  return new(string)
+}
+
+func stringLess(aPtr, bPtr interface{}) bool {
+  return *aPtr.(*string) < *bPtr.(*string)
+}
+
+func tempDir(t *testing.T) string {
+  dir, err := ioutil.TempDir("", "extsorttest")
+  if err != nil {
+    t.Fatal(err)
+  }
+  return dir
+}
+
+func countFiles(t *testing.T, dir string) int {
+  infos, err := ioutil.ReadDir(dir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  return len(infos)
+}
+
+func filesContain(t *testing.T, dir, text string) bool {
+  infos, err := ioutil.ReadDir(dir)
+  if err != nil {
+    t.Fatal(err)
+  }
+  for _, info := range infos {
+    contents, err := ioutil.ReadFile(filepath.Join(dir, info.Name()))
+    if err != nil {
+      t.Fatal(err)
+    }
+    if strings.Contains(string(contents), text) {
+      return true
+    }
+  }
+  return false
+}
+
+func toIntArray(s functional.Stream) ([]int, error) {
+  var result []int
+  var x int
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    result = append(result, x)
+  }
+  return result, err
+}
@@ -0,0 +1,185 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package extsort
+
+import (
+  "bufio"
+  "crypto/cipher"
+  "crypto/rand"
+  "encoding/binary"
+  "errors"
+  "io"
+)
+
+// ErrSpillAuth is returned when a spill file encrypted with an AEAD fails
+// authentication because it was modified, truncated, or written with a
+// different key.
+var ErrSpillAuth = errors.New("extsort: spill file failed authentication.")
+
+// sealFrameSize is the most plaintext bytes sealed into one frame.
+const sealFrameSize = 64 * 1024
+
+// sealHeaderSize is the size of the frame length plus the last frame flag.
+const sealHeaderSize = 5
+
+// spillWriter buffers writes to a spill file encrypting them first if
+// an AEAD is in use.
+type spillWriter struct {
+  *bufio.Writer
+  sealer *sealingWriter
+}
+
+func newSpillWriter(w io.Writer, aead cipher.AEAD) *spillWriter {
+  if aead == nil {
+    return &spillWriter{Writer: bufio.NewWriter(w)}
+  }
+  sealer := &sealingWriter{w: w, aead: aead}
+  return &spillWriter{Writer: bufio.NewWriter(sealer), sealer: sealer}
+}
+
+// Flush writes any buffered data. Flush must be called exactly once
+// after the last write since it also marks the end of encrypted data.
+func (w *spillWriter) Flush() error {
+  if err := w.Writer.Flush(); err != nil {
+    return err
+  }
+  if w.sealer != nil {
+    return w.sealer.finish()
+  }
+  return nil
+}
+
+// newSpillReader returns a buffered reader of a spill file decrypting it
+// first if aead is not nil.
+func newSpillReader(r io.Reader, aead cipher.AEAD) *bufio.Reader {
+  result := bufio.NewReader(r)
+  if aead == nil {
+    return result
+  }
+  return bufio.NewReader(&openingReader{r: result, aead: aead})
+}
+
+// sealingWriter encrypts what is written to it as a series of frames.
+// Each frame is a 4 byte length, a flag byte that is 1 only for the last
+// frame, a random nonce, and the sealed data. The frame number and flag
+// are authenticated along with the data so that frames cannot be
+// reordered, dropped, or have the last frame cut off without detection.
+type sealingWriter struct {
+  w io.Writer
+  aead cipher.AEAD
+  frame uint64
+}
+
+func (s *sealingWriter) Write(p []byte) (int, error) {
+  written := 0
+  for len(p) > 0 {
+    chunk := p
+    if len(chunk) > sealFrameSize {
+      chunk = chunk[:sealFrameSize]
+    }
+    if err := s.seal(chunk, false); err != nil {
+      return written, err
+    }
+    written += len(chunk)
+    p = p[len(chunk):]
+  }
+  return written, nil
+}
+
+// finish writes the empty last frame.
+func (s *sealingWriter) finish() error {
+  return s.seal(nil, true)
+}
+
+func (s *sealingWriter) seal(p []byte, last bool) error {
+  nonceSize := s.aead.NonceSize()
+  buf := make(
+      []byte,
+      sealHeaderSize + nonceSize,
+      sealHeaderSize + nonceSize + len(p) + s.aead.Overhead())
+  nonce := buf[sealHeaderSize:]
+  if _, err := rand.Read(nonce); err != nil {
+    return err
+  }
+  buf = s.aead.Seal(buf, nonce, p, frameData(s.frame, last))
+  binary.BigEndian.PutUint32(buf, uint32(len(buf) - sealHeaderSize))
+  if last {
+    buf[4] = 1
+  }
+  s.frame++
+  _, err := s.w.Write(buf)
+  return err
+}
+
+// openingReader decrypts what a sealingWriter wrote.
+type openingReader struct {
+  r io.Reader
+  aead cipher.AEAD
+  frame uint64
+  plain []byte
+  done bool
+}
+
+func (o *openingReader) Read(p []byte) (int, error) {
+  for len(o.plain) == 0 {
+    if o.done {
+      return 0, io.EOF
+    }
+    if err := o.open(); err != nil {
+      return 0, err
+    }
+  }
+  n := copy(p, o.plain)
+  o.plain = o.plain[n:]
+  return n, nil
+}
+
+func (o *openingReader) open() error {
+  var header [sealHeaderSize]byte
+  if _, err := io.ReadFull(o.r, header[:]); err != nil {
+    return truncated(err)
+  }
+  nonceSize := o.aead.NonceSize()
+  size := int(binary.BigEndian.Uint32(header[:]))
+  if size < nonceSize + o.aead.Overhead() ||
+      size > nonceSize + sealFrameSize + o.aead.Overhead() ||
+      header[4] > 1 {
+    return ErrSpillAuth
+  }
+  last := header[4] == 1
+  buf := make([]byte, size)
+  if _, err := io.ReadFull(o.r, buf); err != nil {
+    return truncated(err)
+  }
+  plain, err := o.aead.Open(
+      buf[nonceSize:nonceSize], buf[:nonceSize], buf[nonceSize:],
+      frameData(o.frame, last))
+  if err != nil {
+    return ErrSpillAuth
+  }
+  o.frame++
+  o.plain = plain
+  o.done = last
+  return nil
+}
+
+// frameData returns the additional data authenticated with a frame.
+func frameData(frame uint64, last bool) []byte {
+  var result [9]byte
+  binary.BigEndian.PutUint64(result[:], frame)
+  if last {
+    result[8] = 1
+  }
+  return result[:]
+}
+
+// truncated turns an EOF before the last frame into ErrSpillAuth.
+func truncated(err error) error {
+  if err == io.EOF || err == io.ErrUnexpectedEOF {
+    return ErrSpillAuth
+  }
+  return err
+}
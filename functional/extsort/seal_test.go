@@ -0,0 +1,71 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package extsort
+
+import (
+  "bytes"
+  "crypto/aes"
+  "crypto/cipher"
+  "io/ioutil"
+  "strings"
+  "testing"
+)
+
+func TestSealRoundTrip(t *testing.T) {
+  aead := newAEAD(t, 1)
+  plain := strings.Repeat("secret ", 20000)
+  var buf bytes.Buffer
+  w := newSpillWriter(&buf, aead)
+  w.WriteString(plain)
+  if err := w.Flush(); err != nil {
+    t.Fatalf("Got error flushing: %v", err)
+  }
+  if bytes.Contains(buf.Bytes(), []byte("secret")) {
+    t.Error("Expected no plaintext in sealed data")
+  }
+  output, err := ioutil.ReadAll(newSpillReader(bytes.NewReader(buf.Bytes()), aead))
+  if err != nil {
+    t.Fatalf("Got error reading: %v", err)
+  }
+  if string(output) != plain {
+    t.Error("Expected plaintext to round trip")
+  }
+}
+
+func TestSealDetectsTampering(t *testing.T) {
+  aead := newAEAD(t, 1)
+  var buf bytes.Buffer
+  w := newSpillWriter(&buf, aead)
+  w.WriteString("hello world")
+  w.Flush()
+  sealed := buf.Bytes()
+  tampered := append([]byte(nil), sealed...)
+  tampered[sealHeaderSize + aead.NonceSize()] ^= 1
+  verifySealError(t, tampered, aead)
+  verifySealError(t, sealed[:len(sealed) - 1], aead)
+  firstFrame := sealHeaderSize + aead.NonceSize() + len("hello world") + aead.Overhead()
+  verifySealError(t, sealed[:firstFrame], aead)
+  verifySealError(t, sealed, newAEAD(t, 2))
+}
+
+func verifySealError(t *testing.T, sealed []byte, aead cipher.AEAD) {
+  _, err := ioutil.ReadAll(newSpillReader(bytes.NewReader(sealed), aead))
+  if err != ErrSpillAuth {
+    t.Errorf("Expected ErrSpillAuth, got %v", err)
+  }
+}
+
+func newAEAD(t *testing.T, keyByte byte) cipher.AEAD {
+  block, err := aes.NewCipher(bytes.Repeat([]byte{keyByte}, 32))
+  if err != nil {
+    t.Fatal(err)
+  }
+  aead, err := cipher.NewGCM(block)
+  if err != nil {
+    t.Fatal(err)
+  }
+  return aead
+}
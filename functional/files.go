@@ -0,0 +1,230 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+  "os"
+  "path/filepath"
+  "sync"
+)
+
+// FileLine is a line of text read from a file.
+type FileLine struct {
+  // Path is the path of the file.
+  Path string
+  // Number is the 1-based line number within the file.
+  Number int
+  // Text is the line without its line ending.
+  Text string
+}
+
+// ReadFilesLines returns the lines of the files in paths as a Stream of
+// FileLine. Up to parallelism files are read at once, so lines of
+// different files are interleaved, but the lines of each file appear in
+// order. parallelism less than 1 means 1. If a file cannot be opened or
+// read, Next returns an error naming the file in place of that file's
+// remaining lines; the caller may keep calling Next to get the lines of
+// the other files. Each file is closed as soon as it is read. Calling
+// Close on returned Stream stops the reading and closes any open files
+// before returning.
+func ReadFilesLines(paths []string, parallelism int) Stream {
+  return readLinesOf(NewStreamFromValues(paths, nil), openFile, parallelism)
+}
+
+func openFile(path string) (io.ReadCloser, error) {
+  return os.Open(path)
+}
+
+// readLinesOf reads the lines of each path in paths, a Stream of string,
+// opening them with open, parallelism at a time.
+func readLinesOf(
+    paths Stream,
+    open func(path string) (io.ReadCloser, error),
+    parallelism int) Stream {
+  if parallelism < 1 {
+    parallelism = 1
+  }
+  s := &filesStream{
+      open: open,
+      paths: make(chan string),
+      results: make(chan fileLineResult),
+      quit: make(chan struct{})}
+  s.wg.Add(parallelism + 1)
+  go s.feed(paths)
+  for i := 0; i < parallelism; i++ {
+    go s.work()
+  }
+  go func() {
+    s.wg.Wait()
+    close(s.results)
+  }()
+  return s
+}
+
+// FileErrors tells GlobLinesOpt what to do when a file cannot be opened
+// or read.
+type FileErrors int
+
+const (
+  // ReportFileErrors reports the error through Next and then goes on
+  // with the next file. GlobLines behaves this way.
+  ReportFileErrors FileErrors = iota
+  // SkipFileErrors silently goes on with the next file.
+  SkipFileErrors
+)
+
+// GlobFiles returns the paths of the files matching pattern as a Stream
+// of string in the order filepath.Glob returns them. pattern is not
+// expanded until the first call to Next. If pattern is malformed, Next
+// returns filepath.ErrBadPattern.
+func GlobFiles(pattern string) Stream {
+  return Deferred(func() Stream {
+    paths, err := filepath.Glob(pattern)
+    if err != nil {
+      return errorStream{err}
+    }
+    return NewStreamFromValues(paths, nil)
+  })
+}
+
+// GlobLines returns the lines of the files matching pattern one file after
+// another as a Stream of FileLine. pattern is not expanded until the first
+// call to Next. If a file cannot be opened or read, Next reports the
+// error and then goes on with the next file. Calling Close on returned
+// Stream closes any open file.
+func GlobLines(pattern string) Stream {
+  return GlobLinesOpt(pattern, ReportFileErrors)
+}
+
+// GlobLinesOpt works like GlobLines except that onError controls what
+// happens when a file cannot be opened or read.
+func GlobLinesOpt(pattern string, onError FileErrors) Stream {
+  return Deferred(func() Stream {
+    paths, err := filepath.Glob(pattern)
+    if err != nil {
+      return errorStream{err}
+    }
+    result := ReadFilesLines(paths, 1)
+    if onError == SkipFileErrors {
+      result = skipErrorsStream{result}
+    }
+    return result
+  })
+}
+
+type skipErrorsStream struct {
+  Stream
+}
+
+func (s skipErrorsStream) Next(ptr interface{}) error {
+  for {
+    err := s.Stream.Next(ptr)
+    if err == nil || err == Done {
+      return err
+    }
+  }
+}
+
+type fileLineResult struct {
+  line FileLine
+  err error
+}
+
+type filesStream struct {
+  open func(path string) (io.ReadCloser, error)
+  paths chan string
+  results chan fileLineResult
+  quit chan struct{}
+  wg sync.WaitGroup
+  closeOnce sync.Once
+  done bool
+}
+
+func (s *filesStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  result, ok := <-s.results
+  if !ok {
+    s.done = true
+    return Done
+  }
+  if result.err != nil {
+    return result.err
+  }
+  *ptr.(*FileLine) = result.line
+  return nil
+}
+
+func (s *filesStream) Close() error {
+  s.done = true
+  s.closeOnce.Do(func() {
+    close(s.quit)
+    // Drain results so that workers blocked sending can see quit.
+    for range s.results {
+    }
+  })
+  return nil
+}
+
+func (s *filesStream) feed(paths Stream) {
+  defer s.wg.Done()
+  defer close(s.paths)
+  defer paths.Close()
+  var path string
+  var err error
+  for err = paths.Next(&path); err == nil; err = paths.Next(&path) {
+    select {
+      case s.paths <- path:
+      case <-s.quit:
+        return
+    }
+  }
+  if err != Done {
+    s.send(fileLineResult{err: err})
+  }
+}
+
+func (s *filesStream) work() {
+  defer s.wg.Done()
+  for path := range s.paths {
+    if !s.readFile(path) {
+      return
+    }
+  }
+}
+
+// readFile sends the lines of the file at path. It returns false if
+// the Stream was closed.
+func (s *filesStream) readFile(path string) bool {
+  f, err := s.open(path)
+  if err != nil {
+    return s.send(fileLineResult{err: Annotate(err, path)})
+  }
+  lines := ReadLines(f)
+  defer lines.Close()
+  line := FileLine{Path: path}
+  for err = lines.Next(&line.Text); err == nil; err = lines.Next(&line.Text) {
+    line.Number++
+    if !s.send(fileLineResult{line: line}) {
+      return false
+    }
+  }
+  if err != Done {
+    return s.send(fileLineResult{err: Annotate(err, path)})
+  }
+  return true
+}
+
+func (s *filesStream) send(result fileLineResult) bool {
+  select {
+    case s.results <- result:
+      return true
+    case <-s.quit:
+      return false
+  }
+}
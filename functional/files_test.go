@@ -0,0 +1,136 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "io/ioutil"
+    "os"
+    "path/filepath"
+    "sort"
+    "strings"
+    "testing"
+)
+
+func writeTestFiles(t *testing.T, files map[string]string) string {
+  dir, err := ioutil.TempDir("", "filestest")
+  if err != nil {
+    t.Fatalf("Error creating temp dir: %v", err)
+  }
+  for name, contents := range files {
+    if err := ioutil.WriteFile(filepath.Join(dir, name), []byte(contents), 0644); err != nil {
+      t.Fatalf("Error writing %s: %v", name, err)
+    }
+  }
+  return dir
+}
+
+func TestReadFilesLines(t *testing.T) {
+  dir := writeTestFiles(t, map[string]string{
+      "a.txt": "a1\na2\n",
+      "b.txt": "b1\n",
+      "c.txt": "c1\nc2\nc3"})
+  defer os.RemoveAll(dir)
+  paths := []string{
+      filepath.Join(dir, "a.txt"),
+      filepath.Join(dir, "missing.txt"),
+      filepath.Join(dir, "b.txt"),
+      filepath.Join(dir, "c.txt")}
+  s := ReadFilesLines(paths, 2)
+  var line FileLine
+  var results []string
+  var errs []error
+  for err := s.Next(&line); err != Done; err = s.Next(&line) {
+    if err != nil {
+      errs = append(errs, err)
+      continue
+    }
+    results = append(results, fmt.Sprintf("%s:%d:%s", filepath.Base(line.Path), line.Number, line.Text))
+  }
+  sort.Strings(results)
+  if output := strings.Join(results, " "); output != "a.txt:1:a1 a.txt:2:a2 b.txt:1:b1 c.txt:1:c1 c.txt:2:c2 c.txt:3:c3" {
+    t.Errorf("Expected all lines, got %v", output)
+  }
+  if len(errs) != 1 || !errors.Is(errs[0], os.ErrNotExist) || !strings.HasPrefix(errs[0].Error(), paths[1] + ": ") {
+    t.Errorf("Expected one not exist error, got %v", errs)
+  }
+  closeVerifyResult(t, s, nil)
+}
+
+func TestReadFilesLinesClose(t *testing.T) {
+  files := make(map[string]string)
+  for i := 0; i < 10; i++ {
+    files[fmt.Sprintf("f%d.txt", i)] = strings.Repeat("line\n", 100)
+  }
+  dir := writeTestFiles(t, files)
+  defer os.RemoveAll(dir)
+  var paths []string
+  for name := range files {
+    paths = append(paths, filepath.Join(dir, name))
+  }
+  s := ReadFilesLines(paths, 3)
+  var line FileLine
+  if err := s.Next(&line); err != nil || line.Text != "line" {
+    t.Errorf("Expected line, got %v and %v", line.Text, err)
+  }
+  closeVerifyResult(t, s, nil)
+  closeVerifyResult(t, s, nil)
+  verifyDone(t, s, &line, Done)
+}
+
+func TestGlobLines(t *testing.T) {
+  dir := writeTestFiles(t, map[string]string{
+      "a.log": "a1\na2\n",
+      "b.log": "b1\n",
+      "c.txt": "c1\n"})
+  defer os.RemoveAll(dir)
+  if err := os.Mkdir(filepath.Join(dir, "d.log"), 0755); err != nil {
+    t.Fatalf("Error creating dir: %v", err)
+  }
+  s := GlobLines(filepath.Join(dir, "*.log"))
+  var line FileLine
+  var results []string
+  for err := s.Next(&line); err != Done; err = s.Next(&line) {
+    if err != nil {
+      results = append(results, "error")
+      continue
+    }
+    results = append(results, line.Text)
+  }
+  if output := strings.Join(results, " "); output != "a1 a2 b1 error" {
+    t.Errorf("Expected a1 a2 b1 error, got %v", output)
+  }
+  s = GlobLinesOpt(filepath.Join(dir, "*.log"), SkipFileErrors)
+  results = nil
+  var err error
+  for err = s.Next(&line); err == nil; err = s.Next(&line) {
+    results = append(results, line.Text)
+  }
+  if output := strings.Join(results, " "); output != "a1 a2 b1" || err != Done {
+    t.Errorf("Expected a1 a2 b1, got %v and %v", output, err)
+  }
+  closeVerifyResult(t, s, nil)
+}
+
+func TestGlobFiles(t *testing.T) {
+  dir := writeTestFiles(t, map[string]string{"b.log": "", "a.log": "", "c.txt": ""})
+  defer os.RemoveAll(dir)
+  paths, err := toStringArray(GlobFiles(filepath.Join(dir, "*.log")))
+  for i := range paths {
+    paths[i] = filepath.Base(paths[i])
+  }
+  if output := fmt.Sprintf("%v", paths); output != "[a.log b.log]" {
+    t.Errorf("Expected [a.log b.log] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  var path string
+  if err := GlobFiles("[").Next(&path); err != filepath.ErrBadPattern {
+    t.Errorf("Expected ErrBadPattern, got %v", err)
+  }
+}
@@ -0,0 +1,109 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// AtLeast returns a Filterer that returns nil if at least n of the fs
+// return nil. Otherwise it returns Skipped. If one of the fs returns an
+// error other than Skipped, the returned Filterer returns that error.
+// AtLeast stops evaluating fs as soon as the outcome is known.
+// AtLeast(1, fs...) works like Any(fs...).
+func AtLeast(n int, fs ...Filterer) Filterer {
+  return &thresholdFilterer{fs: fs, min: n, max: len(fs)}
+}
+
+// Exactly returns a Filterer that returns nil if exactly n of the fs
+// return nil. Otherwise it returns Skipped. If one of the fs returns an
+// error other than Skipped, the returned Filterer returns that error.
+// Exactly stops evaluating fs as soon as the outcome is known.
+func Exactly(n int, fs ...Filterer) Filterer {
+  return &thresholdFilterer{fs: fs, min: n, max: n}
+}
+
+// FilterExplainer is a Filterer of T that applies a list of Filterers
+// like All does while recording how many values each one of them rejected.
+// A FilterExplainer cannot be used by multiple goroutines simultaneously.
+type FilterExplainer struct {
+  filterers []Filterer
+  rejected []int
+  count int
+}
+
+// Explain returns a FilterExplainer that filters the same way f does. If f
+// was created with All, the returned FilterExplainer keeps separate
+// rejection counts for each Filterer passed to All, in order. Otherwise it
+// keeps a single rejection count for f.
+func Explain(f Filterer) *FilterExplainer {
+  fs := andList(f)
+  return &FilterExplainer{filterers: fs, rejected: make([]int, len(fs))}
+}
+
+func (f *FilterExplainer) Filter(ptr interface{}) error {
+  f.count++
+  for i := range f.filterers {
+    err := f.filterers[i].Filter(ptr)
+    if err == Skipped {
+      f.rejected[i]++
+    }
+    if err != nil {
+      return err
+    }
+  }
+  return nil
+}
+
+// Filterers returns the Filterers for which f keeps rejection counts.
+func (f *FilterExplainer) Filterers() []Filterer {
+  return f.filterers
+}
+
+// Rejected returns how many values each Filterer rejected so far. The
+// ith element corresponds to the ith element that Filterers returns.
+// Since the Filterers are applied in order, a value counts only against
+// the first Filterer that rejected it.
+func (f *FilterExplainer) Rejected() []int {
+  result := make([]int, len(f.rejected))
+  copy(result, f.rejected)
+  return result
+}
+
+// Count returns the number of values f has been asked to filter so far.
+func (f *FilterExplainer) Count() int {
+  return f.count
+}
+
+type thresholdFilterer struct {
+  fs []Filterer
+  min int
+  max int
+}
+
+func (f *thresholdFilterer) Filter(ptr interface{}) error {
+  if f.min > f.max || f.min > len(f.fs) {
+    return Skipped
+  }
+  matched := 0
+  for i := range f.fs {
+    if matched >= f.min && f.max == len(f.fs) {
+      return nil
+    }
+    if matched + len(f.fs) - i < f.min {
+      return Skipped
+    }
+    err := f.fs[i].Filter(ptr)
+    if err == nil {
+      matched++
+      if matched > f.max {
+        return Skipped
+      }
+    } else if err != Skipped {
+      return err
+    }
+  }
+  if matched >= f.min {
+    return nil
+  }
+  return Skipped
+}
@@ -0,0 +1,91 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestAtLeast(t *testing.T) {
+  f := AtLeast(2, lessThan(3), greaterThan(6), equal(1), equal(7))
+  stream := Filter(f, xrange(0, 10))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[1 7]" {
+    t.Errorf("Expected [1 7] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestAtLeastShortCircuit(t *testing.T) {
+  f := AtLeast(1, includeFilterer, errFilterer)
+  if output := f.Filter(ptrInt(0)); output != nil {
+    t.Errorf("Expected nil got %v", output)
+  }
+  f = AtLeast(2, skipFilterer, skipFilterer, errFilterer)
+  if output := f.Filter(ptrInt(0)); output != Skipped {
+    t.Errorf("Expected Skipped got %v", output)
+  }
+  f = AtLeast(1, skipFilterer, errFilterer)
+  if output := f.Filter(ptrInt(0)); output != filterError {
+    t.Errorf("Expected filterError got %v", output)
+  }
+}
+
+func TestExactly(t *testing.T) {
+  f := Exactly(1, lessThan(5), equal(2), greaterThan(7))
+  stream := Filter(f, xrange(0, 10))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 3 4 8 9]" {
+    t.Errorf("Expected [0 1 3 4 8 9] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestExactlyZero(t *testing.T) {
+  f := Exactly(0, lessThan(3), greaterThan(6))
+  results, _ := toIntArray(Filter(f, xrange(0, 10)))
+  if output := fmt.Sprintf("%v", results); output != "[3 4 5 6]" {
+    t.Errorf("Expected [3 4 5 6] got %v", output)
+  }
+  if output := Exactly(3, includeFilterer).Filter(ptrInt(0)); output != Skipped {
+    t.Errorf("Expected Skipped got %v", output)
+  }
+}
+
+func TestExplain(t *testing.T) {
+  f := Explain(All(greaterThan(2), lessThan(8), notEqual(5)))
+  stream := Filter(f, xrange(0, 10))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 4 6 7]" {
+    t.Errorf("Expected [3 4 6 7] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  if output := fmt.Sprintf("%v", f.Rejected()); output != "[3 2 1]" {
+    t.Errorf("Expected [3 2 1] got %v", output)
+  }
+  if output := f.Count(); output != 10 {
+    t.Errorf("Expected 10 got %v", output)
+  }
+}
+
+func TestExplainSingle(t *testing.T) {
+  f := Explain(lessThan(3))
+  toIntArray(Filter(f, xrange(0, 10)))
+  if output := fmt.Sprintf("%v", f.Rejected()); output != "[7]" {
+    t.Errorf("Expected [7] got %v", output)
+  }
+}
+
+func TestExplainError(t *testing.T) {
+  f := Explain(All(includeFilterer, errFilterer))
+  if output := f.Filter(ptrInt(0)); output != filterError {
+    t.Errorf("Expected filterError got %v", output)
+  }
+  if output := fmt.Sprintf("%v", f.Rejected()); output != "[0 0]" {
+    t.Errorf("Expected [0 0] got %v", output)
+  }
+}
@@ -11,6 +11,7 @@ import (
   "errors"
   "io"
   "reflect"
+  "sync"
 )
 
 // Done indicates that the end of a Stream has been reached
@@ -52,6 +53,34 @@ type Tuple interface {
   Ptrs() []interface{}
 }
 
+// Skipper is implemented by Streams that can skip values more cheaply
+// than emitting them, such as the Streams Count, NewStreamFromValues, and
+// ReadRows return. Slice uses it to fast-forward to its start.
+type Skipper interface {
+  // Skip skips the next n values. Skip returns Done if the end of the
+  // Stream was reached before n values were skipped. Skip may return
+  // other errors.
+  Skip(n int) error
+}
+
+// LenHinter is implemented by Streams that know how many values they have
+// left to emit, such as the Streams NewStreamFromValues and
+// NewStreamFromPtrs return. Consumers can use it to size buffers up front.
+type LenHinter interface {
+  // LenHint returns the number of values left to emit and true, or
+  // false if that number is not known.
+  LenHint() (int, bool)
+}
+
+// LenHintOf returns the result of s.LenHint() if s implements LenHinter;
+// otherwise it returns 0 and false.
+func LenHintOf(s Stream) (int, bool) {
+  if h, ok := s.(LenHinter); ok {
+    return h.LenHint()
+  }
+  return 0, false
+}
+
 // Filterer of T filters values in a Stream of T.
 type Filterer interface {
   // Filter returns nil if value ptr points to should be included or Skipped
@@ -93,6 +122,17 @@ func (c CompositeMapper) Fast() Mapper {
   return fastCompositeMapper{fastPieces}
 }
 
+// Pooled returns a version of this CompositeMapper that can be used by
+// multiple goroutines simultaneously like this CompositeMapper, but that
+// keeps sets of intermediate values in a sync.Pool for reuse rather than
+// creating new ones with each call to Map. The returned Mapper is nearly as
+// fast as the one Fast returns when used repeatedly.
+func (c CompositeMapper) Pooled() Mapper {
+  return &pooledMapper{pool: sync.Pool{New: func() interface{} {
+    return c.Fast()
+  }}}
+}
+
 func (c CompositeMapper) pieces() []compositeMapperPiece {
   if len(c._pieces) == 0 {
     return nilPieceL
@@ -157,13 +197,13 @@ func Filter(f Filterer, s Stream) Stream {
 // Count returns an infinite Stream of int which emits all values beginning
 // at 0.
 func Count() Stream {
-  return &count{0, 1}
+  return &count{start: 0, step: 1}
 }
 
 // CountFrom returns an infinite Stream of int emitting values beginning at
 // start and increasing by step.
 func CountFrom(start, step int) Stream {
-  return &count{start, step}
+  return &count{start: start, step: step}
 }
 
 // Slice returns a Stream that will emit elements in s starting at index start
@@ -178,20 +218,51 @@ func Slice(s Stream, start int, end int) Stream {
 // ReadRows returns the rows in a database table as a Stream of Tuple. When
 // end of returned Stream is reached, it closes r if r implements io.Closer
 // propagating any Close error through Next. Calling Close on returned
-// stream closes r if r implements io.Closer.
+// stream closes r if r implements io.Closer. If r also has an
+// Err() error method, as *sql.Rows does, ReadRows checks it when the Next
+// method of r returns false and reports any error through Next instead of
+// Done so that result sets are not silently truncated.
 func ReadRows(r Rows) Stream {
   c, _ := r.(io.Closer)
-  return &rowStream{rows: r, maybeCloser: maybeCloser{c: c}}
+  e, _ := r.(errRows)
+  return &rowStream{
+      rows: r,
+      errRows: e,
+      maybeCloser: maybeCloser{c: c, metric: trackOpen("ReadRows")}}
+}
+
+// ReadRowsColumns works like ReadRows except that it maps the columns of
+// r onto the fields of the emitted Tuple values using indices. indices
+// has one element for each column in r: indices[i] is the 0-based index
+// of the Tuple field, as returned by Ptrs, that receives column i, or -1
+// if column i should be ignored. This allows one Tuple type to serve
+// queries that select its fields in different orders or that select
+// extra columns.
+func ReadRowsColumns(r Rows, indices []int) Stream {
+  result := ReadRows(r).(*rowStream)
+  result.indices = indices
+  result.scanPtrs = make([]interface{}, len(indices))
+  return result
 }
 
 // ReadLines returns the lines of text in r separated by either "\n" or "\r\n"
 // as a Stream of string. The emitted string types do not contain the
-// end of line characters. When end of returned Stream is reached, it closes
-// r if r implements io.Closer propagating any Close error through Next.
+// end of line characters. A byte order mark at the start of r is
+// dropped; a UTF-16 mark also makes ReadLines decode r as little or big
+// endian UTF-16, so files exported on Windows can be read directly. The
+// returned Stream implements Positioned, counting lines, and Offsetter so
+// that a job can record where it stopped and later resume by seeking r
+// to that offset. Offsets are only exact for text that is not UTF-16.
+// When end of returned Stream is reached, it closes r if r implements
+// io.Closer propagating any Close error through Next.
 // Calling Close on returned Stream closes r if r implements io.Closer.
 func ReadLines(r io.Reader) Stream {
   c, _ := r.(io.Closer)
-  return &lineStream{bufio: bufio.NewReader(r), maybeCloser: maybeCloser{c: c}}
+  counter := &countingReader{r: r}
+  return &lineStream{
+      bufio: bufio.NewReader(counter),
+      counter: counter,
+      maybeCloser: maybeCloser{c: c, metric: trackOpen("ReadLines")}}
 }
 
 // Deferred returns a Stream that emits the values from the Stream f returns.
@@ -227,24 +298,32 @@ func Concat(s ...Stream) Stream {
 }
 
 // NewStreamFromValues converts a []T into a Stream of T. aSlice is a []T.
-// c is a Copier of T. If c is nil, regular assignment is used.
+// c is a Copier of T. If c is nil, the Copier registered for T is used,
+// or regular assignment if there is none.
 // Calling Close on returned Stream does nothing.
 func NewStreamFromValues(aSlice interface{}, c Copier) Stream {
   sliceValue := getSliceValue(aSlice)
   if sliceValue.Len() == 0 {
     return nilS
   }
+  if c == nil {
+    c = registeredCopier(sliceValue.Type().Elem())
+  }
   return &plainStream{sliceValue: sliceValue, copyFunc: toSliceValueCopier(c)}
 }
 
 // NewStreamFromPtrs converts a []*T into a Stream of T. aSlice is a []*T.
-// c is a Copier of T. If c is nil, regular assignment is used.
+// c is a Copier of T. If c is nil, the Copier registered for T is used,
+// or regular assignment if there is none.
 // Calling Close on returned Stream does nothing.
 func NewStreamFromPtrs(aSlice interface{}, c Copier) Stream {
   sliceValue := getSliceValue(aSlice)
   if sliceValue.Len() == 0 {
     return nilS
   }
+  if c == nil {
+    c = registeredCopier(sliceValue.Type().Elem().Elem())
+  }
   valueCopierFunc := toSliceValueCopier(c)
   copyFunc := func(src reflect.Value, dest interface{}) {
     valueCopierFunc(reflect.Indirect(src), dest)
@@ -278,6 +357,31 @@ func DropWhile(f Filterer, s Stream) Stream {
   return &dropStream{Stream: s, f: f}
 }
 
+// PredicateErrors tells TakeWhileOpt and DropWhileOpt what to do when
+// the Filter method of their Filterer returns an error other than Skipped.
+type PredicateErrors int
+
+const (
+  // FailOnPredicateError reports the error through Next. TakeWhile and
+  // DropWhile behave this way.
+  FailOnPredicateError PredicateErrors = iota
+  // SkipOnPredicateError leaves the offending value out of the returned
+  // Stream and keeps scanning as if the error never happened.
+  SkipOnPredicateError
+)
+
+// TakeWhileOpt works like TakeWhile except that onError controls what
+// happens when the Filter method of f returns an error other than Skipped.
+func TakeWhileOpt(f Filterer, s Stream, onError PredicateErrors) Stream {
+  return &takeStream{Stream: s, f: f, skipErrors: onError == SkipOnPredicateError}
+}
+
+// DropWhileOpt works like DropWhile except that onError controls what
+// happens when the Filter method of f returns an error other than Skipped.
+func DropWhileOpt(f Filterer, s Stream, onError PredicateErrors) Stream {
+  return &dropStream{Stream: s, f: f, skipErrors: onError == SkipOnPredicateError}
+}
+
 // Any returns a Filterer that returns Skipped if all of the fs return
 // Skipped. Otherwise it returns nil or the first error not equal to Skipped.
 func Any(fs ...Filterer) Filterer {
@@ -338,6 +442,67 @@ func FastCompose(f Mapper, g Mapper, ptr interface{}) Mapper {
   return fastCompositeMapper{pieces}
 }
 
+// ComposeAll composes multiple Mappers together into one e.g f(g(h(x))) in
+// one pass. mappers[0] is the outermost Mapper and is applied last; the last
+// element of mappers is applied first. creaters[i] is a Creater of the
+// type that mappers[i+1] maps to and that mappers[i] maps from, so
+// len(creaters) must be one less than len(mappers). ComposeAll(cs, f, g)
+// is equivalent to Compose(f, g, cs[0]). ComposeAll panics if the lengths
+// do not agree. If mappers is empty, ComposeAll returns the zero value of
+// CompositeMapper.
+func ComposeAll(creaters []Creater, mappers ...Mapper) CompositeMapper {
+  if len(mappers) == 0 {
+    return CompositeMapper{}
+  }
+  checkComposeAllLen(len(creaters), len(mappers))
+  l := 0
+  for i := range mappers {
+    l += mapperLen(mappers[i])
+  }
+  pieces := make([]compositeMapperPiece, l)
+  n := 0
+  for i := len(mappers) - 1; i >= 0; i-- {
+    n += appendMapper(pieces[n:], mappers[i])
+    if i > 0 {
+      pieces[n - 1].creater = creaters[i - 1]
+    }
+  }
+  return CompositeMapper{pieces}
+}
+
+// FastComposeAll works like ComposeAll except that it uses pointers
+// instead of Creaters to link the Mappers just as FastCompose does.
+// ptrs[i] receives intermediate results from mappers[i+1]. Like
+// FastCompose, the returned Mapper cannot be used by multiple goroutines
+// simultaneously.
+func FastComposeAll(ptrs []interface{}, mappers ...Mapper) Mapper {
+  if len(mappers) == 0 {
+    return CompositeMapper{}.Fast()
+  }
+  checkComposeAllLen(len(ptrs), len(mappers))
+  l := 0
+  for i := range mappers {
+    l += mapperLen(mappers[i])
+  }
+  pieces := make([]fastMapperPiece, l)
+  n := 0
+  for i := len(mappers) - 1; i >= 0; i-- {
+    n += appendFastMapper(pieces[n:], mappers[i])
+    if i > 0 {
+      pieces[n - 1].ptr = ptrs[i - 1]
+    }
+  }
+  return fastCompositeMapper{pieces}
+}
+
+// PooledCompose works like Compose except that it returns a Mapper that
+// reuses intermediate values as if Pooled were called on the result of
+// Compose. Like Compose, the returned Mapper can be used by multiple
+// goroutines simultaneously if f and g can.
+func PooledCompose(f Mapper, g Mapper, c Creater) Mapper {
+  return Compose(f, g, c).Pooled()
+}
+
 // NoCloseStream returns a Stream just like s but with a Close method that does
 // nothing. The returnes Stream will still automatically close itself when the
 // end of stream is reached. This function is useful for preventing a stream from
@@ -365,6 +530,43 @@ func NoCloseReader(r io.Reader) io.Reader {
   return r
 }
 
+// NoCloseWriter returns an io.Writer just like w that does not implement
+// io.Closer.
+func NoCloseWriter(w io.Writer) io.Writer {
+  _, ok := w.(io.Closer)
+  if ok {
+    return writerWrapper{w}
+  }
+  return w
+}
+
+// NoClose shields a shared resource from being closed by the Streams and
+// Consumers it is passed to. Many functions in this package, such as
+// ReadRows, ReadLines, Slice, and TakeWhile, close their underlying
+// resource automatically when they reach the end. Wrap a resource with
+// NoClose when it must outlive the Stream built on it, for instance when
+// the same Rows iterator is read through several successive calls to Slice,
+// and close it yourself when finished. x may be a Stream, Rows, io.Reader,
+// or io.Writer; NoClose returns NoCloseStream(x), NoCloseRows(x),
+// NoCloseReader(x), or NoCloseWriter(x) respectively, checked in that
+// order. If x does not implement io.Closer, NoClose returns x unchanged.
+// NoClose panics if x implements io.Closer but is none of these types.
+func NoClose(x interface{}) interface{} {
+  switch v := x.(type) {
+  case Stream:
+    return NoCloseStream(v)
+  case Rows:
+    return NoCloseRows(v)
+  case io.Reader:
+    return NoCloseReader(v)
+  case io.Writer:
+    return NoCloseWriter(v)
+  case io.Closer:
+    panic("NoClose does not support this type.")
+  }
+  return x
+}
+
 // NewFilterer returns a new Filterer of T. f takes a *T returning nil
 // if T value pointed to it should be included or Skipped if it should not
 // be included. f can return other errors too.
@@ -380,18 +582,48 @@ func NewMapper(m func(srcPtr interface{}, destPtr interface{}) error) Mapper {
   return funcMapper(m)
 }
 
+// NewBoolFilterer returns a new Filterer of T. f takes a *T returning true
+// if T value pointed to it should be included or false if it should not
+// be included.
+func NewBoolFilterer(f func(ptr interface{}) bool) Filterer {
+  return boolFilterer(f)
+}
+
+// NewValueMapper returns a new Mapper mapping T values to U values. f takes
+// a *T and returns the mapped U value itself along with true, or returns
+// false if the mapped value should be skipped. c is a Copier of U used to
+// store the returned U value at the destination. If c is nil, the Copier
+// registered for U is used, or regular assignment if there is none. If f
+// returns nil along with true, the destination gets the zero value of U.
+func NewValueMapper(
+    f func(srcPtr interface{}) (dest interface{}, ok bool), c Copier) Mapper {
+  return &valueMapper{f: f, c: c}
+}
+
 type count struct {
   start int
   step int
+  position int
 }
 
 func (c *count) Next(ptr interface{}) error {
   p := ptr.(*int)
   *p = c.start
   c.start += c.step
+  c.position++
+  return nil
+}
+
+func (c *count) Skip(n int) error {
+  c.start += n * c.step
+  c.position += n
   return nil
 }
 
+func (c *count) Position() int {
+  return c.position
+}
+
 func (c *count) Close() error {
   return nil
 }
@@ -437,6 +669,10 @@ func (s nilStream) Close() error {
   return nil
 }
 
+func (s nilStream) LenHint() (int, bool) {
+  return 0, true
+}
+
 type nilMapper struct {
 }
 
@@ -471,6 +707,21 @@ func (s *sliceStream) Next(ptr interface{}) error {
   if s.done {
     return Done
   }
+  if sk, ok := s.Stream.(Skipper); ok && s.index < s.start {
+    n := s.start - s.index
+    if s.end >= 0 && s.end < s.start {
+      n = s.end - s.index
+    }
+    err := sk.Skip(n)
+    if err == Done {
+      s.done = true
+      return Done
+    }
+    if err != nil {
+      return err
+    }
+    s.index += n
+  }
   for s.end < 0 || s.index < s.end {
     err := s.Stream.Next(ptr)
     if err == Done {
@@ -489,10 +740,46 @@ func (s *sliceStream) Next(ptr interface{}) error {
   return finish(s.Close())
 }
 
+func (s *sliceStream) LenHint() (int, bool) {
+  if s.done {
+    return 0, true
+  }
+  result, ok := LenHintOf(s.Stream)
+  if !ok {
+    return 0, false
+  }
+  begin := s.index
+  if s.start > begin {
+    result -= s.start - begin
+    begin = s.start
+  }
+  if s.end >= 0 && s.end - begin < result {
+    result = s.end - begin
+  }
+  if result < 0 {
+    result = 0
+  }
+  return result, true
+}
+
+func (s *sliceStream) Position() int {
+  if s.index <= s.start {
+    return 0
+  }
+  return s.index - s.start
+}
+
+type errRows interface {
+  Err() error
+}
+
 type rowStream struct {
   rows Rows
+  errRows errRows
   maybeCloser
   done bool
+  indices []int
+  scanPtrs []interface{}
 }
 
 func (s *rowStream) Next(ptr interface{}) error {
@@ -500,17 +787,66 @@ func (s *rowStream) Next(ptr interface{}) error {
     return Done
   }
   if !s.rows.Next() {
-    s.done = true
-    return finish(s.Close())
+    return s.end()
   }
   ptrs := ptr.(Tuple).Ptrs()
-  return s.rows.Scan(ptrs...)
+  if s.indices != nil {
+    ptrs = s.reorder(ptrs)
+  }
+  return countEmitted(s.rows.Scan(ptrs...))
+}
+
+// Skip advances past rows without scanning them.
+func (s *rowStream) Skip(n int) error {
+  if s.done {
+    return Done
+  }
+  for i := 0; i < n; i++ {
+    if !s.rows.Next() {
+      return s.end()
+    }
+  }
+  return nil
+}
+
+func (s *rowStream) end() error {
+  if s.errRows != nil {
+    if err := s.errRows.Err(); err != nil {
+      return err
+    }
+  }
+  s.done = true
+  return finish(s.Close())
+}
+
+func (s *rowStream) reorder(ptrs []interface{}) []interface{} {
+  for i, idx := range s.indices {
+    if idx < 0 {
+      s.scanPtrs[i] = new(interface{})
+    } else {
+      s.scanPtrs[i] = ptrs[idx]
+    }
+  }
+  return s.scanPtrs
 }
 
 type lineStream struct {
   bufio *bufio.Reader
+  counter *countingReader
   maybeCloser
+  started bool
   done bool
+  lines int
+}
+
+// Offset returns the number of bytes read from the underlying reader
+// that have been emitted as lines including their line endings.
+func (s *lineStream) Offset() int64 {
+  return s.counter.n - int64(s.bufio.Buffered())
+}
+
+func (s *lineStream) Position() int {
+  return s.lines
 }
 
 func (s *lineStream) Next(ptr interface{}) error {
@@ -518,6 +854,10 @@ func (s *lineStream) Next(ptr interface{}) error {
     return Done
   }
   p := ptr.(*string)
+  if !s.started {
+    s.started = true
+    s.skipBOM()
+  }
   line, isPrefix, err := s.bufio.ReadLine()
   if err == io.EOF {
     s.done = true
@@ -528,10 +868,34 @@ func (s *lineStream) Next(ptr interface{}) error {
   }
   if !isPrefix {
     *p = string(line)
-    return nil
+    s.lines++
+    return countEmitted(nil)
   }
   *p, err = s.readRestOfLine(line)
-  return err
+  if err == nil {
+    s.lines++
+  }
+  return countEmitted(err)
+}
+
+func (s *lineStream) skipBOM() {
+  if b, err := s.bufio.Peek(2); err == nil {
+    if b[0] == 0xff && b[1] == 0xfe {
+      s.decodeUTF16(false)
+    } else if b[0] == 0xfe && b[1] == 0xff {
+      s.decodeUTF16(true)
+    }
+  }
+  if b, err := s.bufio.Peek(len(utf8BOM)); err == nil && string(b) == utf8BOM {
+    s.bufio.Discard(len(utf8BOM))
+  }
+}
+
+// decodeUTF16 switches to reading UTF-16 text. The mark becomes a
+// leading U+FEFF which skipBOM then drops.
+func (s *lineStream) decodeUTF16(bigEndian bool) {
+  s.bufio = bufio.NewReader(
+      &decodeReader{r: s.bufio, next: nextUTF16(bigEndian)})
 }
 
 func (s *lineStream) readRestOfLine(line []byte) (string, error) {
@@ -639,6 +1003,23 @@ func (s *plainStream) Close() error {
   return nil
 }
 
+func (s *plainStream) Skip(n int) error {
+  if remaining := s.sliceValue.Len() - s.index; n > remaining {
+    s.index += remaining
+    return Done
+  }
+  s.index += n
+  return nil
+}
+
+func (s *plainStream) LenHint() (int, bool) {
+  return s.sliceValue.Len() - s.index, true
+}
+
+func (s *plainStream) Position() int {
+  return s.index
+}
+
 type flattenStream struct {
   stream Stream
   current Stream
@@ -669,22 +1050,29 @@ func (s *flattenStream) Close() error {
 type takeStream struct {
   Stream
   f Filterer
+  skipErrors bool
 }
 
 func (s *takeStream) Next(ptr interface{}) error {
   if s.f == nil {
     return Done
   }
-  err := s.Stream.Next(ptr)
-  if err == Done {
-    s.f = nil
-    return Done
-  }
-  if err != nil {
-    return err
-  }
-  if ferr := s.f.Filter(ptr); ferr != Skipped {
-    return ferr
+  for {
+    err := s.Stream.Next(ptr)
+    if err == Done {
+      s.f = nil
+      return Done
+    }
+    if err != nil {
+      return err
+    }
+    ferr := s.f.Filter(ptr)
+    if ferr == Skipped {
+      break
+    }
+    if ferr == nil || !s.skipErrors {
+      return ferr
+    }
   }
   s.f = nil
   return finish(s.Close())
@@ -693,6 +1081,7 @@ func (s *takeStream) Next(ptr interface{}) error {
 type dropStream struct {
   Stream
   f Filterer
+  skipErrors bool
 }
 
 func (s *dropStream) Next(ptr interface{}) error {
@@ -706,7 +1095,7 @@ func (s *dropStream) Next(ptr interface{}) error {
       s.f = nil
       return nil
     }
-    if ferr != nil {
+    if ferr != nil && !s.skipErrors {
       return ferr
     }
   }
@@ -719,6 +1108,15 @@ func (f funcFilterer) Filter(ptr interface{}) error {
   return f(ptr)
 }
 
+type boolFilterer func(ptr interface{}) bool
+
+func (f boolFilterer) Filter(ptr interface{}) error {
+  if f(ptr) {
+    return nil
+  }
+  return Skipped
+}
+
 type andFilterer []Filterer
 
 func (f andFilterer) Filter(ptr interface{}) error {
@@ -747,6 +1145,53 @@ func (m funcMapper) Map(srcPtr interface{}, destPtr interface{}) error {
   return m(srcPtr, destPtr)
 }
 
+type valueMapper struct {
+  f func(srcPtr interface{}) (interface{}, bool)
+  c Copier
+  // once looks up the registered Copier for the type of the first
+  // value once so that Map need not take the registry lock each time.
+  once sync.Once
+  registeredType reflect.Type
+  registered Copier
+}
+
+func (m *valueMapper) Map(srcPtr interface{}, destPtr interface{}) error {
+  dest, ok := m.f(srcPtr)
+  if !ok {
+    return Skipped
+  }
+  destElem := reflect.ValueOf(destPtr).Elem()
+  if dest == nil {
+    destElem.Set(reflect.Zero(destElem.Type()))
+    return nil
+  }
+  destValue := reflect.ValueOf(dest)
+  c := m.c
+  if c == nil {
+    c = m.copierFor(destValue.Type())
+  }
+  if c == nil {
+    destElem.Set(destValue)
+    return nil
+  }
+  p := reflect.New(destValue.Type())
+  p.Elem().Set(destValue)
+  c(p.Interface(), destPtr)
+  return nil
+}
+
+func (m *valueMapper) copierFor(t reflect.Type) Copier {
+  m.once.Do(func() {
+    m.registeredType = t
+    m.registered = registeredCopier(t)
+  })
+  if t == m.registeredType {
+    return m.registered
+  }
+  // U is an interface type holding values of more than one type.
+  return registeredCopier(t)
+}
+
 type fastCompositeMapper struct {
   pieces []fastMapperPiece
 }
@@ -770,6 +1215,16 @@ func (m fastCompositeMapper) Map(srcPtr interface{}, destPtr interface{}) error
   return nil
 }
 
+type pooledMapper struct {
+  pool sync.Pool
+}
+
+func (m *pooledMapper) Map(srcPtr interface{}, destPtr interface{}) error {
+  fm := m.pool.Get().(Mapper)
+  defer m.pool.Put(fm)
+  return fm.Map(srcPtr, destPtr)
+}
+
 type compositeMapperPiece struct {
   mapper Mapper
   creater Creater
@@ -802,6 +1257,10 @@ type readerWrapper struct {
   io.Reader
 }
 
+type writerWrapper struct {
+  io.Writer
+}
+
 type rowsWrapper struct {
   Rows
 }
@@ -817,9 +1276,11 @@ func (s noCloseStream) Close() error {
 type maybeCloser struct {
   c io.Closer
   e error
+  metric openMetric
 }
 
 func (mc *maybeCloser) Close() error {
+  mc.metric.release()
   if mc.c != nil {
     mc.e = mc.c.Close()
     mc.c = nil
@@ -901,6 +1362,12 @@ func appendFastMapper(pieces []fastMapperPiece, m Mapper) int {
   return 1
 }
 
+func checkComposeAllLen(linkLen, mapperLen int) {
+  if linkLen != mapperLen - 1 {
+    panic("Need exactly one fewer intermediate value than Mappers.")
+  }
+}
+
 func newCreater(ptr interface{}) Creater {
   return func() interface{} {
     return ptr
@@ -914,6 +1381,17 @@ func finish(e error) error {
   return e
 }
 
+type countingReader struct {
+  r io.Reader
+  n int64
+}
+
+func (c *countingReader) Read(p []byte) (int, error) {
+  n, err := c.r.Read(p)
+  c.n += int64(n)
+  return n, err
+}
+
 func copyBytes(b []byte) []byte {
   result := make([]byte, len(b))
   copy(result, b)
@@ -10,6 +10,7 @@ import (
     "fmt"
     "io"
     "strings"
+    "sync"
     "testing"
 )
 
@@ -252,6 +253,71 @@ func TestCountFrom(t *testing.T) {
   verifyDone(t, stream, new(int), err)
 }
 
+func TestSliceSkipsCount(t *testing.T) {
+  stream := Slice(Count(), 1000000000, 1000000002)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[1000000000 1000000001]"  {
+    t.Errorf("Expected [1000000000 1000000001] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestSliceSkipsValues(t *testing.T) {
+  stream := Slice(NewStreamFromValues([]int{1, 2, 3, 4}, nil), 2, -1)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 4]"  {
+    t.Errorf("Expected [3 4] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  stream = Slice(NewStreamFromValues([]int{1, 2}, nil), 5, -1)
+  results, err = toIntArray(stream)
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestSliceSkipsRows(t *testing.T) {
+  rows := &rowsCloseChecker{
+      &fakeRows{ids: []int {3, 4, 5}, names: []string{"foo", "bar", "baz"}},
+      &simpleCloseChecker{noDupClose: true}}
+  stream := Slice(ReadRows(rows), 2, -1)
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{5 baz}]"  {
+    t.Errorf("Expected [{5 baz}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+  verifyCloseCalled(t, rows)
+  rows = &rowsCloseChecker{
+      &fakeRows{ids: []int {3}, names: []string{"foo"}},
+      &simpleCloseChecker{noDupClose: true}}
+  stream = Slice(ReadRows(rows), 2, -1)
+  _, err = toIntAndStringArray(stream)
+  verifyDone(t, stream, new(intAndString), err)
+  verifyCloseCalled(t, rows)
+}
+
+func TestLenHint(t *testing.T) {
+  verifyLenHint(t, NewStreamFromValues([]int{1, 2, 3}, nil), 1, 2, true)
+  verifyLenHint(t, NilStream(), 0, 0, true)
+  verifyLenHint(t, Slice(NewStreamFromValues([]int{1, 2, 3, 4, 5}, nil), 1, 3), 0, 2, true)
+  verifyLenHint(t, Slice(NewStreamFromValues([]int{1, 2, 3, 4, 5}, nil), 1, 3), 1, 1, true)
+  verifyLenHint(t, Slice(NewStreamFromValues([]int{1, 2, 3}, nil), 1, 10), 0, 2, true)
+  verifyLenHint(t, Slice(NewStreamFromValues([]int{1, 2, 3}, nil), 5, 10), 0, 0, true)
+  verifyLenHint(t, Slice(Count(), 1, 3), 0, 0, false)
+}
+
+func verifyLenHint(t *testing.T, s Stream, reads int, expected int, expectedOk bool) {
+  var x int
+  for i := 0; i < reads; i++ {
+    s.Next(&x)
+  }
+  n, ok := LenHintOf(s)
+  if n != expected || ok != expectedOk {
+    t.Errorf("Expected %d %v, got %d %v", expected, expectedOk, n, ok)
+  }
+}
+
 func TestReadRows(t *testing.T) {
   rows := &rowsCloseChecker{
       &fakeRows{ids: []int {3, 4}, names: []string{"foo", "bar"}},
@@ -298,6 +364,40 @@ func TestReadRowsError(t *testing.T) {
   s.Close()
 }
 
+func TestReadRowsErr(t *testing.T) {
+  rows := &errRowsCloseChecker{
+      &fakeRowsWithErr{&fakeRows{ids: []int {3}, names: []string{"foo"}}, scanError},
+      &simpleCloseChecker{noDupClose: true}}
+  stream := ReadRows(rows)
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{3 foo}]"  {
+    t.Errorf("Expected [{3 foo}] got %v", output)
+  }
+  if err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, rows)
+}
+
+func TestReadRowsErrNil(t *testing.T) {
+  rows := &fakeRowsWithErr{&fakeRows{ids: []int {3}, names: []string{"foo"}}, nil}
+  stream := ReadRows(rows)
+  _, err := toIntAndStringArray(stream)
+  verifyDone(t, stream, new(intAndString), err)
+}
+
+func TestReadRowsColumns(t *testing.T) {
+  rows := &nameIdRows{
+      names: []string{"foo", "bar"}, ids: []int{3, 4}, extra: []float64{1.5, 2.5}}
+  stream := ReadRowsColumns(rows, []int{1, -1, 0})
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{3 foo} {4 bar}]"  {
+    t.Errorf("Expected [{3 foo} {4 bar}] got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+}
+
 func TestReadRowsNextPropagateClose(t *testing.T) {
   rows := &rowsCloseChecker{&fakeRows{}, &simpleCloseChecker{closeError: closeError, noDupClose: true}}
   stream := ReadRows(rows)
@@ -737,6 +837,36 @@ func TestDropWhileSome(t *testing.T) {
   verifyDone(t, stream, new(int), err)
 }
 
+func TestTakeWhileOptSkipErrors(t *testing.T) {
+  stream := TakeWhileOpt(failOn(2, lessThan(4)), xrange(0, 10), SkipOnPredicateError)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 3]" {
+    t.Errorf("Expected [0 1 3] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestTakeWhileOptFailOnError(t *testing.T) {
+  stream := TakeWhileOpt(failOn(2, lessThan(4)), xrange(0, 10), FailOnPredicateError)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1]" {
+    t.Errorf("Expected [0 1] got %v", output)
+  }
+  if err != filterError {
+    t.Errorf("Expected filterError got %v", err)
+  }
+  stream.Close()
+}
+
+func TestDropWhileOptSkipErrors(t *testing.T) {
+  stream := DropWhileOpt(failOn(2, lessThan(4)), xrange(0, 7), SkipOnPredicateError)
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[4 5 6]" {
+    t.Errorf("Expected [4 5 6] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
 func TestAny(t *testing.T) {
   a := Any(equal(1), equal(2))
   b := Any()
@@ -1016,6 +1146,79 @@ func TestFastComposeCompose(t *testing.T) {
   }
 }
 
+func TestComposeAll(t *testing.T) {
+  m := ComposeAll(
+      []Creater{
+          func() interface{} { return new(int64) },
+          func() interface{} { return new(int32) }},
+      int64Plus1, doubleInt32Int64, squareIntInt32)
+  stream := Map(m, xrange(3, 6), new(int))
+  results, err := toInt64Array(stream)
+  if output := fmt.Sprintf("%v", results); output != "[19 33 51]" {
+    t.Errorf("Expected [19 33 51] got %v", output)
+  }
+  verifyDone(t, stream, new(int64), err)
+  if output := len(m.pieces()); output != 3 {
+    t.Errorf("Expected 3 pieces, got %v", output)
+  }
+}
+
+func TestComposeAllEmpty(t *testing.T) {
+  if output := ComposeAll(nil).Map(ptrInt(0), new(int)); output != Skipped {
+    t.Errorf("Expected Skipped got %v", output)
+  }
+}
+
+func TestFastComposeAll(t *testing.T) {
+  m := FastComposeAll(
+      []interface{}{new(int64), new(int32)},
+      int64Plus1, doubleInt32Int64, squareIntInt32)
+  stream := Map(m, xrange(3, 6), new(int))
+  results, err := toInt64Array(stream)
+  if output := fmt.Sprintf("%v", results); output != "[19 33 51]" {
+    t.Errorf("Expected [19 33 51] got %v", output)
+  }
+  verifyDone(t, stream, new(int64), err)
+}
+
+func TestComposeAllBadLength(t *testing.T) {
+  defer func() {
+    if recover() == nil {
+      t.Error("Expected panic.")
+    }
+  }()
+  FastComposeAll(nil, int64Plus1, int64Plus1)
+}
+
+func TestPooledCompose(t *testing.T) {
+  m := PooledCompose(doubleInt32Int64, squareIntInt32, newInt32)
+  var wg sync.WaitGroup
+  errs := make([]error, 4)
+  for i := range errs {
+    wg.Add(1)
+    go func(i int) {
+      defer wg.Done()
+      for j := 0; j < 100; j++ {
+        var result int64
+        if err := m.Map(ptrInt(j), &result); err != nil {
+          errs[i] = err
+          return
+        }
+        if result != 2 * int64(j) * int64(j) {
+          errs[i] = fmt.Errorf("Expected %v, got %v", 2 * j * j, result)
+          return
+        }
+      }
+    }(i)
+  }
+  wg.Wait()
+  for _, err := range errs {
+    if err != nil {
+      t.Error(err)
+    }
+  }
+}
+
 func TestNoCloseStream(t *testing.T) {
   s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
   stream := Slice(NoCloseStream(s), 0, 3)
@@ -1047,6 +1250,96 @@ func TestNoCloseReader(t *testing.T) {
   }
 }
 
+func TestNewBoolFilterer(t *testing.T) {
+  f := NewBoolFilterer(func(ptr interface{}) bool {
+    return *ptr.(*int) % 3 == 0
+  })
+  stream := Filter(f, xrange(0, 10))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 3 6 9]" {
+    t.Errorf("Expected [0 3 6 9] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestNewValueMapper(t *testing.T) {
+  m := NewValueMapper(
+      func(srcPtr interface{}) (interface{}, bool) {
+        x := *srcPtr.(*int)
+        return fmt.Sprintf("#%d", x), x % 2 == 0
+      },
+      nil)
+  stream := Map(m, xrange(0, 5), new(int))
+  results, err := toStringArray(stream)
+  if output := strings.Join(results, ","); output != "#0,#2,#4" {
+    t.Errorf("Expected #0,#2,#4 got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestNewValueMapperNil(t *testing.T) {
+  m := NewValueMapper(
+      func(srcPtr interface{}) (interface{}, bool) {
+        if *srcPtr.(*int) == 1 {
+          return nil, true
+        }
+        return []int{*srcPtr.(*int)}, true
+      },
+      nil)
+  stream := Map(m, xrange(0, 3), new(int))
+  var x []int
+  var results [][]int
+  var err error
+  for err = stream.Next(&x); err == nil; err = stream.Next(&x) {
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[[0] [] [2]]" || results[1] != nil {
+    t.Errorf("Expected [[0] [] [2]] got %v", output)
+  }
+  verifyDone(t, stream, &x, err)
+}
+
+func TestNewValueMapperWithCopier(t *testing.T) {
+  m := NewValueMapper(
+      func(srcPtr interface{}) (interface{}, bool) {
+        return *srcPtr.(*int) + 1, true
+      },
+      squareIntCopier)
+  stream := Map(m, xrange(0, 3), new(int))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[1 4 9]" {
+    t.Errorf("Expected [1 4 9] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestNoCloseWriter(t *testing.T) {
+  var b strings.Builder
+  w := &writerCloseChecker{&b, &simpleCloseChecker{}}
+  if _, ok := NoCloseWriter(w).(io.Closer); ok {
+    t.Error("Expected writer not to implement io.Closer.")
+  }
+  if output := NoCloseWriter(&b); output != io.Writer(&b) {
+    t.Error("Expected writer that cannot close to be returned unchanged.")
+  }
+}
+
+func TestNoClose(t *testing.T) {
+  rows := &rowsCloseChecker{&fakeRows{}, &simpleCloseChecker{}}
+  if _, ok := NoClose(rows).(io.Closer); ok {
+    t.Error("Expected rows not to implement io.Closer.")
+  }
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  NoClose(s).(Stream).Close()
+  if s.closeCalled() {
+    t.Error("Did not expect close to be called on stream.")
+  }
+  x := 3
+  if NoClose(x) != 3 {
+    t.Error("Expected value returned unchanged.")
+  }
+}
+
 func verifyDupClose(t *testing.T, c io.Closer) {
   closeVerifyResult(t, c, nil)
   closeVerifyResult(t, c, nil)
@@ -1133,6 +1426,38 @@ func (f *fakeRows) Scan(args ...interface{}) error {
   return nil
 }
 
+// nameIdRows has columns name, extra, id.
+type nameIdRows struct {
+  names []string
+  extra []float64
+  ids []int
+  idx int
+}
+
+func (f *nameIdRows) Next() bool {
+  if f.idx == len(f.ids) {
+    return false
+  }
+  f.idx++
+  return true
+}
+
+func (f *nameIdRows) Scan(args ...interface{}) error {
+  *args[0].(*string) = f.names[f.idx - 1]
+  *args[1].(*interface{}) = f.extra[f.idx - 1]
+  *args[2].(*int) = f.ids[f.idx - 1]
+  return nil
+}
+
+type fakeRowsWithErr struct {
+  *fakeRows
+  err error
+}
+
+func (f *fakeRowsWithErr) Err() error {
+  return f.err
+}
+
 type fakeRowsError struct {}
 
 func (f fakeRowsError) Next() bool {
@@ -1167,6 +1492,16 @@ type rowsCloseChecker struct {
   closeChecker
 }
 
+type writerCloseChecker struct {
+  io.Writer
+  closeChecker
+}
+
+type errRowsCloseChecker struct {
+  *fakeRowsWithErr
+  closeChecker
+}
+
 type readerCloseChecker struct {
   io.Reader
   closeChecker
@@ -1305,6 +1640,15 @@ func equal(x int) Filterer {
   })
 }
 
+func failOn(x int, f Filterer) Filterer {
+  return NewFilterer(func(ptr interface{}) error {
+    if *ptr.(*int) == x {
+      return filterError
+    }
+    return f.Filter(ptr)
+  })
+}
+
 func ptrInt(x int) *int {
   return &x
 }
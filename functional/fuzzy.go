@@ -0,0 +1,175 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// FuzzyMatch is a value emitted by FuzzyJoin. Left is a *T from the left
+// Stream, Right is a *U from the right Stream. When an element has no
+// match, the other field is nil.
+type FuzzyMatch struct {
+  Left interface{}
+  Right interface{}
+  Similarity float64
+}
+
+// FuzzyJoin returns a Stream of FuzzyMatch pairing each T value in left, a
+// Stream of T, with the most similar not yet matched U value in right, a
+// Stream of U. similarity takes a *T and a *U and returns how similar they
+// are; a pair matches only if its similarity is at least threshold. Left
+// values with no match are emitted as they are read with a nil Right; once
+// left is exhausted, right values that matched nothing are emitted with a
+// nil Left. FuzzyJoin reads all of right into memory the first time
+// Next is called. leftCreater and rightCreater allocate a new *T and *U
+// for each value read so that emitted pointers remain valid. Calling
+// Close on returned Stream closes both left and right.
+func FuzzyJoin(
+    left Stream,
+    leftCreater Creater,
+    right Stream,
+    rightCreater Creater,
+    similarity func(leftPtr, rightPtr interface{}) float64,
+    threshold float64) Stream {
+  return &fuzzyJoinStream{
+      left: left,
+      leftCreater: leftCreater,
+      right: right,
+      rightCreater: rightCreater,
+      similarity: similarity,
+      threshold: threshold}
+}
+
+// Levenshtein returns the minimum number of single character insertions,
+// deletions, and substitutions needed to change a into b.
+func Levenshtein(a, b string) int {
+  ar, br := []rune(a), []rune(b)
+  prev := make([]int, len(br) + 1)
+  curr := make([]int, len(br) + 1)
+  for j := range prev {
+    prev[j] = j
+  }
+  for i := 1; i <= len(ar); i++ {
+    curr[0] = i
+    for j := 1; j <= len(br); j++ {
+      cost := 1
+      if ar[i - 1] == br[j - 1] {
+        cost = 0
+      }
+      curr[j] = minInt(
+          minInt(prev[j] + 1, curr[j - 1] + 1), prev[j - 1] + cost)
+    }
+    prev, curr = curr, prev
+  }
+  return prev[len(br)]
+}
+
+// LevenshteinSimilarity returns a similarity between 0.0 and 1.0 of a and
+// b based on their Levenshtein distance. Identical strings have a
+// similarity of 1.0.
+func LevenshteinSimilarity(a, b string) float64 {
+  maxLen := len([]rune(a))
+  if l := len([]rune(b)); l > maxLen {
+    maxLen = l
+  }
+  if maxLen == 0 {
+    return 1.0
+  }
+  return 1.0 - float64(Levenshtein(a, b)) / float64(maxLen)
+}
+
+type fuzzyJoinStream struct {
+  left Stream
+  leftCreater Creater
+  right Stream
+  rightCreater Creater
+  similarity func(leftPtr, rightPtr interface{}) float64
+  threshold float64
+  rights []interface{}
+  matched []bool
+  loaded bool
+  leftDone bool
+  idx int
+}
+
+func (s *fuzzyJoinStream) Next(ptr interface{}) error {
+  if !s.loaded {
+    if err := s.load(); err != nil {
+      return err
+    }
+  }
+  p := ptr.(*FuzzyMatch)
+  if !s.leftDone {
+    leftPtr := s.leftCreater()
+    err := s.left.Next(leftPtr)
+    if err == nil {
+      *p = s.bestMatch(leftPtr)
+      return nil
+    }
+    if err != Done {
+      return err
+    }
+    s.leftDone = true
+  }
+  for ; s.idx < len(s.rights); s.idx++ {
+    if !s.matched[s.idx] {
+      *p = FuzzyMatch{Right: s.rights[s.idx]}
+      s.idx++
+      return nil
+    }
+  }
+  return Done
+}
+
+func (s *fuzzyJoinStream) Close() error {
+  result := s.left.Close()
+  if err := s.right.Close(); result == nil {
+    result = err
+  }
+  return result
+}
+
+func (s *fuzzyJoinStream) load() error {
+  for {
+    rightPtr := s.rightCreater()
+    err := s.right.Next(rightPtr)
+    if err == Done {
+      break
+    }
+    if err != nil {
+      return err
+    }
+    s.rights = append(s.rights, rightPtr)
+  }
+  s.matched = make([]bool, len(s.rights))
+  s.loaded = true
+  return nil
+}
+
+func (s *fuzzyJoinStream) bestMatch(leftPtr interface{}) FuzzyMatch {
+  best := -1
+  var bestSimilarity float64
+  for i := range s.rights {
+    if s.matched[i] {
+      continue
+    }
+    sim := s.similarity(leftPtr, s.rights[i])
+    if sim >= s.threshold && (best == -1 || sim > bestSimilarity) {
+      best = i
+      bestSimilarity = sim
+    }
+  }
+  if best == -1 {
+    return FuzzyMatch{Left: leftPtr}
+  }
+  s.matched[best] = true
+  return FuzzyMatch{
+      Left: leftPtr, Right: s.rights[best], Similarity: bestSimilarity}
+}
+
+func minInt(a, b int) int {
+  if a < b {
+    return a
+  }
+  return b
+}
@@ -0,0 +1,94 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestLevenshtein(t *testing.T) {
+  verifyLevenshtein(t, "kitten", "sitting", 3)
+  verifyLevenshtein(t, "", "abc", 3)
+  verifyLevenshtein(t, "abc", "", 3)
+  verifyLevenshtein(t, "same", "same", 0)
+  verifyLevenshtein(t, "héllo", "hello", 1)
+}
+
+func TestLevenshteinSimilarity(t *testing.T) {
+  if output := LevenshteinSimilarity("", ""); output != 1.0 {
+    t.Errorf("Expected 1.0, got %v", output)
+  }
+  if output := LevenshteinSimilarity("abcd", "abxd"); output != 0.75 {
+    t.Errorf("Expected 0.75, got %v", output)
+  }
+}
+
+func TestFuzzyJoin(t *testing.T) {
+  left := NewStreamFromValues(
+      []string{"Jon Smith", "Alice Jones", "Bob Brown"}, nil)
+  right := NewStreamFromValues(
+      []string{"Alyce Jones", "Carl White", "John Smith"}, nil)
+  stream := FuzzyJoin(
+      left, newString, right, newString, stringSimilarity, 0.8)
+  var results []string
+  var m FuzzyMatch
+  err := stream.Next(&m)
+  for ; err == nil; err = stream.Next(&m) {
+    results = append(results, fmt.Sprintf("%s|%s", fuzzyStr(m.Left), fuzzyStr(m.Right)))
+  }
+  verifyDone(t, stream, new(FuzzyMatch), err)
+  expected := "[Jon Smith|John Smith Alice Jones|Alyce Jones Bob Brown|- -|Carl White]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v got %v", expected, output)
+  }
+}
+
+func TestFuzzyJoinMatchesOnce(t *testing.T) {
+  left := NewStreamFromValues([]string{"abc", "abc"}, nil)
+  right := NewStreamFromValues([]string{"abc"}, nil)
+  stream := FuzzyJoin(
+      left, newString, right, newString, stringSimilarity, 0.5)
+  var first, second FuzzyMatch
+  stream.Next(&first)
+  stream.Next(&second)
+  if first.Right == nil || second.Right != nil {
+    t.Error("Expected only the first left value to match")
+  }
+  if first.Similarity != 1.0 {
+    t.Errorf("Expected similarity 1.0, got %v", first.Similarity)
+  }
+}
+
+func TestFuzzyJoinClose(t *testing.T) {
+  left := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  right := &streamCloseChecker{NilStream(), &simpleCloseChecker{closeError: closeError}}
+  stream := FuzzyJoin(
+      left, newString, right, newString, stringSimilarity, 0.5)
+  closeVerifyResult(t, stream, closeError)
+  verifyCloseCalled(t, left, right)
+}
+
+func verifyLevenshtein(t *testing.T, a, b string, expected int) {
+  if output := Levenshtein(a, b); output != expected {
+    t.Errorf("Expected %d for %q, %q, got %d", expected, a, b, output)
+  }
+}
+
+func stringSimilarity(leftPtr, rightPtr interface{}) float64 {
+  return LevenshteinSimilarity(*leftPtr.(*string), *rightPtr.(*string))
+}
+
+func newString() interface{} {
+  return new(string)
+}
+
+func fuzzyStr(ptr interface{}) string {
+  if ptr == nil {
+    return "-"
+  }
+  return *ptr.(*string)
+}
@@ -43,10 +43,14 @@ func NewGenerator(f func(e Emitter)) Stream {
 // f returns to the caller.
 // This function is draft API and may change in incompatible ways.
 func NewGeneratorCloseMayFail(f func(e Emitter) error) Stream {
-  result := &regularGenerator{emitterStream: emitterStream{ptrCh: make(chan interface{}), errCh: make(chan error)}}
+  result := &regularGenerator{
+      emitterStream: emitterStream{ptrCh: make(chan interface{}), errCh: make(chan error)},
+      metric: trackOpen("NewGenerator")}
+  exited := trackGenerator()
   go func() {
     var err error
     defer func() {
+      exited()
       result.endEmitter(err)
     }()
     result.startEmitter()
@@ -72,6 +76,7 @@ func EmitAll(s Stream, e Emitter) error {
 type regularGenerator struct {
   emitterStream
   closeResult error
+  metric openMetric
 }
 
 func (s *regularGenerator) Return(err error) {
@@ -82,24 +87,16 @@ func (s *regularGenerator) Return(err error) {
 }
 
 func (s *regularGenerator) Next(ptr interface{}) error {
-  if s.isClosed() {
-    return Done
-  }
-  result := s.emitterStream.Next(ptr)
-  if result == Done {
-    s.closeResult = <-s.errCh
-    s.close()
-    return finish(s.closeResult)
-  }
-  return result
+  return countEmitted(s.next(ptr))
 }
 
 func (s *regularGenerator) Close() error {
   if s.isClosed() {
     return s.closeResult
   }
-  result := s.Next(nil)
+  result := s.next(nil)
   if !s.isClosed() {
+    s.metric.release()
     return errors.New("Emitting function did not return on Close.")
   }
   if result == Done {
@@ -107,3 +104,19 @@ func (s *regularGenerator) Close() error {
   }
   return result
 }
+
+// next works like Next but does not count emitted values so that Close
+// can use it.
+func (s *regularGenerator) next(ptr interface{}) error {
+  if s.isClosed() {
+    return Done
+  }
+  result := s.emitterStream.Next(ptr)
+  if result == Done {
+    s.closeResult = <-s.errCh
+    s.close()
+    s.metric.release()
+    return finish(s.closeResult)
+  }
+  return result
+}
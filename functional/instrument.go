@@ -0,0 +1,240 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "log"
+  "reflect"
+  "time"
+)
+
+// Positioned is implemented by Streams that know how many values they have
+// emitted, such as the Streams Count, CountFrom, Slice, NewStreamFromValues,
+// NewStreamFromPtrs, ReadLines, and Progress return. It lets consumers
+// report where they stopped for error messages or to resume later.
+type Positioned interface {
+  // Position returns the number of values emitted so far which is also
+  // the 0-based index of the next value to be emitted.
+  Position() int
+}
+
+// PositionOf returns the position of s and true if s implements
+// Positioned; otherwise it returns 0 and false.
+func PositionOf(s Stream) (int, bool) {
+  if p, ok := s.(Positioned); ok {
+    return p.Position(), true
+  }
+  return 0, false
+}
+
+// Offsetter is implemented by Streams that read from an io.Reader and
+// know how far into it they are, such as the Stream ReadLines returns.
+type Offsetter interface {
+  // Offset returns the number of bytes of the io.Reader consumed by the
+  // values emitted so far. Seeking a fresh reader of the same data to
+  // this offset resumes with the next value.
+  Offset() int64
+}
+
+// OffsetOf returns the offset of s and true if s implements Offsetter;
+// otherwise it returns 0 and false.
+func OffsetOf(s Stream) (int64, bool) {
+  if o, ok := s.(Offsetter); ok {
+    return o.Offset(), true
+  }
+  return 0, false
+}
+
+// Progress returns a Stream that emits the same values as s but calls
+// report each time every values have been emitted passing the number of
+// values emitted so far. When the end of s is reached, Progress calls
+// report one last time with the total number of values emitted unless
+// that total was just reported. If every is less than 1, report is called
+// only when the end of s is reached. The returned Stream implements
+// Positioned. Calling Close on returned Stream closes s.
+func Progress(s Stream, every int, report func(count int)) Stream {
+  return &progressStream{Stream: s, every: every, report: report}
+}
+
+type progressStream struct {
+  Stream
+  every int
+  report func(count int)
+  count int
+  done bool
+}
+
+func (s *progressStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  err := s.Stream.Next(ptr)
+  if err == nil {
+    s.count++
+    if s.every > 0 && s.count % s.every == 0 {
+      s.report(s.count)
+    }
+  } else if err == Done {
+    s.done = true
+    if s.every <= 0 || s.count % s.every != 0 || s.count == 0 {
+      s.report(s.count)
+    }
+  }
+  return err
+}
+
+func (s *progressStream) Position() int {
+  return s.count
+}
+
+// SkipCounter reports how many values a Filterer or Mapper skipped.
+type SkipCounter struct {
+  count int
+}
+
+// Count returns the number of values skipped so far.
+func (c *SkipCounter) Count() int {
+  return c.count
+}
+
+// FilterCounted works like Filter but also returns a SkipCounter that
+// counts the values f skips.
+func FilterCounted(f Filterer, s Stream) (Stream, *SkipCounter) {
+  counter := &SkipCounter{}
+  return Filter(&skipCountingFilterer{f: f, counter: counter}, s), counter
+}
+
+// MapCounted works like Map but also returns a SkipCounter that counts
+// the values f skips.
+func MapCounted(f Mapper, s Stream, ptr interface{}) (Stream, *SkipCounter) {
+  counter := &SkipCounter{}
+  return Map(&skipCountingMapper{f: f, counter: counter}, s, ptr), counter
+}
+
+type skipCountingFilterer struct {
+  f Filterer
+  counter *SkipCounter
+}
+
+func (f *skipCountingFilterer) Filter(ptr interface{}) error {
+  err := f.f.Filter(ptr)
+  if err == Skipped {
+    f.counter.count++
+  }
+  return err
+}
+
+type skipCountingMapper struct {
+  f Mapper
+  counter *SkipCounter
+}
+
+func (m *skipCountingMapper) Map(srcPtr, destPtr interface{}) error {
+  err := m.f.Map(srcPtr, destPtr)
+  if err == Skipped {
+    m.counter.count++
+  }
+  return err
+}
+
+// SlowReporter is called with the value a stage was working on and how long
+// the stage took whenever a single successful call exceeds a threshold.
+// Calls that return an error, including Done and Skipped, are never
+// reported since there is no value to blame. A nil return
+// lets the pipeline continue; a non-nil return becomes the error of that
+// call, letting a pipeline fail on pathological values instead of just
+// noting them.
+type SlowReporter func(ptr interface{}, d time.Duration) error
+
+// LogSlow returns a SlowReporter that logs slow values with the standard
+// log package prefixing each message with name. It never returns an error.
+func LogSlow(name string) SlowReporter {
+  return func(ptr interface{}, d time.Duration) error {
+    log.Printf("%s: slow value took %v: %v", name, d, valueOf(ptr))
+    return nil
+  }
+}
+
+// SlowStream returns a Stream that emits the same values as s but calls
+// report each time a single call to Next on s takes longer than threshold.
+// report gets the pointer passed to Next. If report returns an error,
+// Next returns that error instead. Calling Close on returned Stream
+// closes s.
+func SlowStream(s Stream, threshold time.Duration, report SlowReporter) Stream {
+  return &slowStream{Stream: s, threshold: threshold, report: report}
+}
+
+// SlowMapper returns a Mapper that works like m but calls report each
+// time a single call to m takes longer than threshold. report gets the
+// source pointer. If report returns an error, the returned Mapper returns
+// that error instead.
+func SlowMapper(m Mapper, threshold time.Duration, report SlowReporter) Mapper {
+  return &slowMapper{m: m, threshold: threshold, report: report}
+}
+
+// SlowFilterer works like SlowMapper but for Filterers.
+func SlowFilterer(f Filterer, threshold time.Duration, report SlowReporter) Filterer {
+  return &slowFilterer{f: f, threshold: threshold, report: report}
+}
+
+type slowStream struct {
+  Stream
+  threshold time.Duration
+  report SlowReporter
+}
+
+func (s *slowStream) Next(ptr interface{}) error {
+  start := time.Now()
+  err := s.Stream.Next(ptr)
+  return checkSlow(ptr, start, s.threshold, s.report, err)
+}
+
+type slowMapper struct {
+  m Mapper
+  threshold time.Duration
+  report SlowReporter
+}
+
+func (m *slowMapper) Map(srcPtr, destPtr interface{}) error {
+  start := time.Now()
+  err := m.m.Map(srcPtr, destPtr)
+  return checkSlow(srcPtr, start, m.threshold, m.report, err)
+}
+
+type slowFilterer struct {
+  f Filterer
+  threshold time.Duration
+  report SlowReporter
+}
+
+func (f *slowFilterer) Filter(ptr interface{}) error {
+  start := time.Now()
+  err := f.f.Filter(ptr)
+  return checkSlow(ptr, start, f.threshold, f.report, err)
+}
+
+func checkSlow(
+    ptr interface{},
+    start time.Time,
+    threshold time.Duration,
+    report SlowReporter,
+    err error) error {
+  if err != nil {
+    return err
+  }
+  if d := time.Since(start); d > threshold {
+    return report(ptr, d)
+  }
+  return nil
+}
+
+func valueOf(ptr interface{}) interface{} {
+  v := reflect.ValueOf(ptr)
+  if v.Kind() == reflect.Ptr && !v.IsNil() {
+    return v.Elem().Interface()
+  }
+  return ptr
+}
@@ -0,0 +1,228 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "bytes"
+    "fmt"
+    "log"
+    "os"
+    "strings"
+    "testing"
+    "time"
+)
+
+func TestProgress(t *testing.T) {
+  var reported []int
+  stream := Progress(xrange(0, 7), 3, func(count int) {
+    reported = append(reported, count)
+  })
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4 5 6]" {
+    t.Errorf("Expected [0 1 2 3 4 5 6] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  if output := fmt.Sprintf("%v", reported); output != "[3 6 7]" {
+    t.Errorf("Expected [3 6 7] got %v", output)
+  }
+}
+
+func TestProgressNoDupAtDone(t *testing.T) {
+  var reported []int
+  stream := Progress(xrange(0, 6), 3, func(count int) {
+    reported = append(reported, count)
+  })
+  _, err := toIntArray(stream)
+  verifyDone(t, stream, new(int), err)
+  if output := fmt.Sprintf("%v", reported); output != "[3 6]" {
+    t.Errorf("Expected [3 6] got %v", output)
+  }
+}
+
+func TestProgressOnlyAtDone(t *testing.T) {
+  var reported []int
+  stream := Progress(NilStream(), 0, func(count int) {
+    reported = append(reported, count)
+  })
+  _, err := toIntArray(stream)
+  verifyDone(t, stream, new(int), err)
+  if output := fmt.Sprintf("%v", reported); output != "[0]" {
+    t.Errorf("Expected [0] got %v", output)
+  }
+}
+
+func TestProgressClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := Progress(s, 1, func(count int) {})
+  stream.Close()
+  verifyCloseCalled(t, s)
+}
+
+func TestFilterCounted(t *testing.T) {
+  stream, counter := FilterCounted(lessThan(3), xrange(0, 7))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  if output := counter.Count(); output != 4 {
+    t.Errorf("Expected 4 skipped, got %v", output)
+  }
+}
+
+func TestMapCounted(t *testing.T) {
+  m := NewMapper(func(srcPtr, destPtr interface{}) error {
+    x := *srcPtr.(*int)
+    if x % 2 == 1 {
+      return Skipped
+    }
+    *destPtr.(*int) = x * 10
+    return nil
+  })
+  stream, counter := MapCounted(m, xrange(0, 5), new(int))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 20 40]" {
+    t.Errorf("Expected [0 20 40] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  if output := counter.Count(); output != 2 {
+    t.Errorf("Expected 2 skipped, got %v", output)
+  }
+}
+
+func TestPositioned(t *testing.T) {
+  verifyPosition(t, Count(), 3, 3)
+  verifyPosition(t, CountFrom(5, 2), 2, 2)
+  verifyPosition(t, Slice(Count(), 2, 10), 3, 3)
+  verifyPosition(t, Slice(Count(), 2, 4), 5, 2)
+  verifyPosition(t, NewStreamFromValues([]int{4, 5, 6}, nil), 2, 2)
+  verifyPosition(t, NewStreamFromPtrs([]*int{ptrInt(4), ptrInt(5)}, nil), 1, 1)
+  verifyPosition(t, Progress(Filter(greaterThan(2), Count()), 0, func(int) {}), 4, 4)
+  if _, ok := PositionOf(Filter(greaterThan(2), Count())); ok {
+    t.Error("Expected Filter stream not to be Positioned")
+  }
+}
+
+func verifyPosition(t *testing.T, s Stream, reads int, expected int) {
+  var x int
+  for i := 0; i < reads; i++ {
+    s.Next(&x)
+  }
+  position, ok := PositionOf(s)
+  if !ok || position != expected {
+    t.Errorf("Expected position %d, got %d %v", expected, position, ok)
+  }
+}
+
+func TestSlowMapperAndFilterer(t *testing.T) {
+  var slow []int
+  report := func(ptr interface{}, d time.Duration) error {
+    slow = append(slow, *ptr.(*int))
+    if d < 20 * time.Millisecond {
+      t.Errorf("Expected at least 20ms, got %v", d)
+    }
+    return nil
+  }
+  sleepOn := func(x int) Mapper {
+    return NewMapper(func(srcPtr interface{}, destPtr interface{}) error {
+      if *srcPtr.(*int) == x {
+        time.Sleep(20 * time.Millisecond)
+      }
+      *destPtr.(*int) = *srcPtr.(*int)
+      return nil
+    })
+  }
+  stream := Map(SlowMapper(sleepOn(2), 10 * time.Millisecond, report), xrange(0, 4), new(int))
+  f := SlowFilterer(NewFilterer(func(ptr interface{}) error {
+    return sleepOn(1).Map(ptr, new(int))
+  }), 10 * time.Millisecond, report)
+  results, _ := toIntArray(Filter(f, stream))
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3]" {
+    t.Errorf("Expected [0 1 2 3] got %v", output)
+  }
+  if output := fmt.Sprintf("%v", slow); output != "[1 2]" {
+    t.Errorf("Expected [1 2] got %v", output)
+  }
+}
+
+func TestSlowStreamError(t *testing.T) {
+  var buf bytes.Buffer
+  log.SetOutput(&buf)
+  defer log.SetOutput(os.Stderr)
+  s := NewGenerator(func(e Emitter) {
+    for i := 0; i < 3; i++ {
+      ptr := e.EmitPtr()
+      if ptr == nil {
+        return
+      }
+      if i == 1 {
+        time.Sleep(20 * time.Millisecond)
+      }
+      *ptr.(*int) = i
+      e.Return(nil)
+    }
+  })
+  logSlow := LogSlow("source")
+  stream := SlowStream(s, 10 * time.Millisecond, func(ptr interface{}, d time.Duration) error {
+    logSlow(ptr, d)
+    return filterError
+  })
+  var x int
+  if err := stream.Next(&x); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if err := stream.Next(&x); err != filterError {
+    t.Errorf("Expected filterError, got %v", err)
+  }
+  if output := buf.String(); !strings.Contains(output, "source: slow value took ") || !strings.HasSuffix(output, ": 1\n") {
+    t.Errorf("Expected log of slow value, got %v", output)
+  }
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestSlowStreamDoneAndErrors(t *testing.T) {
+  reported := 0
+  report := func(ptr interface{}, d time.Duration) error {
+    reported++
+    return filterError
+  }
+  s := SlowStream(NilStream(), -time.Second, report)
+  if err := s.Next(new(int)); err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  m := SlowMapper(errMapper, -time.Second, report)
+  if err := m.Map(new(int), new(int)); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+  if reported != 0 {
+    t.Errorf("Expected no reports, got %d", reported)
+  }
+}
+
+func TestReadLinesOffset(t *testing.T) {
+  text := "\ufeffab\r\ncde\n\nlast"
+  s := ReadLines(strings.NewReader(text))
+  var line string
+  var offsets []int64
+  for s.Next(&line) == nil {
+    offset, _ := OffsetOf(s)
+    offsets = append(offsets, offset)
+  }
+  if output := fmt.Sprintf("%v", offsets); output != "[7 11 12 16]" {
+    t.Errorf("Expected [7 11 12 16] got %v", output)
+  }
+  if pos, ok := PositionOf(s); pos != 4 || !ok {
+    t.Errorf("Expected 4 lines, got %v", pos)
+  }
+  resumed := ReadLines(strings.NewReader(text[offsets[0]:]))
+  results, _ := toStringArray(resumed)
+  if output := fmt.Sprintf("%q", results); output != `["cde" "" "last"]` {
+    t.Errorf("Expected cde, empty, last got %v", output)
+  }
+  if _, ok := OffsetOf(Count()); ok {
+    t.Error("Expected Count not to implement Offsetter.")
+  }
+}
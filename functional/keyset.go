@@ -0,0 +1,67 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+// ReadKeysetPages returns a Stream of Tuple that emits the rows of a
+// keyset paginated query one page after another. factory runs the query
+// for the page of rows whose keys come after afterKey; afterKey is nil
+// for the first page. key returns the key of the Tuple ptr points to and
+// is used to remember the key of the last row of each page. Unlike using
+// Slice over ReadRows with OFFSET, each query reads only its own page.
+// If pageSize is greater than 0, a page with fewer than pageSize rows is
+// taken to be the last; otherwise pages are read until one is empty.
+// Errors from factory are reported through Next. Calling Close on returned
+// Stream closes the Rows of the current page if they implement io.Closer.
+func ReadKeysetPages(
+    factory func(afterKey interface{}) (Rows, error),
+    key func(ptr interface{}) interface{},
+    pageSize int) Stream {
+  return &keysetStream{factory: factory, key: key, pageSize: pageSize}
+}
+
+type keysetStream struct {
+  factory func(afterKey interface{}) (Rows, error)
+  key func(ptr interface{}) interface{}
+  pageSize int
+  current Stream
+  lastKey interface{}
+  count int
+  done bool
+}
+
+func (s *keysetStream) Next(ptr interface{}) error {
+  for !s.done {
+    if s.current == nil {
+      rows, err := s.factory(s.lastKey)
+      if err != nil {
+        return err
+      }
+      s.current = ReadRows(rows)
+      s.count = 0
+    }
+    err := s.current.Next(ptr)
+    if err == nil {
+      s.count++
+      s.lastKey = s.key(ptr)
+      return nil
+    }
+    if err != Done {
+      return err
+    }
+    s.current = nil
+    if s.count == 0 || (s.pageSize > 0 && s.count < s.pageSize) {
+      s.done = true
+    }
+  }
+  return Done
+}
+
+func (s *keysetStream) Close() error {
+  if s.current != nil {
+    return s.current.Close()
+  }
+  return nil
+}
@@ -0,0 +1,87 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestReadKeysetPages(t *testing.T) {
+  q := &keysetQuery{ids: []int{1, 2, 3, 4, 5}, limit: 2}
+  stream := ReadKeysetPages(q.run, intAndStringKey, 2)
+  results, err := toIntAndStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[{1 a} {2 a} {3 a} {4 a} {5 a}]" {
+    t.Errorf("Got %v", output)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+  if output := fmt.Sprintf("%v", q.afterKeys); output != "[<nil> 2 4]" {
+    t.Errorf("Expected [<nil> 2 4] got %v", output)
+  }
+}
+
+func TestReadKeysetPagesNoPageSize(t *testing.T) {
+  q := &keysetQuery{ids: []int{1, 2, 3, 4}, limit: 2}
+  stream := ReadKeysetPages(q.run, intAndStringKey, 0)
+  results, err := toIntAndStringArray(stream)
+  if len(results) != 4 {
+    t.Errorf("Expected 4 results, got %v", results)
+  }
+  verifyDone(t, stream, new(intAndString), err)
+  if output := fmt.Sprintf("%v", q.afterKeys); output != "[<nil> 2 4]" {
+    t.Errorf("Expected [<nil> 2 4] got %v", output)
+  }
+}
+
+func TestReadKeysetPagesError(t *testing.T) {
+  stream := ReadKeysetPages(
+      func(afterKey interface{}) (Rows, error) { return nil, scanError },
+      intAndStringKey,
+      2)
+  if _, err := toIntAndStringArray(stream); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+}
+
+func TestReadKeysetPagesClose(t *testing.T) {
+  var rows *rowsCloseChecker
+  stream := ReadKeysetPages(
+      func(afterKey interface{}) (Rows, error) {
+        rows = &rowsCloseChecker{
+            &fakeRows{ids: []int{1, 2}, names: []string{"a", "b"}},
+            &simpleCloseChecker{}}
+        return rows, nil
+      },
+      intAndStringKey,
+      2)
+  var x intAndString
+  stream.Next(&x)
+  stream.Close()
+  verifyCloseCalled(t, rows)
+}
+
+type keysetQuery struct {
+  ids []int
+  limit int
+  afterKeys []interface{}
+}
+
+func (q *keysetQuery) run(afterKey interface{}) (Rows, error) {
+  q.afterKeys = append(q.afterKeys, afterKey)
+  var ids []int
+  var names []string
+  for _, id := range q.ids {
+    if (afterKey == nil || id > afterKey.(int)) && len(ids) < q.limit {
+      ids = append(ids, id)
+      names = append(names, "a")
+    }
+  }
+  return &fakeRows{ids: ids, names: names}, nil
+}
+
+func intAndStringKey(ptr interface{}) interface{} {
+  return ptr.(*intAndString).id
+}
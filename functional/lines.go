@@ -0,0 +1,115 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+  "strings"
+)
+
+var (
+  // NonBlank is a Filterer of string that skips lines that are empty or
+  // contain only white space.
+  NonBlank Filterer = NewBoolFilterer(func(ptr interface{}) bool {
+    return strings.TrimSpace(*ptr.(*string)) != ""
+  })
+)
+
+// NotComment returns a Filterer of string that skips lines that start
+// with any of prefixes, ignoring leading white space.
+func NotComment(prefixes ...string) Filterer {
+  return NewBoolFilterer(func(ptr interface{}) bool {
+    line := strings.TrimSpace(*ptr.(*string))
+    for _, prefix := range prefixes {
+      if strings.HasPrefix(line, prefix) {
+        return false
+      }
+    }
+    return true
+  })
+}
+
+// LineOptions controls how ReadLinesOpt reads lines.
+type LineOptions struct {
+  // HeaderLines is the number of lines at the start to skip.
+  HeaderLines int
+  // If Header is non-nil, the skipped header lines are stored in it
+  // the first time Next is called.
+  Header *[]string
+  // FooterLines is the number of lines at the end to skip. Skipping them
+  // requires reading FooterLines lines ahead.
+  FooterLines int
+  // SkipBlank, if true, skips lines that are empty or contain only
+  // white space.
+  SkipBlank bool
+  // CommentPrefixes lists prefixes that mark lines to skip, e.g "#".
+  CommentPrefixes []string
+  // Decoder, if non-nil, converts the text to UTF-8 before it is split
+  // into lines.
+  Decoder Decoder
+}
+
+// ReadLinesOpt works like ReadLines but reads lines as opts directs.
+// Header and footer lines are counted before blank and comment lines
+// are skipped.
+func ReadLinesOpt(r io.Reader, opts LineOptions) Stream {
+  if opts.Decoder != nil {
+    r = decode(r, opts.Decoder)
+  }
+  result := Stream(&lineOptStream{Stream: ReadLines(r), opts: opts})
+  if opts.SkipBlank {
+    result = Filter(NonBlank, result)
+  }
+  if len(opts.CommentPrefixes) > 0 {
+    result = Filter(NotComment(opts.CommentPrefixes...), result)
+  }
+  return result
+}
+
+type lineOptStream struct {
+  Stream
+  opts LineOptions
+  started bool
+  ahead []string
+}
+
+func (s *lineOptStream) Next(ptr interface{}) error {
+  if !s.started {
+    s.started = true
+    if err := s.skipHeader(); err != nil {
+      return err
+    }
+  }
+  for len(s.ahead) <= s.opts.FooterLines {
+    var line string
+    if err := s.Stream.Next(&line); err != nil {
+      return err
+    }
+    s.ahead = append(s.ahead, line)
+  }
+  *ptr.(*string) = s.ahead[0]
+  s.ahead = s.ahead[1:]
+  return nil
+}
+
+func (s *lineOptStream) skipHeader() error {
+  var header []string
+  for i := 0; i < s.opts.HeaderLines; i++ {
+    var line string
+    err := s.Stream.Next(&line)
+    if err == Done {
+      break
+    }
+    if err != nil {
+      return err
+    }
+    header = append(header, line)
+  }
+  if s.opts.Header != nil {
+    *s.opts.Header = header
+  }
+  return nil
+}
@@ -0,0 +1,78 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+func TestReadLinesOptHeaderFooter(t *testing.T) {
+  var header []string
+  r := strings.NewReader("h1\nh2\na\nb\nc\nf1\nf2\n")
+  stream := ReadLinesOpt(
+      r, LineOptions{HeaderLines: 2, Header: &header, FooterLines: 2})
+  results, err := toStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[a b c]" {
+    t.Errorf("Expected [a b c] got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+  if output := fmt.Sprintf("%v", header); output != "[h1 h2]" {
+    t.Errorf("Expected [h1 h2] got %v", output)
+  }
+}
+
+func TestReadLinesOptShort(t *testing.T) {
+  var header []string
+  stream := ReadLinesOpt(
+      strings.NewReader("h1\n"),
+      LineOptions{HeaderLines: 2, Header: &header, FooterLines: 1})
+  results, err := toStringArray(stream)
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+  verifyDone(t, stream, new(string), err)
+  if output := fmt.Sprintf("%v", header); output != "[h1]" {
+    t.Errorf("Expected [h1] got %v", output)
+  }
+  stream = ReadLinesOpt(
+      strings.NewReader("a\nb\n"), LineOptions{FooterLines: 2})
+  results, _ = toStringArray(stream)
+  if len(results) != 0 {
+    t.Errorf("Expected no results, got %v", results)
+  }
+}
+
+func TestReadLinesOptClose(t *testing.T) {
+  r := &readerCloseChecker{strings.NewReader("a\n"), &simpleCloseChecker{}}
+  stream := ReadLinesOpt(r, LineOptions{HeaderLines: 1})
+  stream.Close()
+  verifyCloseCalled(t, r)
+}
+
+func TestReadLinesOptBlankAndComments(t *testing.T) {
+  r := strings.NewReader("# header\na\n\n  // note\n   \nb\n;c\n")
+  stream := ReadLinesOpt(r, LineOptions{
+      HeaderLines: 1,
+      SkipBlank: true,
+      CommentPrefixes: []string{"//", ";"}})
+  results, err := toStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[a b]" {
+    t.Errorf("Expected [a b] got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+}
+
+func TestNonBlankAndNotComment(t *testing.T) {
+  stream := Filter(
+      All(NonBlank, NotComment("#")),
+      NewStreamFromValues([]string{"a", " ", "", " # x", "b#"}, nil))
+  results, _ := toStringArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[a b#]" {
+    t.Errorf("Expected [a b#] got %v", output)
+  }
+}
@@ -0,0 +1,88 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+)
+
+// MapperBuilder builds a CompositeMapper one stage at a time, checking that
+// the types of adjacent stages line up before any real data is mapped.
+// Stages are listed in the order they are applied, which is the reverse of
+// the order Compose and ComposeAll take them.
+type MapperBuilder struct {
+  src Creater
+  mappers []Mapper
+  creaters []Creater
+}
+
+// NewMapperBuilder returns a new MapperBuilder for a CompositeMapper that
+// maps T values. src is a Creater of T.
+func NewMapperBuilder(src Creater) *MapperBuilder {
+  return &MapperBuilder{src: src}
+}
+
+// Then appends a stage to b. m maps the values the previous stage produces
+// to U values; c is a Creater of U that supplies the intermediate values
+// between m and the next stage. Then returns b for chaining.
+func (b *MapperBuilder) Then(m Mapper, c Creater) *MapperBuilder {
+  b.mappers = append(b.mappers, m)
+  b.creaters = append(b.creaters, c)
+  return b
+}
+
+// Validate does a dry run of the stages in b. It calls each Mapper once
+// with freshly created values of the expected types and reports the first
+// stage that panics, typically because of a failed type assertion. Errors
+// that the Mappers return are ignored since Mappers often reject zero
+// values legitimately. Because Validate calls each Mapper, Mappers with
+// side effects see one extra call.
+func (b *MapperBuilder) Validate() (err error) {
+  stage := 0
+  defer func() {
+    if r := recover(); r != nil {
+      err = fmt.Errorf("functional: MapperBuilder stage %d: %v", stage, r)
+    }
+  }()
+  srcPtr := b.src()
+  for stage = range b.mappers {
+    destPtr := b.creaters[stage]()
+    b.mappers[stage].Map(srcPtr, destPtr)
+    srcPtr = destPtr
+  }
+  return nil
+}
+
+// Build validates b and returns the resulting CompositeMapper. The Creater
+// of the last stage is used only for validation.
+func (b *MapperBuilder) Build() (CompositeMapper, error) {
+  if err := b.Validate(); err != nil {
+    return CompositeMapper{}, err
+  }
+  l := len(b.mappers)
+  if l == 0 {
+    return CompositeMapper{}, nil
+  }
+  mappers := make([]Mapper, l)
+  creaters := make([]Creater, l - 1)
+  for i := range mappers {
+    mappers[i] = b.mappers[l - 1 - i]
+  }
+  for i := range creaters {
+    creaters[i] = b.creaters[l - 2 - i]
+  }
+  return ComposeAll(creaters, mappers...), nil
+}
+
+// BuildFast works like Build but returns the Fast version of the resulting
+// CompositeMapper.
+func (b *MapperBuilder) BuildFast() (Mapper, error) {
+  m, err := b.Build()
+  if err != nil {
+    return nil, err
+  }
+  return m.Fast(), nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "testing"
+)
+
+func TestMapperBuilder(t *testing.T) {
+  m, err := NewMapperBuilder(newInt).
+      Then(squareIntInt32, newInt32).
+      Then(doubleInt32Int64, newInt64).
+      Then(int64Plus1, newInt64).
+      BuildFast()
+  if err != nil {
+    t.Fatalf("Got error building: %v", err)
+  }
+  stream := Map(m, xrange(3, 6), new(int))
+  results, err := toInt64Array(stream)
+  if output := fmt.Sprintf("%v", results); output != "[19 33 51]" {
+    t.Errorf("Expected [19 33 51] got %v", output)
+  }
+  verifyDone(t, stream, new(int64), err)
+}
+
+func TestMapperBuilderMismatch(t *testing.T) {
+  _, err := NewMapperBuilder(newInt).
+      Then(squareIntInt32, newInt64).
+      Then(doubleInt32Int64, newInt64).
+      Build()
+  if err == nil {
+    t.Error("Expected type mismatch error.")
+  }
+}
+
+func TestMapperBuilderEmpty(t *testing.T) {
+  m, err := NewMapperBuilder(newInt).Build()
+  if err != nil {
+    t.Fatalf("Got error building: %v", err)
+  }
+  if output := m.Map(ptrInt(0), new(int)); output != Skipped {
+    t.Errorf("Expected Skipped got %v", output)
+  }
+}
+
+func newInt() interface{} {
+  return new(int)
+}
+
+func newInt32() interface{} {
+  return new(int32)
+}
+
+func newInt64() interface{} {
+  return new(int64)
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "expvar"
+  "sync"
+  "sync/atomic"
+)
+
+var (
+  metricsOnce sync.Once
+  metricsEnabled int32
+  openStreams = new(expvar.Map).Init()
+  generatorsAlive = new(expvar.Int)
+  elementsEmitted = new(expvar.Int)
+)
+
+// EnableMetrics turns on counting of the Streams this package opens
+// and publishes the counts through expvar under the name "functional".
+// The published map has "open_streams", the number of currently open
+// Streams by the function that created them; "generators", the number of
+// goroutines backing NewGenerator Streams that are still alive; and
+// "emitted", the total number of values emitted by those Streams. Only
+// the resource holding sources, ReadRows, ReadLines, and NewGenerator, are
+// counted. Streams created before EnableMetrics is called are never
+// counted. Calling EnableMetrics more than once has no further effect.
+func EnableMetrics() {
+  metricsOnce.Do(func() {
+    m := expvar.NewMap("functional")
+    m.Set("open_streams", openStreams)
+    m.Set("generators", generatorsAlive)
+    m.Set("emitted", elementsEmitted)
+    atomic.StoreInt32(&metricsEnabled, 1)
+  })
+}
+
+func metricsOn() bool {
+  return atomic.LoadInt32(&metricsEnabled) != 0
+}
+
+// openMetric counts a Stream as open from creation until its release
+// method is called.
+type openMetric struct {
+  kind string
+}
+
+func trackOpen(kind string) openMetric {
+  if !metricsOn() {
+    return openMetric{}
+  }
+  openStreams.Add(kind, 1)
+  return openMetric{kind: kind}
+}
+
+// release stops counting the Stream as open. Only the first call counts.
+func (m *openMetric) release() {
+  if m.kind != "" {
+    openStreams.Add(m.kind, -1)
+    m.kind = ""
+  }
+}
+
+// countEmitted counts a value as emitted if err is nil. It returns err.
+func countEmitted(err error) error {
+  if err == nil && metricsOn() {
+    elementsEmitted.Add(1)
+  }
+  return err
+}
+
+// trackGenerator counts a generator goroutine as alive and returns the
+// function to call when it exits.
+func trackGenerator() func() {
+  if !metricsOn() {
+    return func() {}
+  }
+  generatorsAlive.Add(1)
+  return func() { generatorsAlive.Add(-1) }
+}
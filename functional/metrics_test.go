@@ -0,0 +1,97 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "encoding/json"
+  "expvar"
+  "io"
+  "strings"
+  "testing"
+)
+
+func TestMetrics(t *testing.T) {
+  EnableMetrics()
+  EnableMetrics()
+  if expvar.Get("functional") == nil {
+    t.Fatal("Expected metrics to be published.")
+  }
+  emitted := elementsEmitted.Value()
+  lines := ReadLines(strings.NewReader("a\nb\n"))
+  gen := NewGenerator(func(e Emitter) {
+    for i := 0; i < 3; i++ {
+      ptr := e.EmitPtr()
+      if ptr == nil {
+        return
+      }
+      *ptr.(*int) = i
+      e.Return(nil)
+    }
+  })
+  verifyMetric(t, openStreams.Get("ReadLines"), 1)
+  verifyMetric(t, openStreams.Get("NewGenerator"), 1)
+  verifyMetric(t, generatorsAlive, 1)
+  if _, err := toStringArray(lines); err != Done {
+    t.Fatalf("Got error %v", err)
+  }
+  var x int
+  gen.Next(&x)
+  gen.Close()
+  gen.Close()
+  lines.Close()
+  verifyMetric(t, openStreams.Get("ReadLines"), 0)
+  verifyMetric(t, openStreams.Get("NewGenerator"), 0)
+  verifyMetric(t, generatorsAlive, 0)
+  if output := elementsEmitted.Value() - emitted; output != 3 {
+    t.Errorf("Expected 3 emitted, got %v", output)
+  }
+}
+
+func TestMetricsGeneratorIgnoresClose(t *testing.T) {
+  EnableMetrics()
+  emitted := elementsEmitted.Value()
+  open := openStreams.Get("NewGenerator").(*expvar.Int).Value()
+  gen := NewGenerator(func(e Emitter) {
+    for {
+      // Misbehaves by emitting again even after the Stream is closed.
+      e.EmitPtr()
+      e.Return(nil)
+    }
+  })
+  if err := gen.Close(); err == nil {
+    t.Error("Expected error closing generator.")
+  }
+  verifyMetric(t, openStreams.Get("NewGenerator"), open)
+  if output := elementsEmitted.Value() - emitted; output != 0 {
+    t.Errorf("Expected 0 emitted, got %v", output)
+  }
+}
+
+func TestMetricsReceivers(t *testing.T) {
+  EnableMetrics()
+  received := ReadReceiver(RecvFunc(func(ptr interface{}) error {
+    return io.EOF
+  }))
+  decoded := ReadDecoded(
+      strings.NewReader("1 2"),
+      func(r io.Reader) ValueDecoder { return json.NewDecoder(r) })
+  verifyMetric(t, openStreams.Get("ReadReceiver"), 1)
+  verifyMetric(t, openStreams.Get("ReadDecoded"), 1)
+  if _, err := toIntArray(received); err != Done {
+    t.Fatalf("Got error %v", err)
+  }
+  if _, err := toIntArray(decoded); err != Done {
+    t.Fatalf("Got error %v", err)
+  }
+  verifyMetric(t, openStreams.Get("ReadReceiver"), 0)
+  verifyMetric(t, openStreams.Get("ReadDecoded"), 0)
+}
+
+func verifyMetric(t *testing.T, v expvar.Var, expected int64) {
+  if output := v.(*expvar.Int).Value(); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
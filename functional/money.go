@@ -0,0 +1,146 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "math"
+  "strings"
+)
+
+// MoneyFormat describes how amounts of money are written. Amounts are
+// held as int64 counts of the smallest unit so that $40.64 is 4064.
+type MoneyFormat struct {
+  // Symbol is the currency symbol written before the amount, e.g "$".
+  // It may be empty.
+  Symbol string
+  // Thousands separates groups of three digits. 0 means no separator.
+  Thousands rune
+  // Decimal separates the whole units from the fraction.
+  Decimal rune
+  // Digits is the number of fraction digits, e.g 2 for cents.
+  Digits int
+}
+
+var (
+  // USDollars formats amounts like $1,234.56
+  USDollars = &MoneyFormat{Symbol: "$", Thousands: ',', Decimal: '.', Digits: 2}
+  // Euros formats amounts like €1.234,56
+  Euros = &MoneyFormat{Symbol: "€", Thousands: '.', Decimal: ',', Digits: 2}
+)
+
+// Parse parses a formatted amount such as "$1,234.56" into 123456. A
+// leading minus sign, before or after the symbol, or enclosing parentheses
+// make the amount negative; at most one of these is allowed. The symbol
+// and thousands separators are optional, and fewer than Digits fraction
+// digits are allowed. Parse returns an error if the amount does not fit
+// in an int64.
+func (f *MoneyFormat) Parse(s string) (int64, error) {
+  orig := s
+  s = strings.TrimSpace(s)
+  parens := false
+  if strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")") {
+    parens = true
+    s = strings.TrimSpace(s[1:len(s) - 1])
+  }
+  minus := false
+  if strings.HasPrefix(s, "-") {
+    minus = true
+    s = s[1:]
+  }
+  if f.Symbol != "" {
+    s = strings.TrimPrefix(s, f.Symbol)
+  }
+  if !minus && strings.HasPrefix(s, "-") {
+    minus = true
+    s = s[1:]
+  }
+  if minus && parens {
+    return 0, fmt.Errorf("functional: Invalid amount %q.", orig)
+  }
+  negative := minus || parens
+  whole, fraction := s, ""
+  if idx := strings.IndexRune(s, f.Decimal); idx != -1 {
+    whole = s[:idx]
+    fraction = s[idx + len(string(f.Decimal)):]
+  }
+  if f.Thousands != 0 {
+    whole = strings.Replace(whole, string(f.Thousands), "", -1)
+  }
+  if (whole == "" && fraction == "") || len(fraction) > f.Digits {
+    return 0, fmt.Errorf("functional: Invalid amount %q.", orig)
+  }
+  fraction += strings.Repeat("0", f.Digits - len(fraction))
+  // The magnitude of the smallest int64 is one more than the largest.
+  limit := uint64(math.MaxInt64)
+  if negative {
+    limit++
+  }
+  var magnitude uint64
+  for _, r := range whole + fraction {
+    if r < '0' || r > '9' {
+      return 0, fmt.Errorf("functional: Invalid amount %q.", orig)
+    }
+    digit := uint64(r - '0')
+    if magnitude > (limit - digit) / 10 {
+      return 0, fmt.Errorf("functional: Amount %q out of range.", orig)
+    }
+    magnitude = magnitude * 10 + digit
+  }
+  if negative {
+    return -int64(magnitude), nil
+  }
+  return int64(magnitude), nil
+}
+
+// Format formats amount so that 123456 becomes "$1,234.56". Negative
+// amounts get a leading minus sign.
+func (f *MoneyFormat) Format(amount int64) string {
+  sign := ""
+  // Work with the magnitude as uint64 so the smallest int64 works too.
+  magnitude := uint64(amount)
+  if amount < 0 {
+    sign = "-"
+    magnitude = uint64(-amount)
+  }
+  digits := fmt.Sprintf("%0*d", f.Digits + 1, magnitude)
+  whole := digits[:len(digits) - f.Digits]
+  fraction := digits[len(digits) - f.Digits:]
+  if f.Thousands != 0 {
+    var groups []string
+    for len(whole) > 3 {
+      groups = append([]string{whole[len(whole) - 3:]}, groups...)
+      whole = whole[:len(whole) - 3]
+    }
+    whole = strings.Join(append([]string{whole}, groups...), string(f.Thousands))
+  }
+  if f.Digits == 0 {
+    return sign + f.Symbol + whole
+  }
+  return sign + f.Symbol + whole + string(f.Decimal) + fraction
+}
+
+// MoneyToInt64 returns a Mapper from formatted strings to int64 amounts
+// using f.
+func MoneyToInt64(f *MoneyFormat) Mapper {
+  return NewMapper(func(srcPtr, destPtr interface{}) error {
+    amount, err := f.Parse(*srcPtr.(*string))
+    if err != nil {
+      return err
+    }
+    *destPtr.(*int64) = amount
+    return nil
+  })
+}
+
+// Int64ToMoney returns a Mapper from int64 amounts to formatted strings
+// using f.
+func Int64ToMoney(f *MoneyFormat) Mapper {
+  return NewMapper(func(srcPtr, destPtr interface{}) error {
+    *destPtr.(*string) = f.Format(*srcPtr.(*int64))
+    return nil
+  })
+}
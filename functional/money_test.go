@@ -0,0 +1,73 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "math"
+  "testing"
+)
+
+func TestMoneyParse(t *testing.T) {
+  verifyParse(t, USDollars, "$40.64", 4064)
+  verifyParse(t, USDollars, "$1,234,567.8", 123456780)
+  verifyParse(t, USDollars, "12", 1200)
+  verifyParse(t, USDollars, "-$3.05", -305)
+  verifyParse(t, USDollars, "$-3.05", -305)
+  verifyParse(t, USDollars, "($3.05)", -305)
+  verifyParse(t, USDollars, " .5 ", 50)
+  verifyParse(t, Euros, "€1.234,56", 123456)
+  verifyParse(t, USDollars, "$92,233,720,368,547,758.07", math.MaxInt64)
+  verifyParse(t, USDollars, "-$92,233,720,368,547,758.08", math.MinInt64)
+  for _, bad := range []string{
+      "", "$", "$1.234", "abc", "$1.2.3", "--5", "-$-5", "(-$5)", "$-(5)",
+      "$92,233,720,368,547,758.08", "-$92,233,720,368,547,758.09",
+      "$100000000000000000000"} {
+    if _, err := USDollars.Parse(bad); err == nil {
+      t.Errorf("Expected error parsing %q", bad)
+    }
+  }
+}
+
+func TestMoneyFormat(t *testing.T) {
+  verifyFormat(t, USDollars, 4064, "$40.64")
+  verifyFormat(t, USDollars, 5, "$0.05")
+  verifyFormat(t, USDollars, -123456789, "-$1,234,567.89")
+  verifyFormat(t, USDollars, 100000, "$1,000.00")
+  verifyFormat(t, Euros, 123456, "€1.234,56")
+  verifyFormat(t, &MoneyFormat{Symbol: "¥", Digits: 0}, 12345, "¥12345")
+}
+
+func TestMoneyMappers(t *testing.T) {
+  var amount int64
+  if err := MoneyToInt64(USDollars).Map(ptrString("$1.50"), &amount); err != nil || amount != 150 {
+    t.Errorf("Expected 150, got %v %v", amount, err)
+  }
+  if err := MoneyToInt64(USDollars).Map(ptrString("x"), &amount); err == nil {
+    t.Error("Expected error")
+  }
+  var s string
+  Int64ToMoney(USDollars).Map(&amount, &s)
+  if s != "$1.50" {
+    t.Errorf("Expected $1.50, got %v", s)
+  }
+}
+
+func verifyParse(t *testing.T, f *MoneyFormat, s string, expected int64) {
+  amount, err := f.Parse(s)
+  if err != nil || amount != expected {
+    t.Errorf("Expected %d parsing %q, got %d %v", expected, s, amount, err)
+  }
+}
+
+func verifyFormat(t *testing.T, f *MoneyFormat, amount int64, expected string) {
+  if output := f.Format(amount); output != expected {
+    t.Errorf("Expected %q, got %q", expected, output)
+  }
+}
+
+func ptrString(s string) *string {
+  return &s
+}
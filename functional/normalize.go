@@ -0,0 +1,84 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "strings"
+  "unicode"
+)
+
+// Normalizer converts strings to a normal form so that strings that
+// should be treated as equal compare equal. This package does not depend
+// on golang.org/x/text, but its Unicode normalization forms such as
+// norm.NFC and norm.NFD as well as the Caser that cases.Fold returns
+// already satisfy Normalizer and can be passed to Normalize as is.
+type Normalizer interface {
+  String(s string) string
+}
+
+// NormalizerFunc adapts an ordinary function to a Normalizer.
+type NormalizerFunc func(s string) string
+
+// String returns f(s).
+func (f NormalizerFunc) String(s string) string {
+  return f(s)
+}
+
+var (
+  // FoldCase is a Normalizer that applies simple Unicode case folding
+  // using only the standard library. Strings that strings.EqualFold
+  // reports as equal fold to the same string, which is in lower case for
+  // most scripts. It does not apply full case folding, so "ß" and "ss"
+  // stay different; use cases.Fold from golang.org/x/text for that.
+  FoldCase Normalizer = NormalizerFunc(foldCase)
+  // TrimSpace is a Normalizer that removes leading and trailing white
+  // space.
+  TrimSpace Normalizer = NormalizerFunc(strings.TrimSpace)
+)
+
+// ChainNormalizers returns a Normalizer that applies each of ns in turn.
+// For example ChainNormalizers(norm.NFC, FoldCase) gives the keys that
+// dedupes and joins on text need.
+func ChainNormalizers(ns ...Normalizer) Normalizer {
+  return normalizerChain(ns)
+}
+
+// Normalize returns a Mapper of string to string that applies n. Use it
+// before DedupeByKey, FuzzyJoin, or grouping so that text that differs
+// only in its Unicode encoding or case is not treated as distinct.
+func Normalize(n Normalizer) Mapper {
+  return normalizeMapper{n}
+}
+
+// NormalizeStrings converts a Stream of string into a Stream of string
+// normalized with n. Calling Close on returned Stream closes s.
+func NormalizeStrings(s Stream, n Normalizer) Stream {
+  return Map(Normalize(n), s, new(string))
+}
+
+type normalizeMapper struct {
+  n Normalizer
+}
+
+func (m normalizeMapper) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*string) = m.n.String(*srcPtr.(*string))
+  return nil
+}
+
+type normalizerChain []Normalizer
+
+func (c normalizerChain) String(s string) string {
+  for _, n := range c {
+    s = n.String(s)
+  }
+  return s
+}
+
+func foldCase(s string) string {
+  return strings.Map(func(r rune) rune {
+    return unicode.ToLower(unicode.ToUpper(r))
+  }, s)
+}
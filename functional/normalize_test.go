@@ -0,0 +1,42 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+func TestFoldCase(t *testing.T) {
+  for _, pair := range [][2]string{
+      {"Hello", "hello"},
+      {"ΣΊΣΥΦΟΣ", "σίσυφοσ"},
+      {"ς", "σ"},
+      {"K", "k"},
+      {"straße", "straße"}} {
+    if output := FoldCase.String(pair[0]); output != pair[1] {
+      t.Errorf("Expected %q for %q, got %q", pair[1], pair[0], output)
+    }
+  }
+}
+
+func TestNormalizeStrings(t *testing.T) {
+  // Stands in for norm.NFC by composing e and a combining acute accent.
+  nfc := NormalizerFunc(func(s string) string {
+    return strings.Replace(s, "e\u0301", "\u00e9", -1)
+  })
+  s := &streamCloseChecker{
+      NewStreamFromValues([]string{" Cafe\u0301 ", "CAF\u00c9", "tea"}, nil),
+      &simpleCloseChecker{}}
+  stream := NormalizeStrings(s, ChainNormalizers(TrimSpace, nfc, FoldCase))
+  results, err := toStringArray(stream)
+  if output := fmt.Sprintf("%q", results); output != `["café" "café" "tea"]` {
+    t.Errorf("Expected café café tea, got %v", output)
+  }
+  verifyDone(t, stream, new(string), err)
+  verifyCloseCalled(t, s)
+}
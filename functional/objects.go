@@ -0,0 +1,45 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+)
+
+// ObjectStore represents cloud storage such as an S3 bucket. Callers
+// adapt the SDK of their storage service to it so that this package
+// depends on no SDK.
+type ObjectStore interface {
+  // Open opens the object with the given key for reading.
+  Open(key string) (io.ReadCloser, error)
+  // List returns the keys of the objects whose keys begin with prefix
+  // as a Stream of string.
+  List(prefix string) Stream
+}
+
+// ReadObjectsLines returns the lines of the objects in store whose keys
+// begin with prefix as a Stream of FileLine whose Path fields hold the
+// object keys. It works like ReadFilesLines, reading up to parallelism
+// objects at once. If listing fails, Next reports the error and no more
+// objects are read. Calling Close on returned Stream stops the reading and
+// closes the listing and any open objects before returning.
+func ReadObjectsLines(store ObjectStore, prefix string, parallelism int) Stream {
+  return readLinesOf(store.List(prefix), store.Open, parallelism)
+}
+
+// ReadObjectLines returns the lines of the object with the given key in
+// store as a Stream of string. If the object cannot be opened, Next
+// returns that error. Calling Close on returned Stream closes the object.
+// The object is not opened until the first call to Next.
+func ReadObjectLines(store ObjectStore, key string) Stream {
+  return Deferred(func() Stream {
+    r, err := store.Open(key)
+    if err != nil {
+      return errorStream{err}
+    }
+    return ReadLines(r)
+  })
+}
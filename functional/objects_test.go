@@ -0,0 +1,94 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "io"
+    "io/ioutil"
+    "sort"
+    "strings"
+    "testing"
+)
+
+var errNoSuchKey = errors.New("no such key")
+
+type fakeObjectStore map[string]string
+
+func (f fakeObjectStore) Open(key string) (io.ReadCloser, error) {
+  contents, ok := f[key]
+  if !ok {
+    return nil, errNoSuchKey
+  }
+  return ioutil.NopCloser(strings.NewReader(contents)), nil
+}
+
+func (f fakeObjectStore) List(prefix string) Stream {
+  var keys []string
+  for key := range f {
+    if strings.HasPrefix(key, prefix) {
+      keys = append(keys, key)
+    }
+  }
+  sort.Strings(keys)
+  return NewStreamFromValues(keys, nil)
+}
+
+// listingStore lists keys whether or not they are in the store.
+type listingStore struct {
+  fakeObjectStore
+  keys []string
+}
+
+func (l listingStore) List(prefix string) Stream {
+  return NewStreamFromValues(l.keys, nil)
+}
+
+func TestReadObjectsLinesOpenError(t *testing.T) {
+  store := listingStore{fakeObjectStore{"a": "a1"}, []string{"missing"}}
+  s := ReadObjectsLines(store, "", 1)
+  var line FileLine
+  err := s.Next(&line)
+  if !errors.Is(err, errNoSuchKey) || err.Error() != "missing: no such key" {
+    t.Errorf("Expected error naming missing key, got %v", err)
+  }
+  closeVerifyResult(t, s, nil)
+}
+
+func TestReadObjectsLines(t *testing.T) {
+  store := fakeObjectStore{
+      "logs/a": "a1\na2\n",
+      "logs/b": "b1",
+      "other/c": "c1\n"}
+  s := ReadObjectsLines(store, "logs/", 2)
+  var line FileLine
+  var results []string
+  var err error
+  for err = s.Next(&line); err == nil; err = s.Next(&line) {
+    results = append(results, fmt.Sprintf("%s:%d:%s", line.Path, line.Number, line.Text))
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  sort.Strings(results)
+  if output := strings.Join(results, " "); output != "logs/a:1:a1 logs/a:2:a2 logs/b:1:b1" {
+    t.Errorf("Expected lines of logs, got %v", output)
+  }
+  closeVerifyResult(t, s, nil)
+}
+
+func TestReadObjectLines(t *testing.T) {
+  store := fakeObjectStore{"a": "x\ny\n"}
+  results, err := toStringArray(ReadObjectLines(store, "a"))
+  if output := fmt.Sprintf("%v", results); output != "[x y]" || err != Done {
+    t.Errorf("Expected [x y], got %v and %v", output, err)
+  }
+  var line string
+  if err := ReadObjectLines(store, "b").Next(&line); err != errNoSuchKey {
+    t.Errorf("Expected errNoSuchKey, got %v", err)
+  }
+}
@@ -0,0 +1,80 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "fmt"
+  "reflect"
+)
+
+// ErrOutOfOrder is wrapped by the errors AssertOrdered reports.
+var ErrOutOfOrder = errors.New("functional: value out of order")
+
+// AssertOrdered returns a Stream that emits the same values as s, a
+// Stream of T, but whose Next method returns an error wrapping
+// ErrOutOfOrder that includes the 0-based index of the offending value
+// whenever a value is less than the one before it. less reports whether
+// the T value a points to is less than the one b points to. Equal values
+// are in order. The offending value is still stored in the pointer
+// passed to Next so that callers can inspect it or keep going. Use
+// AssertOrdered to guard operations that silently assume sorted input.
+// Calling Close on returned Stream closes s.
+func AssertOrdered(less func(a, b interface{}) bool, s Stream) Stream {
+  return &orderedStream{Stream: s, less: less}
+}
+
+// OrderViolations counts the values that were out of order.
+type OrderViolations struct {
+  count int
+}
+
+// Count returns the number of values found out of order so far.
+func (v *OrderViolations) Count() int {
+  return v.count
+}
+
+// CountUnordered works like AssertOrdered except that instead of
+// reporting an error for each value out of order, it counts them in the
+// returned OrderViolations.
+func CountUnordered(less func(a, b interface{}) bool, s Stream) (Stream, *OrderViolations) {
+  violations := &OrderViolations{}
+  return &orderedStream{Stream: s, less: less, violations: violations}, violations
+}
+
+type orderedStream struct {
+  Stream
+  less func(a, b interface{}) bool
+  violations *OrderViolations
+  prev interface{}
+  copier Copier
+  index int
+}
+
+func (s *orderedStream) Next(ptr interface{}) error {
+  err := s.Stream.Next(ptr)
+  if err != nil {
+    return err
+  }
+  index := s.index
+  s.index++
+  if s.prev == nil {
+    s.prev = reflect.New(reflect.TypeOf(ptr).Elem()).Interface()
+    s.copier = CopierFor(ptr)
+    s.copier(ptr, s.prev)
+    return nil
+  }
+  outOfOrder := s.less(ptr, s.prev)
+  s.copier(ptr, s.prev)
+  if !outOfOrder {
+    return nil
+  }
+  if s.violations != nil {
+    s.violations.count++
+    return nil
+  }
+  return fmt.Errorf("%w at index %d", ErrOutOfOrder, index)
+}
@@ -0,0 +1,47 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "testing"
+)
+
+func intLess(a, b interface{}) bool {
+  return *a.(*int) < *b.(*int)
+}
+
+func TestAssertOrdered(t *testing.T) {
+  s := AssertOrdered(intLess, NewStreamFromValues([]int{1, 2, 2, 1, 3}, nil))
+  var x int
+  var results []string
+  for err := s.Next(&x); err != Done; err = s.Next(&x) {
+    results = append(results, fmt.Sprintf("%d:%v", x, err))
+  }
+  if output := fmt.Sprintf("%v", results); output != "[1:<nil> 2:<nil> 2:<nil> 1:functional: value out of order at index 3 3:<nil>]" {
+    t.Errorf("Expected out of order error at index 3, got %v", output)
+  }
+  s = AssertOrdered(intLess, NewStreamFromValues([]int{2, 1}, nil))
+  s.Next(&x)
+  if err := s.Next(&x); !errors.Is(err, ErrOutOfOrder) {
+    t.Errorf("Expected ErrOutOfOrder, got %v", err)
+  }
+}
+
+func TestCountUnordered(t *testing.T) {
+  s, violations := CountUnordered(intLess, NewStreamFromValues([]int{3, 1, 2, 0}, nil))
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[3 1 2 0]" {
+    t.Errorf("Expected [3 1 2 0] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := violations.Count(); output != 2 {
+    t.Errorf("Expected 2, got %v", output)
+  }
+}
@@ -0,0 +1,57 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+)
+
+// Pages returns a Stream of []T that emits the values of s, a Stream of
+// T, pageSize at a time so that batch APIs can be fed one page at a time.
+// aSlice is a []T; its value is never read, but Pages needs it to create
+// new pages via reflection. Each emitted page is a new slice with
+// pageSize values except possibly the last which has fewer. Pages never
+// emits an empty page. pageSize must be greater than 0.
+// Calling Close on returned Stream closes s.
+func Pages(pageSize int, s Stream, aSlice interface{}) Stream {
+  if pageSize <= 0 {
+    panic("pageSize must be greater than 0.")
+  }
+  return &pagesStream{
+      Stream: s,
+      pageSize: pageSize,
+      sliceType: reflect.TypeOf(aSlice)}
+}
+
+type pagesStream struct {
+  Stream
+  pageSize int
+  sliceType reflect.Type
+  done bool
+}
+
+func (s *pagesStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  page := reflect.MakeSlice(s.sliceType, s.pageSize, s.pageSize)
+  n := 0
+  for ; n < s.pageSize; n++ {
+    err := s.Stream.Next(page.Index(n).Addr().Interface())
+    if err == Done {
+      s.done = true
+      break
+    }
+    if err != nil {
+      return err
+    }
+  }
+  if n == 0 {
+    return Done
+  }
+  reflect.ValueOf(ptr).Elem().Set(page.Slice(0, n))
+  return nil
+}
@@ -0,0 +1,60 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestPages(t *testing.T) {
+  stream := Pages(3, xrange(0, 7), []int(nil))
+  var pages [][]int
+  var page []int
+  err := stream.Next(&page)
+  for ; err == nil; err = stream.Next(&page) {
+    pages = append(pages, page)
+  }
+  if output := fmt.Sprintf("%v", pages); output != "[[0 1 2] [3 4 5] [6]]" {
+    t.Errorf("Expected [[0 1 2] [3 4 5] [6]] got %v", output)
+  }
+  verifyDone(t, stream, &page, err)
+}
+
+func TestPagesExact(t *testing.T) {
+  stream := Pages(2, xrange(0, 4), []int(nil))
+  var count int
+  var page []int
+  err := stream.Next(&page)
+  for ; err == nil; err = stream.Next(&page) {
+    count++
+  }
+  if count != 2 {
+    t.Errorf("Expected 2 pages, got %d", count)
+  }
+  verifyDone(t, stream, &page, err)
+}
+
+func TestPagesError(t *testing.T) {
+  stream := Pages(2, Map(errMapper, Count(), new(int)), []int(nil))
+  var page []int
+  if err := stream.Next(&page); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestPagesClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  stream := Pages(2, s, []int(nil))
+  stream.Close()
+  verifyCloseCalled(t, s)
+}
+
+func TestPagesPanics(t *testing.T) {
+  verifyPanics(t, func() {
+    Pages(0, Count(), []int(nil))
+  })
+}
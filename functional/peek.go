@@ -0,0 +1,57 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+)
+
+// PeekFirst reads the first value of s, a Stream of T, into ptr, a *T,
+// and returns a Stream that emits all the values of s including that first
+// one. c is a Copier of T used to hold onto the first value; nil means
+// the Copier registered for T or simple assignment. If s is empty,
+// PeekFirst returns Done. If reading s fails, PeekFirst returns that
+// error. In either case, the caller should still close the returned Stream.
+// Calling Close on returned Stream closes s.
+func PeekFirst(s Stream, ptr interface{}, c Copier) (Stream, error) {
+  if err := s.Next(ptr); err != nil {
+    return s, err
+  }
+  if c == nil {
+    c = CopierFor(ptr)
+  }
+  first := reflect.New(reflect.TypeOf(ptr).Elem()).Interface()
+  c(ptr, first)
+  return &peekStream{Stream: s, first: first, copier: c}, nil
+}
+
+// IsEmpty reports whether s, a Stream of T, is empty and returns a Stream
+// that emits all the values of s. Since IsEmpty has to read the first
+// value to find out, it needs ptr, a *T, to read it into. IsEmpty returns
+// any error other than Done from reading s. The caller should close the
+// returned Stream as it would s.
+func IsEmpty(s Stream, ptr interface{}) (bool, Stream, error) {
+  result, err := PeekFirst(s, ptr, nil)
+  if err == Done {
+    return true, result, nil
+  }
+  return false, result, err
+}
+
+type peekStream struct {
+  Stream
+  first interface{}
+  copier Copier
+}
+
+func (s *peekStream) Next(ptr interface{}) error {
+  if s.first != nil {
+    s.copier(s.first, ptr)
+    s.first = nil
+    return nil
+  }
+  return s.Stream.Next(ptr)
+}
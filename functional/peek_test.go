@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestPeekFirst(t *testing.T) {
+  var first int
+  stream, err := PeekFirst(xrange(3, 6), &first, nil)
+  if err != nil || first != 3 {
+    t.Fatalf("Expected 3, got %v %v", first, err)
+  }
+  first = 100
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[3 4 5]" {
+    t.Errorf("Expected [3 4 5] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestPeekFirstEmptyAndError(t *testing.T) {
+  s := &streamCloseChecker{NilStream(), &simpleCloseChecker{}}
+  stream, err := PeekFirst(s, new(int), nil)
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  stream.Close()
+  verifyCloseCalled(t, s)
+  if _, err := PeekFirst(Map(errMapper, Count(), new(int)), new(int), nil); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestIsEmpty(t *testing.T) {
+  empty, stream, err := IsEmpty(xrange(0, 2), new(int))
+  if empty || err != nil {
+    t.Errorf("Expected not empty, got %v %v", empty, err)
+  }
+  results, _ := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1]" {
+    t.Errorf("Expected [0 1] got %v", output)
+  }
+  empty, stream, err = IsEmpty(NilStream(), new(int))
+  if !empty || err != nil {
+    t.Errorf("Expected empty, got %v %v", empty, err)
+  }
+  verifyDone(t, stream, new(int), Done)
+}
+
+func TestPeekFirstClose(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 2), &simpleCloseChecker{}}
+  stream, _ := PeekFirst(s, new(int), nil)
+  stream.Close()
+  verifyCloseCalled(t, s)
+}
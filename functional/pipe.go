@@ -0,0 +1,56 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+)
+
+// StreamToPipe renders each value of s, a Stream of T, to the returned
+// io.Reader using render. render gets a *T holding the value and the
+// io.Writer to render it to. ptr is a *T providing storage for the values
+// of s. Values are read from s only as fast as the returned io.Reader is
+// read so that nothing is buffered beyond the value being rendered.
+// Once s is exhausted, the returned io.Reader reports io.EOF, or if
+// reading s or rendering fails, that error. StreamToPipe closes s when it
+// finishes; a Close error is reported through the returned io.Reader.
+// Calling the returned cancel function stops the rendering, closes s, and
+// makes further reads fail with io.ErrClosedPipe. cancel waits for s to be
+// closed and may be called more than once.
+func StreamToPipe(
+    s Stream,
+    ptr interface{},
+    render func(ptr interface{}, w io.Writer) error) (
+    reader io.Reader, cancel func()) {
+  pr, pw := io.Pipe()
+  done := make(chan struct{})
+  go func() {
+    defer close(done)
+    pw.CloseWithError(renderStream(s, ptr, render, pw))
+  }()
+  return pr, func() {
+    pr.Close()
+    <-done
+  }
+}
+
+func renderStream(
+    s Stream,
+    ptr interface{},
+    render func(ptr interface{}, w io.Writer) error,
+    w io.Writer) error {
+  var err error
+  for err = s.Next(ptr); err == nil; err = s.Next(ptr) {
+    if err = render(ptr, w); err != nil {
+      break
+    }
+  }
+  closeErr := s.Close()
+  if err != Done {
+    return err
+  }
+  return closeErr
+}
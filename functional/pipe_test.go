@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "io"
+    "io/ioutil"
+    "testing"
+)
+
+func renderInt(ptr interface{}, w io.Writer) error {
+  _, err := fmt.Fprintf(w, "%d\n", *ptr.(*int))
+  return err
+}
+
+func TestStreamToPipe(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 3), &simpleCloseChecker{}}
+  r, cancel := StreamToPipe(s, new(int), renderInt)
+  defer cancel()
+  b, err := ioutil.ReadAll(r)
+  if err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if output := string(b); output != "0\n1\n2\n" {
+    t.Errorf("Expected 0 1 2 got %q", output)
+  }
+  verifyCloseCalled(t, s)
+}
+
+func TestStreamToPipeError(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 3), &simpleCloseChecker{closeError: closeError}}
+  r, _ := StreamToPipe(s, new(int), renderInt)
+  if _, err := ioutil.ReadAll(r); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  r, _ = StreamToPipe(xrange(0, 3), new(int), func(ptr interface{}, w io.Writer) error {
+    return mapError
+  })
+  if _, err := ioutil.ReadAll(r); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestStreamToPipeCancel(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{}}
+  r, cancel := StreamToPipe(s, new(int), renderInt)
+  b := make([]byte, 2)
+  if _, err := io.ReadFull(r, b); err != nil || string(b) != "0\n" {
+    t.Errorf("Expected 0, got %q and %v", b, err)
+  }
+  cancel()
+  cancel()
+  verifyCloseCalled(t, s)
+  if _, err := r.Read(b); err != io.ErrClosedPipe {
+    t.Errorf("Expected io.ErrClosedPipe, got %v", err)
+  }
+}
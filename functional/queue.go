@@ -0,0 +1,148 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+  "reflect"
+  "sync"
+)
+
+// Queue represents a message queue subscription such as a Kafka consumer.
+type Queue interface {
+  // Fetch stores the next message in the T value ptr points to, waiting
+  // for one if necessary. Fetch returns Done or io.EOF when there are
+  // no more messages.
+  Fetch(ptr interface{}) error
+  // Ack acknowledges the message Fetch most recently returned so that
+  // it is not delivered again.
+  Ack() error
+  // Close ends the subscription.
+  Close() error
+}
+
+// ReadQueue returns the messages of q as a Stream of T. A message is
+// acknowledged only once it has been consumed successfully which the
+// returned Stream takes to mean that Next was called again. So when
+// a consumer stops at an error, the message it failed on is not
+// acknowledged and will be delivered again, giving at-least-once
+// processing. For the same reason, the last message emitted before
+// Close is called is not acknowledged unless Next returned Done first.
+// If Ack fails, Next returns that error without fetching another
+// message. When end of returned Stream is reached, it closes q.
+// Calling Close on returned Stream closes q.
+func ReadQueue(q Queue) Stream {
+  return &queueStream{
+      queue: q,
+      maybeCloser: maybeCloser{c: q, metric: trackOpen("ReadQueue")}}
+}
+
+type queueStream struct {
+  queue Queue
+  maybeCloser
+  pending bool
+  done bool
+}
+
+func (s *queueStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  if s.pending {
+    if err := s.queue.Ack(); err != nil {
+      return err
+    }
+    s.pending = false
+  }
+  err := s.queue.Fetch(ptr)
+  if err == Done || err == io.EOF {
+    s.done = true
+    return finish(s.Close())
+  }
+  if err == nil {
+    s.pending = true
+  }
+  return err
+}
+
+func (s *queueStream) Close() error {
+  s.done = true
+  return s.maybeCloser.Close()
+}
+
+// NewQueueStream returns a Stream of T fed by the returned push function
+// through a buffer holding up to capacity values. push copies the T value
+// ptr points to into the buffer using the Copier CopierFor returns,
+// blocking while the buffer is full. Once the returned Stream is closed,
+// push returns Done right away so that the producer can stop. The
+// producer calls the returned close function when it has no more values;
+// the Stream then returns Done once the buffer is drained. push and close
+// must be called from the same goroutine or otherwise be synchronized;
+// calling push after close panics. NewQueueStream is a lighter weight
+// alternative to NewGenerator for producers that are not naturally
+// written as an emitting function.
+func NewQueueStream(capacity int) (
+    push func(ptr interface{}) error, close func(), s Stream) {
+  q := &queueBuffer{ch: make(chan interface{}, capacity), quit: make(chan struct{})}
+  return q.push, q.close, &queueBufferStream{queueBuffer: q}
+}
+
+type queueBuffer struct {
+  ch chan interface{}
+  quit chan struct{}
+  pushClosed bool
+  quitOnce sync.Once
+}
+
+func (q *queueBuffer) push(ptr interface{}) error {
+  if q.pushClosed {
+    panic("functional: push called after close.")
+  }
+  value := reflect.New(reflect.TypeOf(ptr).Elem()).Interface()
+  CopierFor(ptr)(ptr, value)
+  select {
+    case <-q.quit:
+      return Done
+    case q.ch <- value:
+      return nil
+  }
+}
+
+func (q *queueBuffer) close() {
+  if !q.pushClosed {
+    q.pushClosed = true
+    close(q.ch)
+  }
+}
+
+type queueBufferStream struct {
+  *queueBuffer
+  done bool
+}
+
+func (s *queueBufferStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  select {
+    case <-s.quit:
+      s.done = true
+      return Done
+    case value, ok := <-s.ch:
+      if !ok {
+        s.done = true
+        return Done
+      }
+      reflect.ValueOf(ptr).Elem().Set(reflect.ValueOf(value).Elem())
+      return nil
+  }
+}
+
+func (s *queueBufferStream) Close() error {
+  s.done = true
+  s.quitOnce.Do(func() { close(s.quit) })
+  return nil
+}
@@ -0,0 +1,147 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "io"
+    "testing"
+)
+
+type fakeQueue struct {
+  messages []int
+  acked []int
+  ackError error
+  current int
+  closeChecker
+}
+
+func (q *fakeQueue) Fetch(ptr interface{}) error {
+  if len(q.messages) == 0 {
+    return io.EOF
+  }
+  q.current = q.messages[0]
+  q.messages = q.messages[1:]
+  *ptr.(*int) = q.current
+  return nil
+}
+
+func (q *fakeQueue) Ack() error {
+  if q.ackError != nil {
+    return q.ackError
+  }
+  q.acked = append(q.acked, q.current)
+  return nil
+}
+
+func TestReadQueue(t *testing.T) {
+  q := &fakeQueue{messages: []int{1, 2, 3}, closeChecker: &simpleCloseChecker{}}
+  s := ReadQueue(q)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[1 2 3]" {
+    t.Errorf("Expected [1 2 3] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", q.acked); output != "[1 2 3]" {
+    t.Errorf("Expected [1 2 3] acked got %v", output)
+  }
+  closeVerifyResult(t, s, nil)
+  verifyCloseCalled(t, q)
+}
+
+func TestReadQueueClosesAtEnd(t *testing.T) {
+  q := &fakeQueue{
+      messages: []int{1, 2},
+      closeChecker: &simpleCloseChecker{noDupClose: true}}
+  s := ReadQueue(q)
+  toIntArray(s)
+  verifyCloseCalled(t, q)
+  closeVerifyResult(t, s, nil)
+  closeVerifyResult(t, s, nil)
+}
+
+func TestReadQueueCloseError(t *testing.T) {
+  q := &fakeQueue{
+      messages: []int{1},
+      closeChecker: &simpleCloseChecker{closeError: closeError}}
+  s := ReadQueue(q)
+  var x int
+  s.Next(&x)
+  if err := s.Next(&x); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+}
+
+func TestReadQueueStopEarly(t *testing.T) {
+  q := &fakeQueue{messages: []int{1, 2, 3}, closeChecker: &simpleCloseChecker{}}
+  s := Slice(ReadQueue(q), 0, 2)
+  toIntArray(s)
+  if output := fmt.Sprintf("%v", q.acked); output != "[1]" {
+    t.Errorf("Expected [1] acked got %v", output)
+  }
+  verifyCloseCalled(t, q)
+}
+
+func TestReadQueueAckError(t *testing.T) {
+  q := &fakeQueue{messages: []int{1, 2}, ackError: closeError, closeChecker: &simpleCloseChecker{}}
+  s := ReadQueue(q)
+  var x int
+  if err := s.Next(&x); err != nil || x != 1 {
+    t.Errorf("Expected 1, got %v and %v", x, err)
+  }
+  if err := s.Next(&x); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if len(q.messages) != 1 {
+    t.Error("Expected no fetch after failed Ack.")
+  }
+}
+
+func TestNewQueueStream(t *testing.T) {
+  push, closeQueue, s := NewQueueStream(2)
+  go func() {
+    defer closeQueue()
+    for i := 0; i < 5; i++ {
+      if push(&i) != nil {
+        return
+      }
+    }
+  }()
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3 4]" {
+    t.Errorf("Expected [0 1 2 3 4] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  verifyDone(t, s, new(int), Done)
+}
+
+func TestNewQueueStreamClosedEarly(t *testing.T) {
+  push, closeQueue, s := NewQueueStream(0)
+  pushErr := make(chan error)
+  go func() {
+    defer closeQueue()
+    for i := 0; ; i++ {
+      if err := push(&i); err != nil {
+        pushErr <- err
+        return
+      }
+    }
+  }()
+  var x int
+  if err := s.Next(&x); err != nil || x != 0 {
+    t.Errorf("Expected 0, got %v and %v", x, err)
+  }
+  closeVerifyResult(t, s, nil)
+  closeVerifyResult(t, s, nil)
+  if err := <-pushErr; err != Done {
+    t.Errorf("Expected Done from push, got %v", err)
+  }
+  verifyDone(t, s, &x, Done)
+}
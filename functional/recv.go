@@ -0,0 +1,88 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+)
+
+// Receiver represents the receiving end of a stream of messages such as
+// a server-streaming RPC. Since RPC stubs typically have a method like
+// Recv() (*Message, error), they are usually adapted with RecvFunc.
+type Receiver interface {
+  // Recv stores the next message in the T value ptr points to. Recv
+  // returns io.EOF when there are no more messages.
+  Recv(ptr interface{}) error
+}
+
+// RecvFunc adapts an ordinary function to a Receiver. For example,
+// given a generated stub with Recv() (*pb.Item, error):
+//
+//   functional.RecvFunc(func(ptr interface{}) error {
+//     item, err := stub.Recv()
+//     if err == nil {
+//       *ptr.(**pb.Item) = item
+//     }
+//     return err
+//   })
+type RecvFunc func(ptr interface{}) error
+
+// Recv calls f.
+func (f RecvFunc) Recv(ptr interface{}) error {
+  return f(ptr)
+}
+
+// ReadReceiver returns the messages r receives as a Stream of T. Once r
+// returns io.EOF, the returned Stream returns Done. When end of returned
+// Stream is reached, it closes r if r implements io.Closer propagating any
+// Close error through Next. Calling Close on returned Stream closes r if r
+// implements io.Closer, which is how a caller cancels the underlying RPC.
+func ReadReceiver(r Receiver) Stream {
+  c, _ := r.(io.Closer)
+  return &receiverStream{
+      receiver: r,
+      maybeCloser: maybeCloser{c: c, metric: trackOpen("ReadReceiver")}}
+}
+
+// ValueDecoder decodes a sequence of encoded values such as MessagePack
+// or CBOR values. The Decoder types of most encoding libraries, including
+// encoding/json, already implement it.
+type ValueDecoder interface {
+  // Decode stores the next value in the T value ptr points to. Decode
+  // returns io.EOF when there are no more values.
+  Decode(ptr interface{}) error
+}
+
+// ReadDecoded returns the values encoded in r as a Stream of T.
+// newDecoder returns the ValueDecoder that decodes r, which lets callers
+// choose an encoding without this package depending on it. When end of
+// returned Stream is reached, it closes r if r implements io.Closer
+// propagating any Close error through Next. Calling Close on returned
+// Stream closes r if r implements io.Closer.
+func ReadDecoded(r io.Reader, newDecoder func(r io.Reader) ValueDecoder) Stream {
+  c, _ := r.(io.Closer)
+  return &receiverStream{
+      receiver: RecvFunc(newDecoder(r).Decode),
+      maybeCloser: maybeCloser{c: c, metric: trackOpen("ReadDecoded")}}
+}
+
+type receiverStream struct {
+  receiver Receiver
+  maybeCloser
+  done bool
+}
+
+func (s *receiverStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  err := s.receiver.Recv(ptr)
+  if err == io.EOF {
+    s.done = true
+    return finish(s.Close())
+  }
+  return err
+}
@@ -0,0 +1,89 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "encoding/json"
+    "fmt"
+    "io"
+    "strings"
+    "testing"
+)
+
+type fakeReceiver struct {
+  values []int
+  closeChecker
+}
+
+func (r *fakeReceiver) Recv(ptr interface{}) error {
+  if len(r.values) == 0 {
+    return io.EOF
+  }
+  *ptr.(*int) = r.values[0]
+  r.values = r.values[1:]
+  return nil
+}
+
+func TestReadReceiver(t *testing.T) {
+  r := &fakeReceiver{values: []int{4, 5, 6}, closeChecker: &simpleCloseChecker{}}
+  s := ReadReceiver(r)
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[4 5 6]" {
+    t.Errorf("Expected [4 5 6] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  verifyCloseCalled(t, r)
+  verifyDone(t, s, new(int), Done)
+}
+
+func TestReadReceiverError(t *testing.T) {
+  calls := 0
+  s := ReadReceiver(RecvFunc(func(ptr interface{}) error {
+    calls++
+    if calls == 2 {
+      return scanError
+    }
+    if calls > 2 {
+      return io.EOF
+    }
+    *ptr.(*int) = calls
+    return nil
+  }))
+  var x int
+  if err := s.Next(&x); err != nil || x != 1 {
+    t.Errorf("Expected 1, got %v and %v", x, err)
+  }
+  if err := s.Next(&x); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  verifyDone(t, s, &x, Done)
+  closeVerifyResult(t, s, nil)
+}
+
+func TestReadDecoded(t *testing.T) {
+  r := &readerCloseChecker{
+      strings.NewReader("{\"id\": 1, \"name\": \"a\"} {\"id\": 2}"),
+      &simpleCloseChecker{}}
+  s := ReadDecoded(r, func(r io.Reader) ValueDecoder {
+    return json.NewDecoder(r)
+  })
+  var results []map[string]interface{}
+  var m map[string]interface{}
+  var err error
+  for err = s.Next(&m); err == nil; err = s.Next(&m) {
+    results = append(results, m)
+    m = nil
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[map[id:1 name:a] map[id:2]]" {
+    t.Errorf("Expected two maps, got %v", output)
+  }
+  verifyCloseCalled(t, r)
+}
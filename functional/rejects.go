@@ -0,0 +1,106 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "reflect"
+)
+
+// FilterWithRejects splits s, a Stream of T, into two Streams of T:
+// accepted emits the values f includes and rejected emits the values f
+// skips so that they can be sent somewhere for auditing rather than
+// silently dropped. Values read from s while reading one Stream that
+// belong to the other are copied, using the Copier registered for T or
+// simple assignment, and held until the other Stream reads them, so
+// reading far ahead in one Stream without reading the other uses memory.
+// Once a Stream is closed, values for it are discarded. s is closed once
+// both returned Streams are closed. The returned Streams must not be used
+// from different goroutines at the same time.
+func FilterWithRejects(f Filterer, s Stream) (accepted, rejected Stream) {
+  r := &rejectsSource{f: f, s: s}
+  return &rejectsStream{source: r, accept: true}, &rejectsStream{source: r}
+}
+
+type rejectsSource struct {
+  f Filterer
+  s Stream
+  pending [2][]reflect.Value
+  closed [2]bool
+  done bool
+}
+
+func (r *rejectsSource) next(side int, ptr interface{}) error {
+  if len(r.pending[side]) > 0 {
+    v := r.pending[side][0]
+    r.pending[side] = r.pending[side][1:]
+    CopierFor(ptr)(v.Interface(), ptr)
+    return nil
+  }
+  if r.done {
+    return Done
+  }
+  for {
+    err := r.s.Next(ptr)
+    if err == Done {
+      r.done = true
+      return Done
+    }
+    if err != nil {
+      return err
+    }
+    ferr := r.f.Filter(ptr)
+    var valueSide int
+    if ferr == nil {
+      valueSide = acceptedSide
+    } else if ferr == Skipped {
+      valueSide = rejectedSide
+    } else {
+      return ferr
+    }
+    if valueSide == side {
+      return nil
+    }
+    if !r.closed[valueSide] {
+      v := reflect.New(reflect.TypeOf(ptr).Elem())
+      CopierFor(ptr)(ptr, v.Interface())
+      r.pending[valueSide] = append(r.pending[valueSide], v)
+    }
+  }
+}
+
+func (r *rejectsSource) close(side int) error {
+  r.closed[side] = true
+  r.pending[side] = nil
+  if r.closed[acceptedSide] && r.closed[rejectedSide] {
+    return r.s.Close()
+  }
+  return nil
+}
+
+const (
+  acceptedSide = 0
+  rejectedSide = 1
+)
+
+type rejectsStream struct {
+  source *rejectsSource
+  accept bool
+}
+
+func (s *rejectsStream) Next(ptr interface{}) error {
+  return s.source.next(s.side(), ptr)
+}
+
+func (s *rejectsStream) Close() error {
+  return s.source.close(s.side())
+}
+
+func (s *rejectsStream) side() int {
+  if s.accept {
+    return acceptedSide
+  }
+  return rejectedSide
+}
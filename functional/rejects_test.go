@@ -0,0 +1,49 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestFilterWithRejects(t *testing.T) {
+  accepted, rejected := FilterWithRejects(lessThan(3), xrange(0, 6))
+  var x int
+  if err := rejected.Next(&x); err != nil || x != 3 {
+    t.Errorf("Expected 3, got %v %v", x, err)
+  }
+  results, err := toIntArray(accepted)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyDone(t, accepted, new(int), err)
+  results, err = toIntArray(rejected)
+  if output := fmt.Sprintf("%v", results); output != "[4 5]" {
+    t.Errorf("Expected [4 5] got %v", output)
+  }
+  verifyDone(t, rejected, new(int), err)
+}
+
+func TestFilterWithRejectsError(t *testing.T) {
+  accepted, _ := FilterWithRejects(failOn(2, lessThan(1)), xrange(0, 6))
+  _, err := toIntArray(accepted)
+  if err != filterError {
+    t.Errorf("Expected filterError, got %v", err)
+  }
+}
+
+func TestFilterWithRejectsClose(t *testing.T) {
+  s := &streamCloseChecker{xrange(0, 6), &simpleCloseChecker{closeError: closeError}}
+  accepted, rejected := FilterWithRejects(lessThan(3), s)
+  closeVerifyResult(t, rejected, nil)
+  results, _ := toIntArray(accepted)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  closeVerifyResult(t, accepted, closeError)
+  verifyCloseCalled(t, s)
+}
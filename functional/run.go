@@ -0,0 +1,43 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "io"
+)
+
+// Run calls setup to acquire resources and build a Stream, has consumer
+// consume that Stream, and then closes the Stream followed by the
+// resources setup returned in the reverse order setup listed them. setup
+// should return the resources it has acquired so far even when it returns
+// an error; Run closes them in that case too without calling consumer.
+// If consumer has an Error() error method, as ErrorReportingConsumers do,
+// Run returns its error first. Otherwise Run returns the error from setup
+// or the first error from closing.
+func Run(setup func() (Stream, []io.Closer, error), consumer Consumer) error {
+  s, closers, err := setup()
+  if err == nil {
+    consumer.Consume(s)
+    if ec, ok := consumer.(errorReporter); ok {
+      err = ec.Error()
+    }
+  }
+  if s != nil {
+    if cerr := s.Close(); err == nil {
+      err = cerr
+    }
+  }
+  for i := len(closers) - 1; i >= 0; i-- {
+    if cerr := closers[i].Close(); err == nil {
+      err = cerr
+    }
+  }
+  return err
+}
+
+type errorReporter interface {
+  Error() error
+}
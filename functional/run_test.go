@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "fmt"
+    "io"
+    "testing"
+)
+
+type errorConsumer struct {
+  results []int
+  err error
+}
+
+func (c *errorConsumer) Consume(s Stream) {
+  c.results, _ = toIntArray(s)
+}
+
+func (c *errorConsumer) Error() error {
+  return c.err
+}
+
+func TestRun(t *testing.T) {
+  var closed []string
+  s := &streamCloseChecker{xrange(0, 3), &simpleCloseChecker{}}
+  c := &errorConsumer{}
+  err := Run(func() (Stream, []io.Closer, error) {
+    return s, []io.Closer{orderCloser{"db", &closed, nil}, orderCloser{"stmt", &closed, closeError}}, nil
+  }, c)
+  if err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if output := fmt.Sprintf("%v %v", c.results, closed); output != "[0 1 2] [stmt db]" {
+    t.Errorf("Expected [0 1 2] [stmt db] got %v", output)
+  }
+  verifyCloseCalled(t, s)
+  c.err = mapError
+  if err := Run(func() (Stream, []io.Closer, error) {
+    return xrange(0, 1), nil, nil
+  }, c); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
+
+func TestRunSetupFails(t *testing.T) {
+  var closed []string
+  c := &errorConsumer{}
+  err := Run(func() (Stream, []io.Closer, error) {
+    return nil, []io.Closer{orderCloser{"db", &closed, closeError}}, scanError
+  }, c)
+  if err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+  if output := fmt.Sprintf("%v %v", c.results, closed); output != "[] [db]" {
+    t.Errorf("Expected [] [db] got %v", output)
+  }
+}
@@ -0,0 +1,240 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "container/heap"
+  "reflect"
+  "sort"
+  "time"
+)
+
+// Session is a run of events sharing the same key in which no two
+// consecutive events are more than a gap apart.
+type Session struct {
+  // Key is the key shared by the events.
+  Key string
+  // Start is the timestamp of the earliest event.
+  Start time.Time
+  // End is the timestamp of the latest event.
+  End time.Time
+  // Count is the number of events.
+  Count int
+  // Events is a []*T holding the events in the order they were read or
+  // nil if SessionizeOpt was told not to keep them.
+  Events interface{}
+}
+
+// Duration returns the time between the first and last event.
+func (s *Session) Duration() time.Duration {
+  return s.End.Sub(s.Start)
+}
+
+// Stream returns the events of the session as a Stream of T. It returns
+// a Stream that emits nothing if the events were not kept.
+func (s *Session) Stream() Stream {
+  if s.Events == nil {
+    return NilStream()
+  }
+  return NewStreamFromPtrs(s.Events, nil)
+}
+
+// Sessionize returns a Stream of Session that groups the events in s, a
+// Stream of T, into sessions. key returns the key of the event ptr points
+// to, such as a user ID, and timestamp returns its time. An event joins
+// the open session for its key if it is no more than gap after the
+// latest event of that session; otherwise it starts a new session. ptr
+// is a *T providing storage for the events of s. Sessionize keeps a
+// copy of every event, made with the Copier CopierFor returns, in
+// Events until its session is emitted.
+//
+// s should be in timestamp order. A session is emitted as soon as an
+// event of any key arrives more than gap after the session's End, so
+// only sessions still open are held in memory. Sessions closed by the
+// same event are emitted in order of Start and then Key; the sessions
+// left open at the end of s are emitted the same way. An event that
+// arrives late joins the open session for its key if it is no more than
+// gap before that session's Start. Otherwise, or if that session was
+// already emitted, it starts a new session; when it is more than gap
+// before the open session, it is emitted at once as a session of its own.
+// Calling Close on returned Stream closes s.
+func Sessionize(
+    key func(ptr interface{}) string,
+    timestamp func(ptr interface{}) time.Time,
+    gap time.Duration,
+    s Stream,
+    ptr interface{}) Stream {
+  return SessionizeOpt(key, timestamp, gap, s, ptr, true)
+}
+
+// SessionizeOpt works like Sessionize except that if keepEvents is false,
+// the events themselves are not kept, and emitted Sessions have only
+// their Key, Start, End, and Count. This bounds memory by the number of
+// open sessions rather than the number of events in them.
+func SessionizeOpt(
+    key func(ptr interface{}) string,
+    timestamp func(ptr interface{}) time.Time,
+    gap time.Duration,
+    s Stream,
+    ptr interface{},
+    keepEvents bool) Stream {
+  return &sessionStream{
+      Stream: s,
+      key: key,
+      timestamp: timestamp,
+      gap: gap,
+      ptr: ptr,
+      keepEvents: keepEvents,
+      open: make(map[string]*openSession)}
+}
+
+type openSession struct {
+  Session
+  events reflect.Value
+  // index is the position in the sessionHeap.
+  index int
+}
+
+type sessionStream struct {
+  Stream
+  key func(ptr interface{}) string
+  timestamp func(ptr interface{}) time.Time
+  gap time.Duration
+  ptr interface{}
+  keepEvents bool
+  copier Copier
+  open map[string]*openSession
+  byEnd sessionHeap
+  ready []*openSession
+  latest time.Time
+  exhausted bool
+}
+
+func (s *sessionStream) Next(ptr interface{}) error {
+  for len(s.ready) == 0 {
+    if s.exhausted {
+      return Done
+    }
+    err := s.Stream.Next(s.ptr)
+    if err == Done {
+      s.exhausted = true
+      s.expire(true)
+      continue
+    }
+    if err != nil {
+      return err
+    }
+    s.add(s.ptr)
+  }
+  o := s.ready[0]
+  s.ready = s.ready[1:]
+  session := o.Session
+  if o.events.IsValid() {
+    session.Events = o.events.Interface()
+  }
+  *ptr.(*Session) = session
+  return nil
+}
+
+func (s *sessionStream) add(eventPtr interface{}) {
+  k := s.key(eventPtr)
+  t := s.timestamp(eventPtr)
+  if t.After(s.latest) {
+    s.latest = t
+  }
+  // Since t is no later than latest, this also expires the open session
+  // for k if t is more than gap after it.
+  s.expire(false)
+  o := s.open[k]
+  if o != nil && o.Start.Sub(t) > s.gap {
+    // t is too far before the open session to join it, and it is already
+    // more than gap before the latest event, so it is a session by itself.
+    o = &openSession{Session: Session{Key: k, Start: t, End: t}}
+    s.ready = append(s.ready, o)
+  } else if o == nil {
+    o = &openSession{Session: Session{Key: k, Start: t, End: t}}
+    s.open[k] = o
+    heap.Push(&s.byEnd, o)
+  } else {
+    if t.Before(o.Start) {
+      o.Start = t
+    }
+    if t.After(o.End) {
+      o.End = t
+      heap.Fix(&s.byEnd, o.index)
+    }
+  }
+  o.Count++
+  if s.keepEvents {
+    s.keep(o, eventPtr)
+  }
+}
+
+func (s *sessionStream) keep(o *openSession, eventPtr interface{}) {
+  ptrType := reflect.TypeOf(eventPtr)
+  if s.copier == nil {
+    s.copier = CopierFor(eventPtr)
+  }
+  if !o.events.IsValid() {
+    o.events = reflect.MakeSlice(reflect.SliceOf(ptrType), 0, 1)
+  }
+  event := reflect.New(ptrType.Elem())
+  s.copier(eventPtr, event.Interface())
+  o.events = reflect.Append(o.events, event)
+}
+
+// expire moves the open sessions that ended more than gap before the
+// latest event, or all open sessions if all is true, to the ready
+// sessions.
+func (s *sessionStream) expire(all bool) {
+  var expired []*openSession
+  for len(s.byEnd.sessions) > 0 &&
+      (all || s.latest.Sub(s.byEnd.sessions[0].End) > s.gap) {
+    expired = append(expired, heap.Pop(&s.byEnd).(*openSession))
+  }
+  for _, o := range expired {
+    delete(s.open, o.Key)
+  }
+  sort.Slice(expired, func(i, j int) bool {
+    if !expired[i].Start.Equal(expired[j].Start) {
+      return expired[i].Start.Before(expired[j].Start)
+    }
+    return expired[i].Key < expired[j].Key
+  })
+  s.ready = append(s.ready, expired...)
+}
+
+// sessionHeap orders open sessions by End.
+type sessionHeap struct {
+  sessions []*openSession
+}
+
+func (h *sessionHeap) Len() int {
+  return len(h.sessions)
+}
+
+func (h *sessionHeap) Less(i, j int) bool {
+  return h.sessions[i].End.Before(h.sessions[j].End)
+}
+
+func (h *sessionHeap) Swap(i, j int) {
+  h.sessions[i], h.sessions[j] = h.sessions[j], h.sessions[i]
+  h.sessions[i].index = i
+  h.sessions[j].index = j
+}
+
+func (h *sessionHeap) Push(x interface{}) {
+  o := x.(*openSession)
+  o.index = len(h.sessions)
+  h.sessions = append(h.sessions, o)
+}
+
+func (h *sessionHeap) Pop() interface{} {
+  l := len(h.sessions)
+  result := h.sessions[l - 1]
+  h.sessions = h.sessions[:l - 1]
+  return result
+}
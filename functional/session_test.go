@@ -0,0 +1,114 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+  "time"
+)
+
+type click struct {
+  user string
+  minute int
+}
+
+func clickUser(ptr interface{}) string {
+  return ptr.(*click).user
+}
+
+func clickTime(ptr interface{}) time.Time {
+  return time.Unix(int64(ptr.(*click).minute * 60), 0)
+}
+
+func sessionString(s *Session) string {
+  return fmt.Sprintf(
+      "%s:%d-%d:%d",
+      s.Key, s.Start.Unix() / 60, s.End.Unix() / 60, s.Count)
+}
+
+func TestSessionize(t *testing.T) {
+  clicks := []click{
+      {"a", 0}, {"b", 1}, {"a", 3}, {"b", 4}, {"a", 9}, {"c", 10},
+      {"b", 10}, {"a", 11}}
+  s := &streamCloseChecker{
+      NewStreamFromValues(clicks, nil), &simpleCloseChecker{}}
+  stream := Sessionize(clickUser, clickTime, 5 * time.Minute, s, new(click))
+  var session Session
+  var results []string
+  var err error
+  for err = stream.Next(&session); err == nil; err = stream.Next(&session) {
+    results = append(results, sessionString(&session))
+    if session.Key == "a" && session.Start.Unix() == 9 * 60 {
+      var c click
+      events := session.Stream()
+      var minutes []int
+      for events.Next(&c) == nil {
+        minutes = append(minutes, c.minute)
+      }
+      if output := fmt.Sprintf("%v", minutes); output != "[9 11]" {
+        t.Errorf("Expected [9 11], got %v", output)
+      }
+    }
+  }
+  expected := "[a:0-3:2 b:1-4:2 a:9-11:2 b:10-10:1 c:10-10:1]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+  verifyDone(t, stream, &session, err)
+  verifyCloseCalled(t, s)
+}
+
+func TestSessionizeLateAndDiscard(t *testing.T) {
+  clicks := []click{{"a", 10}, {"a", 8}, {"b", 20}, {"a", 12}}
+  stream := SessionizeOpt(
+      clickUser,
+      clickTime,
+      5 * time.Minute,
+      NewStreamFromValues(clicks, nil),
+      new(click),
+      false)
+  var session Session
+  var results []string
+  for stream.Next(&session) == nil {
+    results = append(results, sessionString(&session))
+    if session.Events != nil {
+      t.Error("Expected no events kept")
+    }
+  }
+  expected := "[a:8-10:2 a:12-12:1 b:20-20:1]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestSessionizeLateBeyondGap(t *testing.T) {
+  clicks := []click{{"a", 20}, {"a", 21}, {"a", 2}, {"a", 18}, {"a", 23}}
+  stream := Sessionize(
+      clickUser,
+      clickTime,
+      5 * time.Minute,
+      NewStreamFromValues(clicks, nil),
+      new(click))
+  var session Session
+  var results []string
+  for stream.Next(&session) == nil {
+    results = append(results, sessionString(&session))
+  }
+  expected := "[a:2-2:1 a:18-23:4]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestSessionizeError(t *testing.T) {
+  s := Concat(
+      NewStreamFromValues([]click{{"a", 0}}, nil), errorStream{scanError})
+  stream := Sessionize(clickUser, clickTime, time.Minute, s, new(click))
+  if err := stream.Next(new(Session)); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+}
@@ -0,0 +1,127 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// Package statsmap provides stateful Mappers that smooth Streams of
+// float64. Because they remember values they have already seen, each
+// Mapper in this package should be used with only one Stream and cannot be
+// used by multiple goroutines simultaneously.
+package statsmap
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "sort"
+)
+
+// MovingAverage returns a Mapper from float64 to float64 that maps each
+// value to the mean of it and up to window - 1 values preceding it.
+// window must be greater than 0.
+func MovingAverage(window int) functional.Mapper {
+  return &movingAverage{ring: newRing(window)}
+}
+
+// MovingMedian returns a Mapper from float64 to float64 that maps each
+// value to the median of it and up to window - 1 values preceding it.
+// When there is an even number of values, the median is the mean of the
+// two middle values. window must be greater than 0.
+func MovingMedian(window int) functional.Mapper {
+  return &movingMedian{ring: newRing(window)}
+}
+
+// EWMA returns a Mapper from float64 to float64 that maps each value to
+// its exponentially weighted moving average. The first value maps to
+// itself; after that, each value x maps to alpha * x + (1 - alpha) * prev
+// where prev is what the preceding value mapped to. alpha must be between
+// 0 and 1.
+func EWMA(alpha float64) functional.Mapper {
+  if alpha < 0 || alpha > 1 {
+    panic("alpha must be between 0 and 1.")
+  }
+  return &ewma{alpha: alpha}
+}
+
+type ring struct {
+  values []float64
+  idx int
+  full bool
+}
+
+func newRing(window int) *ring {
+  if window <= 0 {
+    panic("window must be greater than 0.")
+  }
+  return &ring{values: make([]float64, window)}
+}
+
+// add adds x returning the value it displaced and whether a value was
+// displaced.
+func (r *ring) add(x float64) (float64, bool) {
+  old, displaced := r.values[r.idx], r.full
+  r.values[r.idx] = x
+  r.idx++
+  if r.idx == len(r.values) {
+    r.idx = 0
+    r.full = true
+  }
+  return old, displaced
+}
+
+func (r *ring) contents() []float64 {
+  if r.full {
+    return r.values
+  }
+  return r.values[:r.idx]
+}
+
+type movingAverage struct {
+  *ring
+  sum float64
+}
+
+func (m *movingAverage) Map(srcPtr, destPtr interface{}) error {
+  x := *srcPtr.(*float64)
+  old, displaced := m.add(x)
+  m.sum += x
+  if displaced {
+    m.sum -= old
+  }
+  *destPtr.(*float64) = m.sum / float64(len(m.contents()))
+  return nil
+}
+
+type movingMedian struct {
+  *ring
+  scratch []float64
+}
+
+func (m *movingMedian) Map(srcPtr, destPtr interface{}) error {
+  m.add(*srcPtr.(*float64))
+  m.scratch = append(m.scratch[:0], m.contents()...)
+  sort.Float64s(m.scratch)
+  l := len(m.scratch)
+  if l % 2 == 1 {
+    *destPtr.(*float64) = m.scratch[l / 2]
+  } else {
+    *destPtr.(*float64) = (m.scratch[l / 2 - 1] + m.scratch[l / 2]) / 2
+  }
+  return nil
+}
+
+type ewma struct {
+  alpha float64
+  prev float64
+  started bool
+}
+
+func (m *ewma) Map(srcPtr, destPtr interface{}) error {
+  x := *srcPtr.(*float64)
+  if m.started {
+    m.prev = m.alpha * x + (1 - m.alpha) * m.prev
+  } else {
+    m.prev = x
+    m.started = true
+  }
+  *destPtr.(*float64) = m.prev
+  return nil
+}
@@ -0,0 +1,40 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package statsmap
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestMovingAverage(t *testing.T) {
+  verifyMapped(t, MovingAverage(3), []float64{3, 6, 9, 3, 0}, "[3 4.5 6 6 4]")
+}
+
+func TestMovingMedian(t *testing.T) {
+  verifyMapped(t, MovingMedian(3), []float64{3, 9, 6, 1, 0}, "[3 6 6 6 1]")
+}
+
+func TestEWMA(t *testing.T) {
+  verifyMapped(t, EWMA(0.5), []float64{4, 8, 0, 2}, "[4 6 3 2.5]")
+}
+
+func verifyMapped(t *testing.T, m functional.Mapper, values []float64, expected string) {
+  s := functional.Map(m, functional.NewStreamFromValues(values, nil), new(float64))
+  var results []float64
+  var x float64
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    results = append(results, x)
+  }
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
@@ -0,0 +1,83 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "reflect"
+)
+
+// TB is the subset of testing.TB that the assertion functions in this
+// package use. *testing.T and *testing.B both implement it.
+type TB interface {
+  Errorf(format string, args ...interface{})
+}
+
+// AssertEmits consumes s, a Stream of T, and reports an error through t
+// unless s emits exactly the values in expected, a []T, followed by Done.
+// Values are compared with reflect.DeepEqual. A nil expected slice is the
+// same as an empty one. If s reports an error other
+// than Done, AssertEmits closes s. AssertEmits returns true if no errors
+// were reported.
+func AssertEmits(t TB, s functional.Stream, expected interface{}) bool {
+  expectedValue := reflect.ValueOf(expected)
+  if expectedValue.Kind() != reflect.Slice {
+    panic("Slice argument expected")
+  }
+  actual, err := readAll(s, expectedValue.Type())
+  if err != functional.Done {
+    t.Errorf("Expected Done after %v, got %v", actual.Interface(), err)
+    s.Close()
+    return false
+  }
+  if !sameElements(actual, expectedValue) {
+    t.Errorf("Expected %v, got %v", expected, actual.Interface())
+    return false
+  }
+  return true
+}
+
+// sameElements compares slices a and b element by element so that a nil
+// slice equals an empty one.
+func sameElements(a, b reflect.Value) bool {
+  if a.Len() != b.Len() {
+    return false
+  }
+  for i := 0; i < a.Len(); i++ {
+    if !reflect.DeepEqual(a.Index(i).Interface(), b.Index(i).Interface()) {
+      return false
+    }
+  }
+  return true
+}
+
+// AssertDoneBehavior reports an error through t unless calling Next on s
+// returns Done and calling Close on s returns nil. Call it on a Stream
+// that has already returned Done to verify that it keeps behaving as an
+// exhausted Stream should. ptr is a *T for the calls to Next.
+// AssertDoneBehavior returns true if no errors were reported.
+func AssertDoneBehavior(t TB, s functional.Stream, ptr interface{}) bool {
+  result := true
+  if err := s.Next(ptr); err != functional.Done {
+    t.Errorf("Expected Next to keep returning Done, got %v", err)
+    result = false
+  }
+  if err := s.Close(); err != nil {
+    t.Errorf("Expected nil when closing Done stream, got %v", err)
+    result = false
+  }
+  return result
+}
+
+func readAll(s functional.Stream, sliceType reflect.Type) (reflect.Value, error) {
+  result := reflect.MakeSlice(sliceType, 0, 0)
+  ptr := reflect.New(sliceType.Elem())
+  err := s.Next(ptr.Interface())
+  for ; err == nil; err = s.Next(ptr.Interface()) {
+    result = reflect.Append(result, ptr.Elem())
+  }
+  return result, err
+}
@@ -0,0 +1,73 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestAssertEmits(t *testing.T) {
+  s := functional.Slice(functional.Count(), 2, 5)
+  if !AssertEmits(t, s, []int{2, 3, 4}) {
+    t.Error("Expected AssertEmits to succeed.")
+  }
+  AssertDoneBehavior(t, s, new(int))
+}
+
+func TestAssertEmitsEmpty(t *testing.T) {
+  if !AssertEmits(t, functional.NilStream(), []int(nil)) {
+    t.Error("Expected AssertEmits to succeed with nil slice.")
+  }
+  if !AssertEmits(t, functional.NilStream(), []int{}) {
+    t.Error("Expected AssertEmits to succeed with empty slice.")
+  }
+  r := &fakeTB{}
+  if AssertEmits(r, functional.Slice(functional.Count(), 0, 1), []int(nil)) {
+    t.Error("Expected AssertEmits to fail.")
+  }
+}
+
+func TestAssertEmitsMismatch(t *testing.T) {
+  r := &fakeTB{}
+  if AssertEmits(r, functional.Slice(functional.Count(), 0, 2), []int{0, 2}) {
+    t.Error("Expected AssertEmits to fail.")
+  }
+  if output := len(r.errors); output != 1 {
+    t.Errorf("Expected 1 error, got %v", output)
+  }
+}
+
+func TestAssertEmitsError(t *testing.T) {
+  r := &fakeTB{}
+  s := NewScriptedStream([]int{0}, nil).ErrorAt(1, scriptError)
+  if AssertEmits(r, s, []int{0}) {
+    t.Error("Expected AssertEmits to fail.")
+  }
+  if output := s.CloseCount(); output != 1 {
+    t.Errorf("Expected stream to be closed, got %v closes", output)
+  }
+}
+
+func TestAssertDoneBehavior(t *testing.T) {
+  r := &fakeTB{}
+  s := NewScriptedStream([]int{}, nil).CloseFails(closeError)
+  if AssertDoneBehavior(r, s, new(int)) {
+    t.Error("Expected AssertDoneBehavior to fail.")
+  }
+  if output := len(r.errors); output != 1 {
+    t.Errorf("Expected 1 error, got %v", output)
+  }
+}
+
+type fakeTB struct {
+  errors []string
+}
+
+func (f *fakeTB) Errorf(format string, args ...interface{}) {
+  f.errors = append(f.errors, fmt.Sprintf(format, args...))
+}
@@ -0,0 +1,101 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io/ioutil"
+  "strings"
+)
+
+// GoldenConsumer is an ErrorReportingConsumer of T that renders each T
+// value it consumes as one line of text and compares the result to the
+// contents of a golden file. When in update mode, it rewrites the golden
+// file instead.
+type GoldenConsumer struct {
+  path string
+  ptr interface{}
+  format func(ptr interface{}) string
+  update bool
+  err error
+}
+
+// NewGoldenConsumer returns a new GoldenConsumer. path is the golden file;
+// ptr is a *T that temporarily holds consumed values; format renders the
+// T value ptr points to as a single line of text without the end of line
+// character. If update is true, Consume writes the golden file rather than
+// comparing against it. Tests typically pass the value of their own
+// -update flag for update.
+func NewGoldenConsumer(
+    path string,
+    ptr interface{},
+    format func(ptr interface{}) string,
+    update bool) *GoldenConsumer {
+  return &GoldenConsumer{path: path, ptr: ptr, format: format, update: update}
+}
+
+// Consume renders the values in s, a Stream of T, and compares or updates
+// the golden file. Consume closes s.
+func (g *GoldenConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  g.err = nil
+  var lines []string
+  err := s.Next(g.ptr)
+  for ; err == nil; err = s.Next(g.ptr) {
+    lines = append(lines, g.format(g.ptr))
+  }
+  if err != functional.Done {
+    g.err = err
+    return
+  }
+  if g.update {
+    g.err = ioutil.WriteFile(g.path, []byte(joinLines(lines)), 0644)
+    return
+  }
+  contents, err := ioutil.ReadFile(g.path)
+  if err != nil {
+    g.err = err
+    return
+  }
+  g.err = compareLines(g.path, splitLines(string(contents)), lines)
+}
+
+// Error returns the mismatch or other error from the last call to Consume
+// or nil if the rendered values matched the golden file.
+func (g *GoldenConsumer) Error() error {
+  return g.err
+}
+
+func joinLines(lines []string) string {
+  if len(lines) == 0 {
+    return ""
+  }
+  return strings.Join(lines, "\n") + "\n"
+}
+
+func splitLines(contents string) []string {
+  if contents == "" {
+    return nil
+  }
+  return strings.Split(strings.TrimSuffix(contents, "\n"), "\n")
+}
+
+func compareLines(path string, expected, actual []string) error {
+  for i := 0; i < len(expected) && i < len(actual); i++ {
+    if expected[i] != actual[i] {
+      return fmt.Errorf(
+          "streamtest: %s line %d: expected %q, got %q",
+          path, i + 1, expected[i], actual[i])
+    }
+  }
+  if len(expected) != len(actual) {
+    return fmt.Errorf(
+        "streamtest: %s: expected %d lines, got %d",
+        path, len(expected), len(actual))
+  }
+  return nil
+}
@@ -0,0 +1,61 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "io/ioutil"
+  "os"
+  "path/filepath"
+  "testing"
+)
+
+func TestGoldenConsumer(t *testing.T) {
+  dir, err := ioutil.TempDir("", "streamtest")
+  if err != nil {
+    t.Fatal(err)
+  }
+  defer os.RemoveAll(dir)
+  path := filepath.Join(dir, "squares.golden")
+  newConsumer := func(update bool) *GoldenConsumer {
+    return NewGoldenConsumer(path, new(int), formatInt, update)
+  }
+  g := newConsumer(true)
+  g.Consume(functional.Slice(functional.Count(), 0, 3))
+  if err := g.Error(); err != nil {
+    t.Fatalf("Got error updating golden file: %v", err)
+  }
+  g = newConsumer(false)
+  g.Consume(functional.Slice(functional.Count(), 0, 3))
+  if err := g.Error(); err != nil {
+    t.Errorf("Expected match, got %v", err)
+  }
+  g.Consume(functional.Slice(functional.Count(), 1, 4))
+  if g.Error() == nil {
+    t.Error("Expected mismatch.")
+  }
+  g.Consume(functional.Slice(functional.Count(), 0, 2))
+  if g.Error() == nil {
+    t.Error("Expected mismatch on length.")
+  }
+}
+
+func TestGoldenConsumerClosesStream(t *testing.T) {
+  s := NewScriptedStream([]int{}, nil).ErrorAt(0, scriptError)
+  g := NewGoldenConsumer("nonexistent", new(int), formatInt, false)
+  g.Consume(s)
+  if err := g.Error(); err != scriptError {
+    t.Errorf("Expected scriptError, got %v", err)
+  }
+  if output := s.CloseCount(); output != 1 {
+    t.Errorf("Expected 1 Close call, got %v", output)
+  }
+}
+
+func formatInt(ptr interface{}) string {
+  return fmt.Sprintf("%d", *ptr.(*int))
+}
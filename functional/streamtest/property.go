@@ -0,0 +1,142 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "github.com/keep94/gofunctional2/functional"
+  "math/rand"
+)
+
+// CheckConfig controls how CheckStream drives a pipeline. The zero value
+// for CheckConfig is a valid configuration.
+type CheckConfig struct {
+  // Iterations is the number of randomized runs. 0 means 100.
+  Iterations int
+  // MaxLen is the maximum number of values the source Stream emits.
+  // 0 means 20.
+  MaxLen int
+  // Rand is the source of randomness. nil means a source seeded with 1
+  // so that failures are reproducible.
+  Rand *rand.Rand
+}
+
+// CheckStream repeatedly builds a pipeline with factory and consumes it in
+// randomized ways verifying the invariants of the Stream contract.
+// factory receives a source Stream of int that emits 0, 1, 2, ... up to a
+// random length and must return a Stream of T built on top of it. ptr is
+// a *T that receives emitted values. invariant, which may be nil, is
+// called on each emitted value and may return an error describing a
+// violation. On each run, CheckStream consumes a random number of values,
+// sometimes through functional.Slice with random bounds, and then either
+// reads to the end or closes the pipeline early. Violations, reported
+// through t, are: not closing the source by the time the pipeline is
+// closed, whether it was read to the end or closed early; calling Next on
+// the source after closing it; emitting a value after
+// Done; and returning something other than nil from Close after Done.
+// CheckStream returns true if no violations were reported.
+func CheckStream(
+    t TB,
+    factory func(source functional.Stream) functional.Stream,
+    ptr interface{},
+    invariant func(ptr interface{}) error,
+    config *CheckConfig) bool {
+  if config == nil {
+    config = &CheckConfig{}
+  }
+  iterations := config.Iterations
+  if iterations == 0 {
+    iterations = 100
+  }
+  maxLen := config.MaxLen
+  if maxLen == 0 {
+    maxLen = 20
+  }
+  rnd := config.Rand
+  if rnd == nil {
+    rnd = rand.New(rand.NewSource(1))
+  }
+  for i := 0; i < iterations; i++ {
+    if !checkOnce(t, i, factory, ptr, invariant, rnd, maxLen) {
+      return false
+    }
+  }
+  return true
+}
+
+func checkOnce(
+    t TB,
+    iteration int,
+    factory func(source functional.Stream) functional.Stream,
+    ptr interface{},
+    invariant func(ptr interface{}) error,
+    rnd *rand.Rand,
+    maxLen int) bool {
+  n := rnd.Intn(maxLen + 1)
+  source := &checkedSource{Stream: functional.Slice(functional.Count(), 0, n)}
+  s := factory(source)
+  if rnd.Intn(2) == 0 {
+    start := rnd.Intn(n + 2)
+    s = functional.Slice(s, start, start + rnd.Intn(n + 2) - 1)
+  }
+  toRead := rnd.Intn(n + 2)
+  var err error
+  for read := 0; read < toRead; read++ {
+    if err = s.Next(ptr); err != nil {
+      break
+    }
+    if invariant != nil {
+      if ierr := invariant(ptr); ierr != nil {
+        t.Errorf("Run %d: invariant violated: %v", iteration, ierr)
+        s.Close()
+        return false
+      }
+    }
+  }
+  result := true
+  switch {
+  case err == functional.Done:
+    for j := 0; j < 3; j++ {
+      if nerr := s.Next(ptr); nerr != functional.Done {
+        t.Errorf("Run %d: expected Next to keep returning Done, got %v", iteration, nerr)
+        result = false
+        break
+      }
+    }
+    if cerr := s.Close(); cerr != nil {
+      t.Errorf("Run %d: expected nil when closing Done stream, got %v", iteration, cerr)
+      result = false
+    }
+  default:
+    s.Close()
+  }
+  if !source.closed {
+    t.Errorf("Run %d: expected source to be closed", iteration)
+    result = false
+  }
+  if source.nextAfterClose {
+    t.Errorf("Run %d: Next called on source after Close", iteration)
+    result = false
+  }
+  return result
+}
+
+type checkedSource struct {
+  functional.Stream
+  closed bool
+  nextAfterClose bool
+}
+
+func (s *checkedSource) Next(ptr interface{}) error {
+  if s.closed {
+    s.nextAfterClose = true
+  }
+  return s.Stream.Next(ptr)
+}
+
+func (s *checkedSource) Close() error {
+  s.closed = true
+  return s.Stream.Close()
+}
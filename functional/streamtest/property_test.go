@@ -0,0 +1,90 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "errors"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+func TestCheckStream(t *testing.T) {
+  even := functional.NewFilterer(func(ptr interface{}) error {
+    if *ptr.(*int) % 2 == 0 {
+      return nil
+    }
+    return functional.Skipped
+  })
+  CheckStream(
+      t,
+      func(source functional.Stream) functional.Stream {
+        return functional.Filter(even, source)
+      },
+      new(int),
+      func(ptr interface{}) error {
+        if *ptr.(*int) % 2 != 0 {
+          return errors.New("odd value emitted")
+        }
+        return nil
+      },
+      nil)
+}
+
+func TestCheckStreamLeak(t *testing.T) {
+  r := &fakeTB{}
+  if CheckStream(
+      r,
+      func(source functional.Stream) functional.Stream {
+        return functional.NoCloseStream(source)
+      },
+      new(int),
+      nil,
+      &CheckConfig{MaxLen: 5}) {
+    t.Error("Expected CheckStream to detect unclosed source.")
+  }
+}
+
+func TestCheckStreamSourceNeverClosed(t *testing.T) {
+  r := &fakeTB{}
+  if CheckStream(
+      r,
+      func(source functional.Stream) functional.Stream {
+        return functional.Cycle(func() functional.Stream {
+          return functional.Slice(functional.Count(), 0, 1)
+        })
+      },
+      new(int),
+      nil,
+      &CheckConfig{MaxLen: 3}) {
+    t.Error("Expected CheckStream to detect problems.")
+  }
+}
+
+func TestCheckStreamRepeatedClose(t *testing.T) {
+  CheckStream(
+      t,
+      func(source functional.Stream) functional.Stream {
+        return functional.Slice(source, 0, 3)
+      },
+      new(int),
+      nil,
+      nil)
+  CheckStream(
+      t,
+      func(source functional.Stream) functional.Stream {
+        return functional.TakeWhile(
+            functional.NewFilterer(func(ptr interface{}) error {
+              if *ptr.(*int) < 5 {
+                return nil
+              }
+              return functional.Skipped
+            }),
+            source)
+      },
+      new(int),
+      nil,
+      nil)
+}
@@ -0,0 +1,193 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// Package streamtest provides utilities for testing code that produces or
+// consumes Streams.
+package streamtest
+
+import (
+  "errors"
+  "github.com/keep94/gofunctional2/functional"
+  "reflect"
+)
+
+// CallKind identifies a method called on a ScriptedStream.
+type CallKind int
+
+const (
+  NextCall CallKind = iota
+  CloseCall
+)
+
+func (k CallKind) String() string {
+  if k == NextCall {
+    return "Next"
+  }
+  return "Close"
+}
+
+// Call records a single call made on a ScriptedStream.
+type Call struct {
+  Kind CallKind
+  // Result is what the call returned.
+  Result error
+}
+
+// ScriptedStream is a Stream of T whose behavior is scripted ahead of
+// time. It emits configured values, returns injected errors from chosen
+// calls to Next, optionally fails Close, and records every call made on it.
+// ScriptedStream never closes itself. A ScriptedStream cannot be used by
+// multiple goroutines simultaneously.
+type ScriptedStream struct {
+  values functional.Stream
+  errors map[int]error
+  closeError error
+  nextCount int
+  done bool
+  calls []Call
+}
+
+// NewScriptedStream returns a ScriptedStream that emits the values in
+// aSlice, a []T. c is a Copier of T; nil means use regular assignment.
+func NewScriptedStream(aSlice interface{}, c functional.Copier) *ScriptedStream {
+  return &ScriptedStream{
+      values: functional.NewStreamFromValues(aSlice, c),
+      errors: make(map[int]error)}
+}
+
+// ErrorAt makes the Next call with 0-based index pos return err instead of
+// emitting a value. The value that would have been emitted is emitted by
+// the following call to Next instead. ErrorAt returns s for chaining.
+func (s *ScriptedStream) ErrorAt(pos int, err error) *ScriptedStream {
+  s.errors[pos] = err
+  return s
+}
+
+// CloseFails makes every call to Close return err. CloseFails returns s
+// for chaining.
+func (s *ScriptedStream) CloseFails(err error) *ScriptedStream {
+  s.closeError = err
+  return s
+}
+
+func (s *ScriptedStream) Next(ptr interface{}) error {
+  result := s.next(ptr)
+  s.calls = append(s.calls, Call{Kind: NextCall, Result: result})
+  return result
+}
+
+func (s *ScriptedStream) Close() error {
+  s.calls = append(s.calls, Call{Kind: CloseCall, Result: s.closeError})
+  return s.closeError
+}
+
+// Calls returns all the calls made on s so far in the order they were made.
+func (s *ScriptedStream) Calls() []Call {
+  return s.calls
+}
+
+// NextCount returns the number of times Next was called on s.
+func (s *ScriptedStream) NextCount() int {
+  return s.count(NextCall)
+}
+
+// CloseCount returns the number of times Close was called on s.
+func (s *ScriptedStream) CloseCount() int {
+  return s.count(CloseCall)
+}
+
+// Rows is a fake functional.Rows that also implements io.Closer. It
+// records how many times Close was called.
+type Rows struct {
+  rows [][]interface{}
+  idx int
+  closeError error
+  closeCount int
+}
+
+// NewRows returns a new Rows. Each element of rows is one row and contains
+// the column values in order.
+func NewRows(rows ...[]interface{}) *Rows {
+  return &Rows{rows: rows}
+}
+
+// CloseFails makes every call to Close return err. CloseFails returns r
+// for chaining.
+func (r *Rows) CloseFails(err error) *Rows {
+  r.closeError = err
+  return r
+}
+
+func (r *Rows) Next() bool {
+  if r.idx == len(r.rows) {
+    return false
+  }
+  r.idx++
+  return true
+}
+
+func (r *Rows) Scan(args ...interface{}) error {
+  if r.idx == 0 {
+    return errors.New("streamtest: Scan called before Next.")
+  }
+  row := r.rows[r.idx - 1]
+  if len(args) != len(row) {
+    return errors.New("streamtest: wrong number of arguments to Scan.")
+  }
+  for i := range args {
+    reflect.Indirect(reflect.ValueOf(args[i])).Set(reflect.ValueOf(row[i]))
+  }
+  return nil
+}
+
+func (r *Rows) Close() error {
+  r.closeCount++
+  return r.closeError
+}
+
+// CloseCount returns the number of times Close was called on r.
+func (r *Rows) CloseCount() int {
+  return r.closeCount
+}
+
+// Filterer returns a Filterer that always returns err.
+func Filterer(err error) functional.Filterer {
+  return functional.NewFilterer(func(ptr interface{}) error {
+    return err
+  })
+}
+
+// Mapper returns a Mapper that always returns err without mapping.
+func Mapper(err error) functional.Mapper {
+  return functional.NewMapper(func(srcPtr, destPtr interface{}) error {
+    return err
+  })
+}
+
+func (s *ScriptedStream) next(ptr interface{}) error {
+  pos := s.nextCount
+  s.nextCount++
+  if err, ok := s.errors[pos]; ok {
+    return err
+  }
+  if s.done {
+    return functional.Done
+  }
+  err := s.values.Next(ptr)
+  if err == functional.Done {
+    s.done = true
+  }
+  return err
+}
+
+func (s *ScriptedStream) count(kind CallKind) int {
+  result := 0
+  for i := range s.calls {
+    if s.calls[i].Kind == kind {
+      result++
+    }
+  }
+  return result
+}
@@ -0,0 +1,94 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package streamtest
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+var (
+  scriptError = errors.New("streamtest: script error.")
+  closeError = errors.New("streamtest: close error.")
+)
+
+func TestScriptedStream(t *testing.T) {
+  s := NewScriptedStream([]int{3, 4}, nil).ErrorAt(1, scriptError)
+  var x int
+  var results []string
+  for i := 0; i < 5; i++ {
+    err := s.Next(&x)
+    if err == nil {
+      results = append(results, fmt.Sprintf("%d", x))
+    } else {
+      results = append(results, err.Error())
+    }
+  }
+  expected := "[3 streamtest: script error. 4 " +
+      "functional: End of Stream reached. functional: End of Stream reached.]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+  if output := s.NextCount(); output != 5 {
+    t.Errorf("Expected 5 Next calls, got %v", output)
+  }
+}
+
+func TestScriptedStreamClose(t *testing.T) {
+  s := NewScriptedStream([]int{}, nil).CloseFails(closeError)
+  if err := s.Next(new(int)); err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if err := s.Close(); err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if output := s.CloseCount(); output != 1 {
+    t.Errorf("Expected 1 Close call, got %v", output)
+  }
+  calls := s.Calls()
+  if len(calls) != 2 || calls[0].Kind != NextCall || calls[1].Kind != CloseCall || calls[1].Result != closeError {
+    t.Errorf("Unexpected calls recorded: %v", calls)
+  }
+}
+
+func TestRows(t *testing.T) {
+  rows := NewRows([]interface{}{3, "foo"}, []interface{}{4, "bar"})
+  s := functional.ReadRows(rows)
+  var x idAndName
+  var results []idAndName
+  err := s.Next(&x)
+  for ; err == nil; err = s.Next(&x) {
+    results = append(results, x)
+  }
+  if err != functional.Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[{3 foo} {4 bar}]" {
+    t.Errorf("Expected [{3 foo} {4 bar}], got %v", output)
+  }
+  if output := rows.CloseCount(); output != 1 {
+    t.Errorf("Expected 1 Close call, got %v", output)
+  }
+}
+
+func TestFilterer(t *testing.T) {
+  s := functional.Filter(Filterer(scriptError), functional.Count())
+  if err := s.Next(new(int)); err != scriptError {
+    t.Errorf("Expected scriptError, got %v", err)
+  }
+  s.Close()
+}
+
+type idAndName struct {
+  id int
+  name string
+}
+
+func (t *idAndName) Ptrs() []interface{} {
+  return []interface{}{&t.id, &t.name}
+}
@@ -0,0 +1,259 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "math"
+  "reflect"
+  "strconv"
+  "strings"
+)
+
+// HeaderStrictness controls how NewHeaderMapper treats a header that does
+// not match the struct exactly. Values may be combined with |.
+type HeaderStrictness int
+
+const (
+  // StrictHeader requires every column to match a field and every field
+  // to match a column.
+  StrictHeader HeaderStrictness = 0
+  // IgnoreMissingFields allows struct fields that no column matches.
+  // They are left unchanged.
+  IgnoreMissingFields HeaderStrictness = 1 << iota
+  // IgnoreExtraColumns allows columns that match no struct field.
+  // They are ignored.
+  IgnoreExtraColumns
+)
+
+// NewHeaderMapper returns a Mapper that fills in a struct of type T, where
+// example is a T, from a row laid out as header describes. The source of
+// the Mapper may be a []string such as a CSV record, in which case each
+// string is parsed according to the type of its field, or a Tuple such as
+// a DynamicTuple, in which case each value is converted to the type of its
+// field. Numbers convert only to numbers of the same family, integer or
+// floating point, and only if they fit; any value of a basic kind may be
+// stored in a string field, where it is formatted with strconv. Other
+// conversions are errors. A column matches the exported field whose `functional` tag is
+// the column name or, failing that, whose name equals the column name
+// ignoring case. NewHeaderMapper returns an error if header does not match
+// T as strictness requires. Two columns matching the same field are an
+// error unless strictness includes IgnoreExtraColumns, in which case
+// only the first is used.
+func NewHeaderMapper(
+    header []string,
+    example interface{},
+    strictness HeaderStrictness) (Mapper, error) {
+  t := reflect.TypeOf(example)
+  if t.Kind() != reflect.Struct {
+    return nil, fmt.Errorf("functional: %v is not a struct.", t)
+  }
+  fields := make([]int, len(header))
+  used := make([]bool, t.NumField())
+  for i, column := range header {
+    fields[i] = matchField(t, column)
+    if fields[i] == -1 {
+      if strictness & IgnoreExtraColumns == 0 {
+        return nil, fmt.Errorf(
+            "functional: No field in %v for column %q.", t, column)
+      }
+      continue
+    }
+    if used[fields[i]] {
+      // A second column for the same field counts as an extra column.
+      if strictness & IgnoreExtraColumns == 0 {
+        return nil, fmt.Errorf(
+            "functional: Column %q duplicates field %s of %v.",
+            column, t.Field(fields[i]).Name, t)
+      }
+      fields[i] = -1
+      continue
+    }
+    used[fields[i]] = true
+  }
+  if strictness & IgnoreMissingFields == 0 {
+    for i := range used {
+      if !used[i] && t.Field(i).PkgPath == "" {
+        return nil, fmt.Errorf(
+            "functional: No column for field %s of %v.", t.Field(i).Name, t)
+      }
+    }
+  }
+  return &headerMapper{header: header, fields: fields}, nil
+}
+
+type headerMapper struct {
+  header []string
+  fields []int
+}
+
+func (m *headerMapper) Map(srcPtr, destPtr interface{}) error {
+  var values []reflect.Value
+  if record, ok := srcPtr.(*[]string); ok {
+    values = make([]reflect.Value, len(*record))
+    for i := range *record {
+      values[i] = reflect.ValueOf((*record)[i])
+    }
+  } else {
+    ptrs := srcPtr.(Tuple).Ptrs()
+    values = make([]reflect.Value, len(ptrs))
+    for i := range ptrs {
+      values[i] = reflect.ValueOf(ptrs[i]).Elem()
+    }
+  }
+  if len(values) != len(m.fields) {
+    return fmt.Errorf(
+        "functional: Got %d columns, expected %d.", len(values), len(m.fields))
+  }
+  dest := reflect.ValueOf(destPtr).Elem()
+  for i, idx := range m.fields {
+    if idx == -1 {
+      continue
+    }
+    if err := assignColumn(dest.Field(idx), values[i]); err != nil {
+      return fmt.Errorf("functional: Column %q: %v", m.header[i], err)
+    }
+  }
+  return nil
+}
+
+func matchField(t reflect.Type, column string) int {
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if f.PkgPath == "" && f.Tag.Get("functional") == column {
+      return i
+    }
+  }
+  for i := 0; i < t.NumField(); i++ {
+    f := t.Field(i)
+    if f.PkgPath == "" && f.Tag.Get("functional") == "" && strings.EqualFold(f.Name, column) {
+      return i
+    }
+  }
+  return -1
+}
+
+func assignColumn(dest, src reflect.Value) error {
+  if src.Kind() == reflect.Interface {
+    if src.IsNil() {
+      dest.Set(reflect.Zero(dest.Type()))
+      return nil
+    }
+    src = src.Elem()
+  }
+  if src.Kind() == reflect.String && dest.Kind() != reflect.String {
+    return parseInto(dest, src.String())
+  }
+  if src.Kind() == reflect.Slice && src.Type().Elem().Kind() == reflect.Uint8 && dest.Kind() != reflect.Slice {
+    // Database drivers often return text columns as []byte.
+    return parseInto(dest, string(src.Bytes()))
+  }
+  if src.Type().AssignableTo(dest.Type()) {
+    dest.Set(src)
+    return nil
+  }
+  if dest.Kind() == reflect.String {
+    return formatInto(dest, src)
+  }
+  switch {
+  case isInt(src.Kind()) && isInt(dest.Kind()):
+    if dest.OverflowInt(src.Int()) {
+      return fmt.Errorf("%v overflows %v", src.Int(), dest.Type())
+    }
+    dest.SetInt(src.Int())
+  case isUint(src.Kind()) && isUint(dest.Kind()):
+    if dest.OverflowUint(src.Uint()) {
+      return fmt.Errorf("%v overflows %v", src.Uint(), dest.Type())
+    }
+    dest.SetUint(src.Uint())
+  case isInt(src.Kind()) && isUint(dest.Kind()):
+    if src.Int() < 0 || dest.OverflowUint(uint64(src.Int())) {
+      return fmt.Errorf("%v overflows %v", src.Int(), dest.Type())
+    }
+    dest.SetUint(uint64(src.Int()))
+  case isUint(src.Kind()) && isInt(dest.Kind()):
+    if src.Uint() > math.MaxInt64 || dest.OverflowInt(int64(src.Uint())) {
+      return fmt.Errorf("%v overflows %v", src.Uint(), dest.Type())
+    }
+    dest.SetInt(int64(src.Uint()))
+  case isFloat(src.Kind()) && isFloat(dest.Kind()):
+    if dest.OverflowFloat(src.Float()) {
+      return fmt.Errorf("%v overflows %v", src.Float(), dest.Type())
+    }
+    dest.SetFloat(src.Float())
+  case src.Kind() == dest.Kind() && src.Type().ConvertibleTo(dest.Type()):
+    dest.Set(src.Convert(dest.Type()))
+  default:
+    return fmt.Errorf("cannot convert %v to %v", src.Type(), dest.Type())
+  }
+  return nil
+}
+
+// formatInto stores src formatted as a string in dest.
+func formatInto(dest, src reflect.Value) error {
+  switch {
+  case src.Kind() == reflect.String:
+    dest.SetString(src.String())
+  case src.Kind() == reflect.Bool:
+    dest.SetString(strconv.FormatBool(src.Bool()))
+  case isInt(src.Kind()):
+    dest.SetString(strconv.FormatInt(src.Int(), 10))
+  case isUint(src.Kind()):
+    dest.SetString(strconv.FormatUint(src.Uint(), 10))
+  case isFloat(src.Kind()):
+    dest.SetString(
+        strconv.FormatFloat(src.Float(), 'g', -1, src.Type().Bits()))
+  default:
+    return fmt.Errorf("cannot convert %v to %v", src.Type(), dest.Type())
+  }
+  return nil
+}
+
+func isInt(k reflect.Kind) bool {
+  return k >= reflect.Int && k <= reflect.Int64
+}
+
+func isUint(k reflect.Kind) bool {
+  return k >= reflect.Uint && k <= reflect.Uintptr
+}
+
+func isFloat(k reflect.Kind) bool {
+  return k == reflect.Float32 || k == reflect.Float64
+}
+
+func parseInto(dest reflect.Value, s string) error {
+  switch dest.Kind() {
+  case reflect.Bool:
+    b, err := strconv.ParseBool(s)
+    if err != nil {
+      return err
+    }
+    dest.SetBool(b)
+  case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+    i, err := strconv.ParseInt(s, 10, dest.Type().Bits())
+    if err != nil {
+      return err
+    }
+    dest.SetInt(i)
+  case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+    u, err := strconv.ParseUint(s, 10, dest.Type().Bits())
+    if err != nil {
+      return err
+    }
+    dest.SetUint(u)
+  case reflect.Float32, reflect.Float64:
+    f, err := strconv.ParseFloat(s, dest.Type().Bits())
+    if err != nil {
+      return err
+    }
+    dest.SetFloat(f)
+  case reflect.String:
+    dest.SetString(s)
+  default:
+    return fmt.Errorf("cannot parse into %v", dest.Type())
+  }
+  return nil
+}
@@ -0,0 +1,127 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "testing"
+)
+
+func TestHeaderMapperRecord(t *testing.T) {
+  m, err := NewHeaderMapper(
+      []string{"amount", "ID", "memo", "cleared"}, account{}, StrictHeader)
+  if err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  var a account
+  if err := m.Map(&[]string{"12.5", "7", "rent", "true"}, &a); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if a.Id != 7 || a.Amount != 12.5 || a.Desc != "rent" || !a.Cleared {
+    t.Errorf("Got %v", a)
+  }
+  if err := m.Map(&[]string{"x", "7", "rent", "true"}, &a); err == nil {
+    t.Error("Expected parse error")
+  }
+  if err := m.Map(&[]string{"1"}, &a); err == nil {
+    t.Error("Expected column count error")
+  }
+}
+
+func TestHeaderMapperTuple(t *testing.T) {
+  m, err := NewHeaderMapper(
+      []string{"id", "memo", "other"},
+      account{},
+      IgnoreMissingFields | IgnoreExtraColumns)
+  if err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  tuple := NewDynamicTuple([]string{"id", "memo", "other"})
+  copy(tuple.Values(), []interface{}{int64(9), []byte("food"), 3})
+  a := account{Amount: 1.5}
+  if err := m.Map(tuple, &a); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if a.Id != 9 || a.Desc != "food" || a.Amount != 1.5 {
+    t.Errorf("Got %v", a)
+  }
+}
+
+func TestHeaderMapperConversions(t *testing.T) {
+  m, err := NewHeaderMapper(
+      []string{"id", "memo", "amount"},
+      account{},
+      IgnoreMissingFields)
+  if err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  tuple := NewDynamicTuple([]string{"id", "memo", "amount"})
+  copy(tuple.Values(), []interface{}{uint8(4), int64(65), float32(2.5)})
+  var a account
+  if err := m.Map(tuple, &a); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if a.Id != 4 || a.Desc != "65" || a.Amount != 2.5 {
+    t.Errorf("Got %v", a)
+  }
+  copy(tuple.Values(), []interface{}{1.5, "x", 1.0})
+  if err := m.Map(tuple, &a); err == nil {
+    t.Error("Expected error converting float to int")
+  }
+  copy(tuple.Values(), []interface{}{1, "x", 3})
+  if err := m.Map(tuple, &a); err == nil {
+    t.Error("Expected error converting int to float")
+  }
+  copy(tuple.Values(), []interface{}{uint64(1 << 63), "x", 1.0})
+  if err := m.Map(tuple, &a); err == nil {
+    t.Error("Expected overflow error")
+  }
+  copy(tuple.Values(), []interface{}{1, []int{1}, 1.0})
+  if err := m.Map(tuple, &a); err == nil {
+    t.Error("Expected error converting slice to string")
+  }
+}
+
+func TestHeaderMapperDuplicateColumns(t *testing.T) {
+  header := []string{"id", "amount", "memo", "cleared", "ID"}
+  if _, err := NewHeaderMapper(header, account{}, StrictHeader); err == nil {
+    t.Error("Expected duplicate column error")
+  }
+  m, err := NewHeaderMapper(header, account{}, IgnoreExtraColumns)
+  if err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  var a account
+  if err := m.Map(&[]string{"1", "2", "x", "false", "3"}, &a); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if a.Id != 1 {
+    t.Errorf("Expected first column to win, got %v", a.Id)
+  }
+}
+
+func TestHeaderMapperStrictness(t *testing.T) {
+  if _, err := NewHeaderMapper([]string{"id"}, account{}, StrictHeader); err == nil {
+    t.Error("Expected missing field error")
+  }
+  if _, err := NewHeaderMapper([]string{"id"}, account{}, IgnoreMissingFields); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  header := []string{"id", "amount", "memo", "cleared", "extra"}
+  if _, err := NewHeaderMapper(header, account{}, IgnoreMissingFields); err == nil {
+    t.Error("Expected extra column error")
+  }
+  if _, err := NewHeaderMapper(header, 5, IgnoreExtraColumns); err == nil {
+    t.Error("Expected not a struct error")
+  }
+}
+
+type account struct {
+  Id int
+  Amount float64
+  Desc string `functional:"memo"`
+  Cleared bool
+  hidden int
+}
@@ -0,0 +1,100 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "unicode/utf8"
+)
+
+// TakeBytes returns a Stream that emits the values in s as long as their
+// combined length in bytes is at most n. s is a Stream of string or of
+// []byte. The first value that would go over n is not emitted, and the
+// returned Stream ends there. When end of returned Stream is reached, it
+// automatically closes s if s is not exhausted. Calling Close on returned
+// Stream closes s. If ptr is not a *string or *[]byte, Next returns an
+// error.
+func TakeBytes(n int, s Stream) Stream {
+  return TakeBytesOpt(n, s, false)
+}
+
+// TakeBytesOpt works like TakeBytes except that if truncate is true, the
+// first value that would go over n is cut short to use up what is left
+// of n and emitted as the last value. Strings are cut on a rune boundary
+// so they stay valid UTF-8, which may leave a few bytes of n unused.
+// Useful for building bounded previews of large outputs.
+func TakeBytesOpt(n int, s Stream, truncate bool) Stream {
+  return &takeBytesStream{Stream: s, remaining: n, truncate: truncate}
+}
+
+type takeBytesStream struct {
+  Stream
+  remaining int
+  truncate bool
+  done bool
+}
+
+func (s *takeBytesStream) Next(ptr interface{}) error {
+  if s.done {
+    return Done
+  }
+  switch ptr.(type) {
+    case *string, *[]byte:
+    default:
+      return fmt.Errorf("functional: TakeBytes expects a *string or *[]byte but got a %T", ptr)
+  }
+  if s.remaining <= 0 {
+    return s.end()
+  }
+  err := s.Stream.Next(ptr)
+  if err == Done {
+    s.done = true
+    return Done
+  }
+  if err != nil {
+    return err
+  }
+  length := byteLen(ptr)
+  if length <= s.remaining {
+    s.remaining -= length
+    return nil
+  }
+  if !s.truncate || !truncateBytes(ptr, s.remaining) {
+    return s.end()
+  }
+  s.remaining = 0
+  return nil
+}
+
+func (s *takeBytesStream) end() error {
+  s.done = true
+  return finish(s.Close())
+}
+
+func byteLen(ptr interface{}) int {
+  switch p := ptr.(type) {
+    case *string:
+      return len(*p)
+    case *[]byte:
+      return len(*p)
+  }
+  return 0
+}
+
+// truncateBytes cuts the value ptr points to down to at most n bytes.
+// It returns false if nothing is left.
+func truncateBytes(ptr interface{}, n int) bool {
+  switch p := ptr.(type) {
+    case *string:
+      for n > 0 && !utf8.RuneStart((*p)[n]) {
+        n--
+      }
+      *p = (*p)[:n]
+    case *[]byte:
+      *p = (*p)[:n]
+  }
+  return n > 0
+}
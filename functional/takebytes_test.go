@@ -0,0 +1,72 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestTakeBytes(t *testing.T) {
+  s := &streamCloseChecker{
+      NewStreamFromValues([]string{"abc", "de", "fghi", "j"}, nil),
+      &simpleCloseChecker{}}
+  results, err := toStringArray(TakeBytes(6, s))
+  if output := fmt.Sprintf("%v", results); output != "[abc de]" {
+    t.Errorf("Expected [abc de], got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+  verifyCloseCalled(t, s)
+}
+
+func TestTakeBytesExact(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{closeError: closeError}}
+  stream := TakeBytes(4, Map(IntToString, s, new(int)))
+  var x string
+  var results []string
+  err := stream.Next(&x)
+  for ; err == nil; err = stream.Next(&x) {
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2 3]" {
+    t.Errorf("Expected [0 1 2 3], got %v", output)
+  }
+  if err != closeError {
+    t.Errorf("Expected closeError, got %v", err)
+  }
+  if output := stream.Next(&x); output != Done {
+    t.Errorf("Expected Done, got %v", output)
+  }
+}
+
+func TestTakeBytesTruncate(t *testing.T) {
+  s := NewStreamFromValues([]string{"abc", "défg", "h"}, nil)
+  results, _ := toStringArray(TakeBytesOpt(5, s, true))
+  if output := fmt.Sprintf("%q", results); output != `["abc" "d"]` {
+    t.Errorf("Expected abc d, got %v", output)
+  }
+  b := NewStreamFromValues([][]byte{[]byte("abc"), []byte("défg")}, nil)
+  stream := TakeBytesOpt(5, b, true)
+  var x []byte
+  var total []byte
+  var err error
+  for err = stream.Next(&x); err == nil; err = stream.Next(&x) {
+    total = append(total, x...)
+  }
+  if output := string(total); output != "abcd\xc3" {
+    t.Errorf("Expected abcd and a partial rune, got %q", output)
+  }
+  verifyDone(t, stream, &x, err)
+}
+
+func TestTakeBytesWrongType(t *testing.T) {
+  s := TakeBytes(5, xrange(0, 3))
+  if err := s.Next(new(int)); err == nil || err == Done {
+    t.Errorf("Expected type error, got %v", err)
+  }
+}
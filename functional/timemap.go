@@ -0,0 +1,88 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "time"
+)
+
+// TruncateToDay maps time.Time values to midnight of the same day in
+// their own location.
+var TruncateToDay Mapper = truncateToDay{}
+
+// ParseTime returns a Mapper from string to time.Time that tries each
+// layout in turn with time.Parse and uses the first that works. The Mapper
+// returns an error if no layout works.
+func ParseTime(layouts ...string) Mapper {
+  return ParseTimeIn(nil, layouts...)
+}
+
+// ParseTimeIn works like ParseTime except that times without a zone are
+// taken to be in loc as with time.ParseInLocation. nil loc means UTC.
+func ParseTimeIn(loc *time.Location, layouts ...string) Mapper {
+  if loc == nil {
+    loc = time.UTC
+  }
+  return &parseTime{loc: loc, layouts: layouts}
+}
+
+// TruncateTime returns a Mapper from time.Time to time.Time that rounds
+// each time down to a multiple of d as time.Time.Truncate does. Since
+// Truncate works on absolute time, use TruncateToDay for calendar days
+// in a location other than UTC.
+func TruncateTime(d time.Duration) Mapper {
+  return truncateTime(d)
+}
+
+// InLocation returns a Mapper from time.Time to time.Time that converts
+// each time to loc.
+func InLocation(loc *time.Location) Mapper {
+  return inLocation{loc}
+}
+
+type parseTime struct {
+  loc *time.Location
+  layouts []string
+}
+
+func (m *parseTime) Map(srcPtr, destPtr interface{}) error {
+  s := *srcPtr.(*string)
+  for _, layout := range m.layouts {
+    t, err := time.ParseInLocation(layout, s, m.loc)
+    if err == nil {
+      *destPtr.(*time.Time) = t
+      return nil
+    }
+  }
+  return fmt.Errorf("functional: Cannot parse time %q.", s)
+}
+
+type truncateTime time.Duration
+
+func (m truncateTime) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*time.Time) = srcPtr.(*time.Time).Truncate(time.Duration(m))
+  return nil
+}
+
+type truncateToDay struct {
+}
+
+func (m truncateToDay) Map(srcPtr, destPtr interface{}) error {
+  t := *srcPtr.(*time.Time)
+  year, month, day := t.Date()
+  *destPtr.(*time.Time) = time.Date(year, month, day, 0, 0, 0, 0, t.Location())
+  return nil
+}
+
+type inLocation struct {
+  loc *time.Location
+}
+
+func (m inLocation) Map(srcPtr, destPtr interface{}) error {
+  *destPtr.(*time.Time) = srcPtr.(*time.Time).In(m.loc)
+  return nil
+}
@@ -0,0 +1,58 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "testing"
+  "time"
+)
+
+func TestParseTime(t *testing.T) {
+  m := ParseTime(time.RFC3339, "2006-01-02 15:04", "20060102")
+  var result time.Time
+  if err := m.Map(ptrString("20130514"), &result); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if !result.Equal(time.Date(2013, 5, 14, 0, 0, 0, 0, time.UTC)) {
+    t.Errorf("Got %v", result)
+  }
+  if err := m.Map(ptrString("2013-05-14T10:30:00-07:00"), &result); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if !result.Equal(time.Date(2013, 5, 14, 17, 30, 0, 0, time.UTC)) {
+    t.Errorf("Got %v", result)
+  }
+  if err := m.Map(ptrString("May 14"), &result); err == nil {
+    t.Error("Expected error")
+  }
+}
+
+func TestParseTimeIn(t *testing.T) {
+  loc := time.FixedZone("test", -7 * 3600)
+  var result time.Time
+  ParseTimeIn(loc, "2006-01-02 15:04").Map(ptrString("2013-05-14 10:30"), &result)
+  if !result.Equal(time.Date(2013, 5, 14, 17, 30, 0, 0, time.UTC)) {
+    t.Errorf("Got %v", result)
+  }
+}
+
+func TestTruncateAndLocation(t *testing.T) {
+  loc := time.FixedZone("test", -7 * 3600)
+  src := time.Date(2013, 5, 14, 22, 45, 10, 0, loc)
+  var result time.Time
+  TruncateTime(time.Hour).Map(&src, &result)
+  if !result.Equal(time.Date(2013, 5, 14, 22, 0, 0, 0, loc)) {
+    t.Errorf("Got %v", result)
+  }
+  TruncateToDay.Map(&src, &result)
+  if !result.Equal(time.Date(2013, 5, 14, 0, 0, 0, 0, loc)) {
+    t.Errorf("Got %v", result)
+  }
+  InLocation(time.UTC).Map(&src, &result)
+  if result.Location() != time.UTC || result.Day() != 15 || result.Hour() != 5 {
+    t.Errorf("Got %v", result)
+  }
+}
@@ -0,0 +1,56 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "strings"
+  "unicode"
+)
+
+// TokenOptions controls how text is split into tokens.
+type TokenOptions struct {
+  // Delimiters lists the runes that separate tokens. If empty, any rune
+  // that is not a letter or a digit separates tokens.
+  Delimiters string
+  // Lower, if true, converts tokens to lower case.
+  Lower bool
+}
+
+// TokenMapper returns a Mapper of string to Stream that maps a string to
+// a Stream of string emitting its tokens as opts directs. Empty tokens
+// are never emitted.
+func TokenMapper(opts TokenOptions) Mapper {
+  return tokenMapper{opts}
+}
+
+// Tokenize returns a Stream of string that emits the tokens of each
+// string in s, a Stream of string, in order. For example, Tokenize of
+// the lines of a file as ReadLines returns them emits the words of the
+// file. Calling Close on returned Stream closes s.
+func Tokenize(s Stream, opts TokenOptions) Stream {
+  return Flatten(Map(TokenMapper(opts), s, new(string)))
+}
+
+type tokenMapper struct {
+  opts TokenOptions
+}
+
+func (m tokenMapper) Map(srcPtr, destPtr interface{}) error {
+  text := *srcPtr.(*string)
+  if m.opts.Lower {
+    text = strings.ToLower(text)
+  }
+  tokens := strings.FieldsFunc(text, m.isDelimiter)
+  *destPtr.(*Stream) = NewStreamFromValues(tokens, nil)
+  return nil
+}
+
+func (m tokenMapper) isDelimiter(r rune) bool {
+  if m.opts.Delimiters == "" {
+    return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+  }
+  return strings.ContainsRune(m.opts.Delimiters, r)
+}
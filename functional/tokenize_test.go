@@ -0,0 +1,34 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "strings"
+  "testing"
+)
+
+func TestTokenize(t *testing.T) {
+  r := &readerCloseChecker{
+      strings.NewReader("The cat, the HAT.\n\n42 cats!\n"),
+      &simpleCloseChecker{}}
+  stream := Tokenize(ReadLines(r), TokenOptions{Lower: true})
+  results, err := toStringArray(stream)
+  expected := "[the cat the hat 42 cats]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+  verifyDone(t, stream, new(string), err)
+  verifyCloseCalled(t, r)
+}
+
+func TestTokenizeDelimiters(t *testing.T) {
+  s := NewStreamFromValues([]string{"a,b;;C d", "", ";e"}, nil)
+  results, _ := toStringArray(Tokenize(s, TokenOptions{Delimiters: ",;"}))
+  if output := fmt.Sprintf("%q", results); output != `["a" "b" "C d" "e"]` {
+    t.Errorf("Expected a b C d e, got %v", output)
+  }
+}
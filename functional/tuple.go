@@ -0,0 +1,241 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "errors"
+  "fmt"
+  "reflect"
+)
+
+// NamedTuple is a Tuple whose fields have names.
+type NamedTuple interface {
+  Tuple
+  // Names returns the name of each field in the same order as Ptrs.
+  Names() []string
+}
+
+var (
+  errTupleNotNamed = errors.New("functional: Tuple has no field names.")
+  errNoSuchField = errors.New("functional: No such Tuple field.")
+)
+
+// TupleField returns the value of the field called name in t or nil if t
+// is not a NamedTuple or has no such field.
+func TupleField(t Tuple, name string) interface{} {
+  nt, ok := t.(NamedTuple)
+  if !ok {
+    return nil
+  }
+  idx := fieldIndex(nt.Names(), name)
+  if idx == -1 {
+    return nil
+  }
+  return reflect.ValueOf(nt.Ptrs()[idx]).Elem().Interface()
+}
+
+// MapTuple returns a Mapper from one NamedTuple to another that copies
+// fields by name. Each field of the destination gets the source field
+// with the same name unless renames maps the destination field name to a
+// different source field name. Source fields the destination lacks are
+// dropped, so MapTuple both projects and renames. The Mapper returns an
+// error if the source has no field for some destination field.
+func MapTuple(renames map[string]string) Mapper {
+  return NewMapper(func(srcPtr, destPtr interface{}) error {
+    src, ok := srcPtr.(NamedTuple)
+    if !ok {
+      return errTupleNotNamed
+    }
+    dest, ok := destPtr.(NamedTuple)
+    if !ok {
+      return errTupleNotNamed
+    }
+    srcNames := src.Names()
+    srcPtrs := src.Ptrs()
+    destPtrs := dest.Ptrs()
+    for i, name := range dest.Names() {
+      if srcName, ok := renames[name]; ok {
+        name = srcName
+      }
+      idx := fieldIndex(srcNames, name)
+      if idx == -1 {
+        return errNoSuchField
+      }
+      setField(
+          reflect.ValueOf(destPtrs[i]).Elem(),
+          reflect.ValueOf(srcPtrs[idx]).Elem())
+    }
+    return nil
+  })
+}
+
+// DynamicTuple is a NamedTuple whose fields are interface{} values held in
+// a slice. DynamicTuple lets ReadRows read queries whose columns are not
+// known until run time. Because its fields are shared, copy a DynamicTuple
+// with DynamicTupleCopier rather than simple assignment.
+type DynamicTuple struct {
+  names []string
+  values []interface{}
+  ptrs []interface{}
+}
+
+// DynamicTupleCopier is a Copier of DynamicTuple that gives the destination
+// its own copy of the field values. It is registered with RegisterCopier
+// so that a nil Copier of DynamicTuple means DynamicTupleCopier.
+var DynamicTupleCopier Copier = copyDynamicTuple
+
+func init() {
+  RegisterCopier(DynamicTuple{}, DynamicTupleCopier)
+}
+
+// NewDynamicTuple returns a new DynamicTuple with one field for each name
+// in columnNames. The field values start out nil.
+func NewDynamicTuple(columnNames []string) *DynamicTuple {
+  values := make([]interface{}, len(columnNames))
+  ptrs := make([]interface{}, len(columnNames))
+  for i := range values {
+    ptrs[i] = &values[i]
+  }
+  return &DynamicTuple{names: columnNames, values: values, ptrs: ptrs}
+}
+
+// Ptrs returns a *interface{} for each field.
+func (t *DynamicTuple) Ptrs() []interface{} {
+  return t.ptrs
+}
+
+// Names returns the field names.
+func (t *DynamicTuple) Names() []string {
+  return t.names
+}
+
+// Values returns the field values. Changing the returned slice changes
+// the fields.
+func (t *DynamicTuple) Values() []interface{} {
+  return t.values
+}
+
+// Get returns the value of the field called name or nil if there is
+// no such field.
+func (t *DynamicTuple) Get(name string) interface{} {
+  if idx := fieldIndex(t.names, name); idx != -1 {
+    return t.values[idx]
+  }
+  return nil
+}
+
+func copyDynamicTuple(src, dest interface{}) {
+  s := src.(*DynamicTuple)
+  d := dest.(*DynamicTuple)
+  if len(d.values) != len(s.values) {
+    *d = *NewDynamicTuple(s.names)
+  }
+  d.names = s.names
+  copy(d.values, s.values)
+}
+
+// setField sets dest to src. If src is an interface value, such as a
+// DynamicTuple field, and dest is not, dest gets the value src holds or
+// the zero value if src is nil.
+func setField(dest, src reflect.Value) {
+  if src.Kind() == reflect.Interface && dest.Kind() != reflect.Interface {
+    if src.IsNil() {
+      dest.Set(reflect.Zero(dest.Type()))
+      return
+    }
+    src = src.Elem()
+  }
+  dest.Set(src)
+}
+
+// CheckTuples returns a Stream that emits the same values as s, a Stream
+// of Tuple, but that checks the Tuple passed to the first call of Next
+// before reading anything from s. The Tuple must have arity fields. If
+// types is non-empty, it must have arity elements, and field i of the Tuple
+// must be a pointer to a value of the same type as types[i] unless
+// types[i] is nil. If s comes straight from ReadRows or ReadRowsColumns
+// and its Rows have a Columns() ([]string, error) method as *sql.Rows do,
+// CheckTuples also checks that the query returns the right number of
+// columns. If a check fails, Next returns an error describing the
+// mismatch without reading from s. CheckTuples panics if types is
+// non-empty and does not have arity elements. Calling Close on returned
+// Stream closes s.
+func CheckTuples(s Stream, arity int, types ...interface{}) Stream {
+  if len(types) != 0 && len(types) != arity {
+    panic("types must be empty or have arity elements.")
+  }
+  return &checkTupleStream{Stream: s, arity: arity, types: types}
+}
+
+type columnRows interface {
+  Columns() ([]string, error)
+}
+
+type checkTupleStream struct {
+  Stream
+  arity int
+  types []interface{}
+  checked bool
+}
+
+func (s *checkTupleStream) Next(ptr interface{}) error {
+  if !s.checked {
+    if err := s.check(ptr); err != nil {
+      return err
+    }
+    s.checked = true
+  }
+  return s.Stream.Next(ptr)
+}
+
+func (s *checkTupleStream) check(ptr interface{}) error {
+  t, ok := ptr.(Tuple)
+  if !ok {
+    return fmt.Errorf("functional: expected a Tuple, got a %T", ptr)
+  }
+  ptrs := t.Ptrs()
+  if len(ptrs) != s.arity {
+    return fmt.Errorf("functional: expected a Tuple with %d fields, got %d", s.arity, len(ptrs))
+  }
+  for i := range s.types {
+    if s.types[i] == nil {
+      continue
+    }
+    expected := reflect.PtrTo(reflect.TypeOf(s.types[i]))
+    if actual := reflect.TypeOf(ptrs[i]); actual != expected {
+      return fmt.Errorf("functional: expected Tuple field %d to be a %v, got a %v", i, expected, actual)
+    }
+  }
+  rs, ok := s.Stream.(*rowStream)
+  if !ok {
+    return nil
+  }
+  cr, ok := rs.rows.(columnRows)
+  if !ok {
+    return nil
+  }
+  columns, err := cr.Columns()
+  if err != nil {
+    return err
+  }
+  if rs.indices != nil {
+    if len(columns) != len(rs.indices) {
+      return fmt.Errorf("functional: expected %d columns for indices, got %d", len(rs.indices), len(columns))
+    }
+  } else if len(columns) != s.arity {
+    return fmt.Errorf("functional: expected %d columns, got %d", s.arity, len(columns))
+  }
+  return nil
+}
+
+func fieldIndex(names []string, name string) int {
+  for i := range names {
+    if names[i] == name {
+      return i
+    }
+  }
+  return -1
+}
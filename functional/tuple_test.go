@@ -0,0 +1,210 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "testing"
+)
+
+func TestTupleField(t *testing.T) {
+  p := &person{Name: "Ann", Age: 30}
+  if output := TupleField(p, "Age"); output != 30 {
+    t.Errorf("Expected 30, got %v", output)
+  }
+  if output := TupleField(p, "Missing"); output != nil {
+    t.Errorf("Expected nil, got %v", output)
+  }
+  if output := TupleField(&unnamedTuple{}, "A"); output != nil {
+    t.Errorf("Expected nil, got %v", output)
+  }
+}
+
+func TestMapTuple(t *testing.T) {
+  m := MapTuple(map[string]string{"FullName": "Name"})
+  var l label
+  if err := m.Map(&person{Name: "Bob", Age: 40, City: "Rome"}, &l); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if l.FullName != "Bob" || l.City != "Rome" {
+    t.Errorf("Expected Bob Rome, got %v", l)
+  }
+}
+
+func TestMapTupleErrors(t *testing.T) {
+  var l label
+  if err := MapTuple(nil).Map(&person{}, &l); err != errNoSuchField {
+    t.Errorf("Expected errNoSuchField, got %v", err)
+  }
+  if err := MapTuple(nil).Map(&unnamedTuple{}, &l); err != errTupleNotNamed {
+    t.Errorf("Expected errTupleNotNamed, got %v", err)
+  }
+}
+
+type person struct {
+  Name string
+  Age int
+  City string
+}
+
+func (p *person) Ptrs() []interface{} {
+  return []interface{}{&p.Name, &p.Age, &p.City}
+}
+
+func (p *person) Names() []string {
+  return []string{"Name", "Age", "City"}
+}
+
+type label struct {
+  FullName string
+  City string
+}
+
+func (l *label) Ptrs() []interface{} {
+  return []interface{}{&l.FullName, &l.City}
+}
+
+func (l *label) Names() []string {
+  return []string{"FullName", "City"}
+}
+
+type unnamedTuple struct {
+  A int
+}
+
+func (u *unnamedTuple) Ptrs() []interface{} {
+  return []interface{}{&u.A}
+}
+
+func TestDynamicTuple(t *testing.T) {
+  rows := &interfaceRows{rows: [][]interface{}{{3, "foo"}, {4, "bar"}}}
+  s := ReadRows(rows)
+  tuple := NewDynamicTuple([]string{"id", "name"})
+  var results []*DynamicTuple
+  for s.Next(tuple) == nil {
+    copied := NewDynamicTuple(nil)
+    DynamicTupleCopier(tuple, copied)
+    results = append(results, copied)
+  }
+  if len(results) != 2 {
+    t.Fatalf("Expected 2 results, got %d", len(results))
+  }
+  if results[0].Get("id") != 3 || results[1].Get("name") != "bar" {
+    t.Errorf("Expected 3 and bar, got %v %v", results[0].Values(), results[1].Values())
+  }
+  if TupleField(results[0], "name") != "foo" {
+    t.Errorf("Expected foo, got %v", TupleField(results[0], "name"))
+  }
+  if results[0].Get("missing") != nil {
+    t.Error("Expected nil for missing field")
+  }
+}
+
+func TestDynamicTupleCopierRegistered(t *testing.T) {
+  tuple := NewDynamicTuple([]string{"id"})
+  tuple.Values()[0] = 1
+  var copied DynamicTuple
+  CopierFor(tuple)(tuple, &copied)
+  tuple.Values()[0] = 2
+  if output := copied.Get("id"); output != 1 {
+    t.Errorf("Expected 1, got %v", output)
+  }
+  *copied.Ptrs()[0].(*interface{}) = 3
+  if output := copied.Get("id"); output != 3 {
+    t.Errorf("Expected 3, got %v", output)
+  }
+}
+
+func TestMapTupleFromDynamicTuple(t *testing.T) {
+  tuple := NewDynamicTuple([]string{"FullName", "City"})
+  tuple.Values()[0] = "Ann"
+  var l label
+  if err := MapTuple(nil).Map(tuple, &l); err != nil {
+    t.Fatalf("Expected no error, got %v", err)
+  }
+  if l.FullName != "Ann" || l.City != "" {
+    t.Errorf("Expected Ann and empty city, got %v", l)
+  }
+}
+
+type interfaceRows struct {
+  rows [][]interface{}
+  idx int
+}
+
+func (r *interfaceRows) Next() bool {
+  if r.idx == len(r.rows) {
+    return false
+  }
+  r.idx++
+  return true
+}
+
+func (r *interfaceRows) Scan(args ...interface{}) error {
+  for i := range args {
+    *args[i].(*interface{}) = r.rows[r.idx - 1][i]
+  }
+  return nil
+}
+
+type columnFakeRows struct {
+  *fakeRows
+  columns []string
+}
+
+func (r columnFakeRows) Columns() ([]string, error) {
+  return r.columns, nil
+}
+
+func TestCheckTuples(t *testing.T) {
+  rows := &fakeRows{ids: []int{1}, names: []string{"a"}}
+  s := CheckTuples(ReadRows(rows), 2, 0, "")
+  var row intAndString
+  if err := s.Next(&row); err != nil || row.id != 1 || row.name != "a" {
+    t.Errorf("Expected 1 a, got %v and %v", row, err)
+  }
+  s = CheckTuples(ReadRows(&fakeRows{ids: []int{1}, names: []string{"a"}}), 3)
+  if err := s.Next(&row); err == nil || err.Error() != "functional: expected a Tuple with 3 fields, got 2" {
+    t.Errorf("Expected arity error, got %v", err)
+  }
+  s = CheckTuples(ReadRows(&fakeRows{ids: []int{1}, names: []string{"a"}}), 2, nil, 0)
+  if err := s.Next(&row); err == nil || err.Error() != "functional: expected Tuple field 1 to be a *int, got a *string" {
+    t.Errorf("Expected type error, got %v", err)
+  }
+  s = CheckTuples(ReadRows(&fakeRows{ids: []int{1}, names: []string{"a"}}), 2)
+  if err := s.Next(new(int)); err == nil || err.Error() != "functional: expected a Tuple, got a *int" {
+    t.Errorf("Expected Tuple error, got %v", err)
+  }
+}
+
+func TestCheckTuplesTooManyTypes(t *testing.T) {
+  defer func() {
+    if recover() == nil {
+      t.Error("Expected CheckTuples to panic.")
+    }
+  }()
+  CheckTuples(ReadRows(&fakeRows{}), 2, 0, "", 0)
+}
+
+func TestCheckTuplesColumns(t *testing.T) {
+  rows := columnFakeRows{&fakeRows{ids: []int{1}, names: []string{"a"}}, []string{"id", "name", "extra"}}
+  s := CheckTuples(ReadRows(rows), 2)
+  var row intAndString
+  if err := s.Next(&row); err == nil || err.Error() != "functional: expected 2 columns, got 3" {
+    t.Errorf("Expected column count error, got %v", err)
+  }
+  if rows.idx != 0 {
+    t.Error("Expected no rows read.")
+  }
+  s = CheckTuples(ReadRowsColumns(rows, []int{0, 1}), 2)
+  if err := s.Next(&row); err == nil || err.Error() != "functional: expected 2 columns for indices, got 3" {
+    t.Errorf("Expected column count error, got %v", err)
+  }
+  rows.columns = rows.columns[:2]
+  s = CheckTuples(ReadRows(rows), 2)
+  if err := s.Next(&row); err != nil || row.id != 1 {
+    t.Errorf("Expected 1, got %v and %v", row, err)
+  }
+}
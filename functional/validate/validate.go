@@ -0,0 +1,182 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+// Package validate checks the values of a Stream against named rules and
+// reports which rules each value violates.
+package validate
+
+import (
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+)
+
+// Rule is a named check of T values.
+type Rule struct {
+  // Name identifies the rule in reports.
+  Name string
+  // Check returns nil if the T value its argument points to passes.
+  // Any other result, including functional.Skipped, is a violation.
+  Check functional.Filterer
+}
+
+// Violation describes a Rule that a value broke.
+type Violation struct {
+  // Rule is the name of the broken Rule.
+  Rule string
+  // Err is what the Rule's Check returned.
+  Err error
+}
+
+func (v Violation) String() string {
+  if v.Err == functional.Skipped {
+    return v.Rule
+  }
+  return fmt.Sprintf("%s: %v", v.Rule, v.Err)
+}
+
+// Report lists the violations of one value.
+type Report struct {
+  // Index is the 0-based position of the value in its Stream.
+  Index int
+  // Violations is empty if the value passed every Rule.
+  Violations []Violation
+}
+
+// Valid returns true if the value passed every Rule.
+func (r *Report) Valid() bool {
+  return len(r.Violations) == 0
+}
+
+// Validator checks T values against a set of Rules. Validator instances
+// hold no state and may be shared.
+type Validator struct {
+  rules []Rule
+}
+
+// New returns a Validator of T that checks every rule in rules.
+func New(rules ...Rule) *Validator {
+  return &Validator{rules: rules}
+}
+
+// Validate returns the violations of the T value ptr points to or nil if
+// there are none.
+func (v *Validator) Validate(ptr interface{}) []Violation {
+  var result []Violation
+  for i := range v.rules {
+    if err := v.rules[i].Check.Filter(ptr); err != nil {
+      result = append(result, Violation{Rule: v.rules[i].Name, Err: err})
+    }
+  }
+  return result
+}
+
+// Filterer returns a Filterer of T that includes only the values that pass
+// every Rule.
+func (v *Validator) Filterer() functional.Filterer {
+  return functional.NewFilterer(func(ptr interface{}) error {
+    for i := range v.rules {
+      if v.rules[i].Check.Filter(ptr) != nil {
+        return functional.Skipped
+      }
+    }
+    return nil
+  })
+}
+
+// Reports returns a Stream of Report with one Report for each value in s,
+// a Stream of T. ptr is a *T where values of s are temporarily held.
+// Calling Close on returned Stream closes s.
+func (v *Validator) Reports(s functional.Stream, ptr interface{}) functional.Stream {
+  return &reportStream{Stream: s, v: v, ptr: ptr}
+}
+
+// Consumer returns a ReportConsumer of T that accumulates the Reports
+// of invalid values, keeping at most maxReports of them.
+func (v *Validator) Consumer(ptr interface{}, maxReports int) *ReportConsumer {
+  return &ReportConsumer{v: v, ptr: ptr, maxReports: maxReports}
+}
+
+// ReportConsumer validates every value in a Stream of T and tallies the
+// violations.
+type ReportConsumer struct {
+  v *Validator
+  ptr interface{}
+  maxReports int
+  total int
+  invalid int
+  counts map[string]int
+  reports []Report
+  err error
+}
+
+// Consume validates the values of s, a Stream of T.
+func (c *ReportConsumer) Consume(s functional.Stream) {
+  defer s.Close()
+  c.total = 0
+  c.invalid = 0
+  c.counts = make(map[string]int)
+  c.reports = nil
+  c.err = nil
+  var err error
+  for err = s.Next(c.ptr); err == nil; err = s.Next(c.ptr) {
+    violations := c.v.Validate(c.ptr)
+    if len(violations) > 0 {
+      c.invalid++
+      for i := range violations {
+        c.counts[violations[i].Rule]++
+      }
+      if len(c.reports) < c.maxReports {
+        c.reports = append(
+            c.reports, Report{Index: c.total, Violations: violations})
+      }
+    }
+    c.total++
+  }
+  if err != functional.Done {
+    c.err = err
+  }
+}
+
+// Error returns any error from last call to Consume.
+func (c *ReportConsumer) Error() error {
+  return c.err
+}
+
+// Total returns the number of values validated.
+func (c *ReportConsumer) Total() int {
+  return c.total
+}
+
+// Invalid returns the number of values that broke at least one Rule.
+func (c *ReportConsumer) Invalid() int {
+  return c.invalid
+}
+
+// Counts returns how many values broke each Rule by Rule name.
+func (c *ReportConsumer) Counts() map[string]int {
+  return c.counts
+}
+
+// Reports returns the Reports of the first invalid values, at most
+// maxReports of them.
+func (c *ReportConsumer) Reports() []Report {
+  return c.reports
+}
+
+type reportStream struct {
+  functional.Stream
+  v *Validator
+  ptr interface{}
+  index int
+}
+
+func (s *reportStream) Next(ptr interface{}) error {
+  if err := s.Stream.Next(s.ptr); err != nil {
+    return err
+  }
+  *ptr.(*Report) = Report{Index: s.index, Violations: s.v.Validate(s.ptr)}
+  s.index++
+  return nil
+}
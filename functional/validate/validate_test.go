@@ -0,0 +1,90 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package validate
+
+import (
+  "errors"
+  "fmt"
+  "github.com/keep94/gofunctional2/functional"
+  "testing"
+)
+
+var (
+  tooBig = errors.New("too big")
+  otherError = errors.New("other")
+)
+
+func TestReports(t *testing.T) {
+  s := newValidator().Reports(
+      functional.NewStreamFromValues([]int{4, 3, 12, 11}, nil), new(int))
+  var results []string
+  var r Report
+  for s.Next(&r) == nil {
+    results = append(results, fmt.Sprintf("%d%v", r.Index, r.Violations))
+  }
+  expected := "[0[] 1[odd] 2[small: too big] 3[odd small: too big]]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestFilterer(t *testing.T) {
+  s := functional.Filter(
+      newValidator().Filterer(),
+      functional.NewStreamFromValues([]int{4, 3, 12, 6}, nil))
+  var results []int
+  var x int
+  for s.Next(&x) == nil {
+    results = append(results, x)
+  }
+  if output := fmt.Sprintf("%v", results); output != "[4 6]" {
+    t.Errorf("Expected [4 6], got %v", output)
+  }
+}
+
+func TestConsumer(t *testing.T) {
+  c := newValidator().Consumer(new(int), 1)
+  c.Consume(functional.NewStreamFromValues([]int{4, 3, 12, 11, 2}, nil))
+  if err := c.Error(); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if c.Total() != 5 || c.Invalid() != 3 {
+    t.Errorf("Expected 5 total 3 invalid, got %d %d", c.Total(), c.Invalid())
+  }
+  if output := fmt.Sprintf("%v", c.Counts()); output != "map[odd:2 small:2]" {
+    t.Errorf("Expected map[odd:2 small:2], got %v", output)
+  }
+  reports := c.Reports()
+  if len(reports) != 1 || reports[0].Index != 1 || reports[0].Valid() {
+    t.Errorf("Expected one report for index 1, got %v", reports)
+  }
+}
+
+func TestConsumerError(t *testing.T) {
+  c := newValidator().Consumer(new(int), 1)
+  c.Consume(functional.Map(
+      functional.NewMapper(func(src, dest interface{}) error {
+        return otherError
+      }),
+      functional.Count(),
+      new(int)))
+  if err := c.Error(); err != otherError {
+    t.Errorf("Expected otherError, got %v", err)
+  }
+}
+
+func newValidator() *Validator {
+  return New(
+      Rule{Name: "odd", Check: functional.NewBoolFilterer(func(ptr interface{}) bool {
+        return *ptr.(*int) % 2 == 0
+      })},
+      Rule{Name: "small", Check: functional.NewFilterer(func(ptr interface{}) error {
+        if *ptr.(*int) > 10 {
+          return tooBig
+        }
+        return nil
+      })})
+}
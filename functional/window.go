@@ -0,0 +1,159 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "sort"
+  "time"
+)
+
+// Window is the result of reducing the events that fall in one time
+// window.
+type Window struct {
+  // Start is the inclusive start of the window.
+  Start time.Time
+  // End is the exclusive end of the window.
+  End time.Time
+  // Count is the number of events in the window.
+  Count int
+  // Value is a *A holding the reduction of the events in the window.
+  Value interface{}
+}
+
+// TumblingWindows returns a Stream of Window that reduces the events of
+// s, a Stream of T, over consecutive, non-overlapping windows of length
+// size. It works like SlidingWindows with a slide equal to size, so each
+// event falls in exactly one window.
+func TumblingWindows(
+    size time.Duration,
+    timestamp func(ptr interface{}) time.Time,
+    s Stream,
+    ptr interface{},
+    creater Creater,
+    reduce func(accPtr, ptr interface{}) error) Stream {
+  return SlidingWindows(size, size, timestamp, s, ptr, creater, reduce)
+}
+
+// SlidingWindows returns a Stream of Window that reduces the events of s,
+// a Stream of T, over windows of length size that start every slide.
+// Window starts are multiples of slide since the zero time, so windows
+// line up with the minute, the hour, and so on. timestamp returns the
+// time of the event ptr points to; ptr is a *T providing storage for the
+// events of s. creater is a Creater of A that creates the starting value
+// for each window; reduce folds the event ptr points to into the A value
+// accPtr points to. An error from reduce is reported through Next.
+// size must be greater than 0; slide must be greater than 0 and no more
+// than size.
+//
+// s should be in timestamp order. A window is emitted once an event at
+// or after its End arrives or s ends, so only the windows an event can
+// still fall in are held in memory. Windows are emitted in order of
+// Start, and windows without events are not emitted. An event that
+// arrives late is left out of the windows that ended at or before the
+// latest event so far.
+// Calling Close on returned Stream closes s.
+func SlidingWindows(
+    size, slide time.Duration,
+    timestamp func(ptr interface{}) time.Time,
+    s Stream,
+    ptr interface{},
+    creater Creater,
+    reduce func(accPtr, ptr interface{}) error) Stream {
+  if size <= 0 {
+    panic("size must be greater than 0.")
+  }
+  if slide <= 0 || slide > size {
+    panic("slide must be greater than 0 and no more than size.")
+  }
+  return &windowStream{
+      Stream: s,
+      size: size,
+      slide: slide,
+      timestamp: timestamp,
+      ptr: ptr,
+      creater: creater,
+      reduce: reduce}
+}
+
+type windowStream struct {
+  Stream
+  size time.Duration
+  slide time.Duration
+  timestamp func(ptr interface{}) time.Time
+  ptr interface{}
+  creater Creater
+  reduce func(accPtr, ptr interface{}) error
+  // open holds the windows not yet emitted in order of Start.
+  open []*Window
+  // closed is the number of windows at the front of open that are
+  // ready to be emitted.
+  closed int
+  latest time.Time
+  exhausted bool
+}
+
+func (s *windowStream) Next(ptr interface{}) error {
+  for s.closed == 0 {
+    if s.exhausted {
+      return Done
+    }
+    err := s.Stream.Next(s.ptr)
+    if err == Done {
+      s.exhausted = true
+      s.closed = len(s.open)
+      continue
+    }
+    if err != nil {
+      return err
+    }
+    if err = s.add(s.ptr); err != nil {
+      return err
+    }
+  }
+  *ptr.(*Window) = *s.open[0]
+  s.open = s.open[1:]
+  s.closed--
+  return nil
+}
+
+func (s *windowStream) add(eventPtr interface{}) error {
+  t := s.timestamp(eventPtr)
+  if t.After(s.latest) {
+    s.latest = t
+  }
+  for start := t.Truncate(s.slide); start.Add(s.size).After(t); start = start.Add(-s.slide) {
+    if !start.Add(s.size).After(s.latest) {
+      // Every window from here back ended before the latest event.
+      break
+    }
+    w := s.window(start)
+    w.Count++
+    if err := s.reduce(w.Value, eventPtr); err != nil {
+      return err
+    }
+  }
+  for s.closed < len(s.open) && !s.open[s.closed].End.After(s.latest) {
+    s.closed++
+  }
+  return nil
+}
+
+// window returns the open window starting at start creating it if
+// needed. Since all windows have the same size, a window that has not
+// ended yet always sorts after the closed ones.
+func (s *windowStream) window(start time.Time) *Window {
+  i := sort.Search(len(s.open), func(i int) bool {
+    return !s.open[i].Start.Before(start)
+  })
+  if i < len(s.open) && s.open[i].Start.Equal(start) {
+    return s.open[i]
+  }
+  w := &Window{Start: start, End: start.Add(s.size), Value: s.creater()}
+  s.open = append(s.open, nil)
+  copy(s.open[i + 1:], s.open[i:])
+  s.open[i] = w
+  return w
+}
@@ -0,0 +1,77 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+  "time"
+)
+
+// addMinute sums the minutes of the clicks in a window.
+func addMinute(accPtr, ptr interface{}) error {
+  *accPtr.(*int) += ptr.(*click).minute
+  return nil
+}
+
+func windowStrings(t *testing.T, s Stream) []string {
+  var w Window
+  var results []string
+  var err error
+  for err = s.Next(&w); err == nil; err = s.Next(&w) {
+    results = append(
+        results,
+        fmt.Sprintf(
+            "%d-%d:%d:%d",
+            w.Start.Unix() / 60, w.End.Unix() / 60, w.Count, *w.Value.(*int)))
+  }
+  verifyDone(t, s, &w, err)
+  return results
+}
+
+func TestTumblingWindows(t *testing.T) {
+  clicks := []click{{"a", 0}, {"b", 4}, {"a", 5}, {"a", 17}, {"b", 19}}
+  s := &streamCloseChecker{
+      NewStreamFromValues(clicks, nil), &simpleCloseChecker{}}
+  stream := TumblingWindows(
+      5 * time.Minute, clickTime, s, new(click), newInt, addMinute)
+  results := windowStrings(t, stream)
+  if output := fmt.Sprintf("%v", results); output != "[0-5:2:4 5-10:1:5 15-20:2:36]" {
+    t.Errorf("Expected [0-5:2:4 5-10:1:5 15-20:2:36], got %v", output)
+  }
+  verifyCloseCalled(t, s)
+}
+
+func TestSlidingWindows(t *testing.T) {
+  clicks := []click{{"a", 1}, {"a", 3}, {"a", 6}, {"a", 2}, {"a", 9}}
+  stream := SlidingWindows(
+      4 * time.Minute,
+      2 * time.Minute,
+      clickTime,
+      NewStreamFromValues(clicks, nil),
+      new(click),
+      newInt,
+      addMinute)
+  results := windowStrings(t, stream)
+  // The click at minute 2 arrives after all its windows ended.
+  expected := "[-2-2:1:1 0-4:2:4 2-6:1:3 4-8:1:6 6-10:2:15 8-12:1:9]"
+  if output := fmt.Sprintf("%v", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestWindowsReduceError(t *testing.T) {
+  stream := TumblingWindows(
+      time.Minute,
+      clickTime,
+      NewStreamFromValues([]click{{"a", 0}}, nil),
+      new(click),
+      newInt,
+      func(accPtr, ptr interface{}) error { return mapError })
+  if err := stream.Next(new(Window)); err != mapError {
+    t.Errorf("Expected mapError, got %v", err)
+  }
+}
@@ -0,0 +1,104 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "strings"
+  "unicode/utf8"
+)
+
+// WrapLines returns a Stream of string that re-wraps the text in s, a
+// Stream of string, into lines of at most width runes. Words are the runs
+// of non-space characters in s, and they flow from one value of s to the
+// next, so a sentence split across several values of s is wrapped as if
+// it were one. Words in each emitted line are separated by a single
+// space. A value of s that is empty or all whitespace ends the current
+// paragraph: the line being built is emitted followed by an empty line.
+// A word longer than width is broken into pieces of width runes so that
+// no line is ever longer than width. width must be greater than 0.
+// Calling Close on returned Stream closes s.
+func WrapLines(width int, s Stream) Stream {
+  if width <= 0 {
+    panic("width must be greater than 0.")
+  }
+  return &wrapStream{Stream: s, width: width}
+}
+
+type wrapStream struct {
+  Stream
+  width int
+  // ready holds the finished lines not yet emitted.
+  ready []string
+  line []string
+  lineLen int
+  text string
+  exhausted bool
+}
+
+func (s *wrapStream) Next(ptr interface{}) error {
+  for len(s.ready) == 0 {
+    if s.exhausted {
+      return Done
+    }
+    err := s.Stream.Next(&s.text)
+    if err == Done {
+      s.exhausted = true
+      s.endLine()
+      continue
+    }
+    if err != nil {
+      return err
+    }
+    words := strings.Fields(s.text)
+    if len(words) == 0 {
+      s.endLine()
+      s.ready = append(s.ready, "")
+      continue
+    }
+    for _, word := range words {
+      s.addWord(word)
+    }
+  }
+  *ptr.(*string) = s.ready[0]
+  s.ready = s.ready[1:]
+  return nil
+}
+
+func (s *wrapStream) addWord(word string) {
+  wordLen := utf8.RuneCountInString(word)
+  for wordLen > s.width {
+    s.endLine()
+    i := 0
+    for n := 0; n < s.width; n++ {
+      _, size := utf8.DecodeRuneInString(word[i:])
+      i += size
+    }
+    s.ready = append(s.ready, word[:i])
+    word = word[i:]
+    wordLen -= s.width
+  }
+  if wordLen == 0 {
+    return
+  }
+  if len(s.line) > 0 && s.lineLen + 1 + wordLen > s.width {
+    s.endLine()
+  }
+  if len(s.line) > 0 {
+    s.lineLen++
+  }
+  s.line = append(s.line, word)
+  s.lineLen += wordLen
+}
+
+// endLine moves the line being built, if any, to the ready lines.
+func (s *wrapStream) endLine() {
+  if len(s.line) == 0 {
+    return
+  }
+  s.ready = append(s.ready, strings.Join(s.line, " "))
+  s.line = s.line[:0]
+  s.lineLen = 0
+}
@@ -0,0 +1,51 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "testing"
+)
+
+func TestWrapLines(t *testing.T) {
+  s := &streamCloseChecker{
+      NewStreamFromValues(
+          []string{
+              "the quick brown",
+              "fox jumps  over the",
+              "",
+              "lazy dog"},
+          nil),
+      &simpleCloseChecker{}}
+  stream := WrapLines(10, s)
+  results, err := toStringArray(stream)
+  expected := `["the quick" "brown fox" "jumps over" "the" "" "lazy dog"]`
+  if output := fmt.Sprintf("%q", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+  verifyDone(t, stream, new(string), err)
+  verifyCloseCalled(t, s)
+}
+
+func TestWrapLinesLongWord(t *testing.T) {
+  s := NewStreamFromValues([]string{"a ééééééé bc"}, nil)
+  results, _ := toStringArray(WrapLines(3, s))
+  expected := `["a" "ééé" "ééé" "é" "bc"]`
+  if output := fmt.Sprintf("%q", results); output != expected {
+    t.Errorf("Expected %v, got %v", expected, output)
+  }
+}
+
+func TestWrapLinesError(t *testing.T) {
+  s := Concat(
+      NewStreamFromValues([]string{"one two"}, nil),
+      errorStream{scanError})
+  stream := WrapLines(20, s)
+  var x string
+  if err := stream.Next(&x); err != scanError {
+    t.Errorf("Expected scanError, got %v", err)
+  }
+}
@@ -0,0 +1,235 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+  "fmt"
+  "reflect"
+  "runtime/debug"
+  "sync"
+  "sync/atomic"
+)
+
+// AutoClose returns a Stream that emits the same values as s but closes s
+// automatically the first time the Next method of s returns an error other
+// than Done. In that case, Next returns the original error, or if closing s
+// also fails, an error that reports both but still wraps the original
+// error for errors.Is. After that, Next returns Done and callers need not
+// call Close, though doing so is harmless and returns nil. Calling Close
+// on returned Stream closes s if it has not been closed already.
+func AutoClose(s Stream) Stream {
+  return &autoCloseStream{Stream: s}
+}
+
+// Single returns a Stream that emits the same values as s but panics if
+// Next or Close is called while another goroutine is in Next or Close, or
+// if Next is called after Close. Use it to find Streams that are shared
+// by accident. Calling Close more than once is allowed. Calling Close on
+// returned Stream closes s.
+func Single(s Stream) Stream {
+  return &singleStream{Stream: s}
+}
+
+// Synchronized returns a Stream that emits the same values as s but
+// whose Next and Close methods can be called from multiple goroutines
+// simultaneously. Each value of s is emitted to exactly one caller of Next,
+// so multiple workers can pull work items from the returned Stream. Once
+// the returned Stream is closed, Next returns Done to all callers rather
+// than calling Next on s. Calling Close on returned Stream closes s.
+func Synchronized(s Stream) Stream {
+  return &synchronizedStream{Stream: s}
+}
+
+// Safe returns a Stream that emits the same values as s but recovers
+// any panic that happens within the Next or Close method of s, such as a
+// failed type assertion in a Mapper or Filterer that s is built from.
+// Next and Close report a recovered panic as a *PanicError. Since a panic
+// may leave s in an inconsistent state, callers should normally close
+// the returned Stream rather than keep reading after getting a *PanicError.
+// Calling Close on returned Stream closes s.
+func Safe(s Stream) Stream {
+  return safeStream{s}
+}
+
+// PanicError reports a panic that Safe recovered.
+type PanicError struct {
+  // Value is the value passed to panic.
+  Value interface{}
+  // Stack is the stack trace of the goroutine at the time of the panic.
+  Stack []byte
+}
+
+func (e *PanicError) Error() string {
+  return fmt.Sprintf("functional: recovered panic: %v", e.Value)
+}
+
+// Unwrap returns Value if it is an error so that errors.Is and errors.As
+// can see through a *PanicError.
+func (e *PanicError) Unwrap() error {
+  err, _ := e.Value.(error)
+  return err
+}
+
+// Typed returns a Stream that emits the same values as s, a Stream of T,
+// but checks that the pointer passed to the first call of Next is a *T
+// where example is a T. If it is not, Next returns a descriptive error
+// without calling Next on s instead of s panicking deep within a Mapper or
+// Filterer. Calling Close on returned Stream closes s.
+func Typed(s Stream, example interface{}) Stream {
+  return &typedStream{Stream: s, ptrType: reflect.PtrTo(reflect.TypeOf(example))}
+}
+
+// TypedStrict works like Typed except that it checks the pointer passed
+// to every call of Next, not just the first. It is meant for development
+// and tests where the cost of the extra check does not matter.
+func TypedStrict(s Stream, example interface{}) Stream {
+  return &typedStream{Stream: s, ptrType: reflect.PtrTo(reflect.TypeOf(example)), strict: true}
+}
+
+type typedStream struct {
+  Stream
+  ptrType reflect.Type
+  strict bool
+  checked bool
+}
+
+func (s *typedStream) Next(ptr interface{}) error {
+  if s.strict || !s.checked {
+    if actual := reflect.TypeOf(ptr); actual != s.ptrType {
+      return fmt.Errorf("functional: Next expects a %v but got a %v", s.ptrType, actual)
+    }
+    s.checked = true
+  }
+  return s.Stream.Next(ptr)
+}
+
+type safeStream struct {
+  Stream
+}
+
+func (s safeStream) Next(ptr interface{}) (err error) {
+  defer recoverPanic(&err)
+  return s.Stream.Next(ptr)
+}
+
+func (s safeStream) Close() (err error) {
+  defer recoverPanic(&err)
+  return s.Stream.Close()
+}
+
+func recoverPanic(err *error) {
+  if r := recover(); r != nil {
+    *err = &PanicError{Value: r, Stack: debug.Stack()}
+  }
+}
+
+const (
+  singleIdle int32 = iota
+  singleBusy
+  singleClosed
+)
+
+type singleStream struct {
+  Stream
+  state int32
+  closeErr error
+}
+
+func (s *singleStream) Next(ptr interface{}) error {
+  if !atomic.CompareAndSwapInt32(&s.state, singleIdle, singleBusy) {
+    s.fail("Next")
+  }
+  defer atomic.StoreInt32(&s.state, singleIdle)
+  return s.Stream.Next(ptr)
+}
+
+func (s *singleStream) Close() error {
+  if atomic.CompareAndSwapInt32(&s.state, singleIdle, singleBusy) {
+    s.closeErr = s.Stream.Close()
+    atomic.StoreInt32(&s.state, singleClosed)
+    return s.closeErr
+  }
+  if atomic.LoadInt32(&s.state) == singleClosed {
+    return s.closeErr
+  }
+  panic("functional: Close called on Stream in use by another goroutine.")
+}
+
+func (s *singleStream) fail(method string) {
+  if atomic.LoadInt32(&s.state) == singleClosed {
+    panic("functional: " + method + " called on closed Stream.")
+  }
+  panic("functional: " + method + " called on Stream in use by another goroutine.")
+}
+
+type synchronizedStream struct {
+  Stream
+  mutex sync.Mutex
+  closed bool
+  closeErr error
+}
+
+func (s *synchronizedStream) Next(ptr interface{}) error {
+  s.mutex.Lock()
+  defer s.mutex.Unlock()
+  if s.closed {
+    return Done
+  }
+  return s.Stream.Next(ptr)
+}
+
+func (s *synchronizedStream) Close() error {
+  s.mutex.Lock()
+  defer s.mutex.Unlock()
+  if !s.closed {
+    s.closed = true
+    s.closeErr = s.Stream.Close()
+  }
+  return s.closeErr
+}
+
+type autoCloseStream struct {
+  Stream
+  closed bool
+  closeErr error
+}
+
+func (s *autoCloseStream) Next(ptr interface{}) error {
+  if s.closed {
+    return Done
+  }
+  err := s.Stream.Next(ptr)
+  if err == nil || err == Done {
+    return err
+  }
+  if cerr := s.Close(); cerr != nil {
+    // Next reports the close error, so Close need not report it again.
+    s.closeErr = nil
+    return &closeFailedError{err: err, closeErr: cerr}
+  }
+  return err
+}
+
+func (s *autoCloseStream) Close() error {
+  if !s.closed {
+    s.closed = true
+    s.closeErr = s.Stream.Close()
+  }
+  return s.closeErr
+}
+
+type closeFailedError struct {
+  err error
+  closeErr error
+}
+
+func (e *closeFailedError) Error() string {
+  return e.err.Error() + " (close also failed: " + e.closeErr.Error() + ")"
+}
+
+func (e *closeFailedError) Unwrap() error {
+  return e.err
+}
@@ -0,0 +1,195 @@
+// Copyright 2013 Travis Keep. All rights reserved.
+// Use of this source code is governed by a BSD-style
+// license that can be found in the LICENSE file or
+// at http://opensource.org/licenses/BSD-3-Clause.
+
+package functional
+
+import (
+    "errors"
+    "fmt"
+    "sync"
+    "testing"
+)
+
+func TestAutoClose(t *testing.T) {
+  stream := AutoClose(xrange(0, 3))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+}
+
+func TestAutoCloseOnError(t *testing.T) {
+  s := &streamCloseChecker{Filter(errFilterer, Count()), &simpleCloseChecker{noDupClose: true}}
+  stream := AutoClose(s)
+  if output := stream.Next(new(int)); output != filterError {
+    t.Errorf("Expected filterError got %v", output)
+  }
+  verifyCloseCalled(t, s)
+  if output := stream.Next(new(int)); output != Done {
+    t.Errorf("Expected Done got %v", output)
+  }
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestAutoCloseOnErrorCloseFails(t *testing.T) {
+  s := &streamCloseChecker{Filter(errFilterer, Count()), &simpleCloseChecker{closeError: closeError, noDupClose: true}}
+  stream := AutoClose(s)
+  output := stream.Next(new(int))
+  if !errors.Is(output, filterError) || output == filterError {
+    t.Errorf("Expected combined error wrapping filterError got %v", output)
+  }
+  if output := stream.Next(new(int)); output != Done {
+    t.Errorf("Expected Done, got %v", output)
+  }
+  closeVerifyResult(t, stream, nil)
+  closeVerifyResult(t, stream, nil)
+}
+
+func TestSingle(t *testing.T) {
+  stream := Single(xrange(0, 3))
+  results, err := toIntArray(stream)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  verifyDone(t, stream, new(int), err)
+  closeVerifyResult(t, stream, nil)
+  verifyPanics(t, func() { stream.Next(new(int)) })
+}
+
+func TestSingleConcurrent(t *testing.T) {
+  b := &blockingStream{entered: make(chan bool), release: make(chan bool)}
+  stream := Single(b)
+  go stream.Next(new(int))
+  <-b.entered
+  verifyPanics(t, func() { stream.Next(new(int)) })
+  verifyPanics(t, func() { stream.Close() })
+  close(b.release)
+}
+
+func TestSynchronized(t *testing.T) {
+  stream := Synchronized(xrange(0, 1000))
+  var wg sync.WaitGroup
+  sums := make([]int, 4)
+  counts := make([]int, 4)
+  for i := range sums {
+    wg.Add(1)
+    go func(i int) {
+      defer wg.Done()
+      var x int
+      for stream.Next(&x) == nil {
+        sums[i] += x
+        counts[i]++
+      }
+    }(i)
+  }
+  wg.Wait()
+  total, count := 0, 0
+  for i := range sums {
+    total += sums[i]
+    count += counts[i]
+  }
+  if total != 499500 || count != 1000 {
+    t.Errorf("Expected 499500 and 1000, got %v and %v", total, count)
+  }
+}
+
+func TestSynchronizedClose(t *testing.T) {
+  s := &streamCloseChecker{Count(), &simpleCloseChecker{noDupClose: true}}
+  stream := Synchronized(s)
+  closeVerifyResult(t, stream, nil)
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s)
+  if output := stream.Next(new(int)); output != Done {
+    t.Errorf("Expected Done got %v", output)
+  }
+}
+
+func verifyPanics(t *testing.T, f func()) {
+  defer func() {
+    if recover() == nil {
+      t.Error("Expected panic.")
+    }
+  }()
+  f()
+}
+
+// blockingStream blocks in Next until release is closed.
+type blockingStream struct {
+  entered chan bool
+  release chan bool
+}
+
+func (s *blockingStream) Next(ptr interface{}) error {
+  s.entered <- true
+  <-s.release
+  return Done
+}
+
+func (s *blockingStream) Close() error {
+  return nil
+}
+
+func TestSafe(t *testing.T) {
+  s := &streamCloseChecker{Slice(Count(), 0, 3), &simpleCloseChecker{}}
+  badMapper := NewMapper(func(srcPtr interface{}, destPtr interface{}) error {
+    if *srcPtr.(*int) == 1 {
+      panic(mapError)
+    }
+    *destPtr.(*int) = *srcPtr.(*int)
+    return nil
+  })
+  stream := Safe(Map(badMapper, s, new(int)))
+  var x int
+  if err := stream.Next(&x); err != nil || x != 0 {
+    t.Errorf("Expected 0 and no error, got %v and %v", x, err)
+  }
+  err := stream.Next(&x)
+  var pe *PanicError
+  if !errors.As(err, &pe) {
+    t.Fatalf("Expected PanicError, got %v", err)
+  }
+  if pe.Value != mapError || len(pe.Stack) == 0 {
+    t.Errorf("Expected mapError and a stack, got %v", pe.Value)
+  }
+  if !errors.Is(err, mapError) {
+    t.Error("Expected PanicError to wrap mapError.")
+  }
+  closeVerifyResult(t, stream, nil)
+  verifyCloseCalled(t, s)
+}
+
+func TestTyped(t *testing.T) {
+  s := Typed(Slice(Count(), 0, 3), 0)
+  var str string
+  if err := s.Next(&str); err == nil || err.Error() != "functional: Next expects a *int but got a *string" {
+    t.Errorf("Expected type error, got %v", err)
+  }
+  results, err := toIntArray(s)
+  if output := fmt.Sprintf("%v", results); output != "[0 1 2]" {
+    t.Errorf("Expected [0 1 2] got %v", output)
+  }
+  if err != Done {
+    t.Errorf("Expected Done, got %v", err)
+  }
+}
+
+func TestTypedStrict(t *testing.T) {
+  s := TypedStrict(Slice(Count(), 0, 3), 0)
+  var x int
+  var str string
+  if err := s.Next(&x); err != nil {
+    t.Errorf("Expected no error, got %v", err)
+  }
+  if err := s.Next(&str); err == nil {
+    t.Error("Expected type error on later Next.")
+  }
+  if err := s.Next(nil); err == nil || err.Error() != "functional: Next expects a *int but got a <nil>" {
+    t.Errorf("Expected type error, got %v", err)
+  }
+  if err := s.Next(&x); err != nil || x != 1 {
+    t.Errorf("Expected 1, got %v and %v", x, err)
+  }
+}